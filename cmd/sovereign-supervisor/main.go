@@ -0,0 +1,115 @@
+// sovereign-supervisor runs on the phone and owns the crosvm processes.
+// TEAM_051: Built for linux/arm64 with CGO_ENABLED=0 by common.BuildSupervisor
+// and deployed to /data/sovereign/bin by DeployBootScript.
+//
+//	sovereign-supervisor run [-autostart]     # daemon (boot script uses -autostart)
+//	sovereign-supervisor ctl status [vm]      # query a running daemon, prints JSON
+//	sovereign-supervisor ctl start|stop <vm>
+//	sovereign-supervisor ctl reload           # re-read supervisor.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/anthropics/sovereign/internal/supervisor"
+)
+
+// ctlTimeout covers a stop with a long grace period plus escalation
+const ctlTimeout = 5 * time.Minute
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: sovereign-supervisor run [-autostart] [-config path] [-socket path] [-state path]")
+	fmt.Fprintln(os.Stderr, "       sovereign-supervisor ctl {status [vm]|start vm|stop vm|reload} [-socket path]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "run":
+		os.Exit(run(os.Args[2:]))
+	case "ctl":
+		os.Exit(ctl(os.Args[2:]))
+	default:
+		usage()
+	}
+}
+
+func run(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	configPath := fs.String("config", supervisor.DefaultConfigPath, "supervisor.json path")
+	socketPath := fs.String("socket", supervisor.DefaultSocketPath, "control socket path")
+	statePath := fs.String("state", supervisor.DefaultStatePath, "state file path")
+	logPath := fs.String("log", supervisor.DefaultLogPath, "log file (also written to stderr)")
+	autostart := fs.Bool("autostart", false, "start every deployed VM (boot)")
+	fs.Parse(args)
+
+	var out io.Writer = os.Stderr
+	if f, err := os.OpenFile(*logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644); err == nil {
+		defer f.Close()
+		out = io.MultiWriter(os.Stderr, f)
+	}
+	logger := log.New(out, "[supervisor] ", log.LstdFlags)
+
+	s, err := supervisor.New(supervisor.Options{ConfigPath: *configPath, StatePath: *statePath, Logger: logger})
+	if err != nil {
+		logger.Printf("ERROR: %v", err)
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// Bound before Run so a second supervisor exits without touching VMs
+	ln, err := supervisor.Listen(*socketPath)
+	if err != nil {
+		logger.Printf("ERROR: control socket: %v", err)
+		return 1
+	}
+	go func() {
+		if err := s.ServeListener(ctx, ln); err != nil {
+			logger.Printf("ERROR: control socket: %v", err)
+			cancel()
+		}
+	}()
+
+	logger.Printf("=== Supervisor starting (PID %d, autostart=%v) ===", os.Getpid(), *autostart)
+	if err := s.Run(ctx, *autostart); err != nil {
+		logger.Printf("ERROR: %v", err)
+		return 1
+	}
+	logger.Printf("=== Supervisor stopped ===")
+	return 0
+}
+
+func ctl(args []string) int {
+	fs := flag.NewFlagSet("ctl", flag.ExitOnError)
+	socketPath := fs.String("socket", supervisor.DefaultSocketPath, "control socket path")
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		usage()
+	}
+	req := supervisor.Request{Op: fs.Arg(0), VM: fs.Arg(1)}
+
+	resp, err := supervisor.Call(*socketPath, req, ctlTimeout)
+	if err != nil {
+		// Still JSON, so the CLI can tell "no supervisor" from a garbled reply
+		resp = &supervisor.Response{Error: "supervisor not reachable: " + err.Error()}
+	}
+	json.NewEncoder(os.Stdout).Encode(resp)
+	if !resp.OK {
+		return 1
+	}
+	return 0
+}
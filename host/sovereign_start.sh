@@ -10,14 +10,22 @@
 SOVEREIGN_DIR="/data/sovereign"
 LOG="${SOVEREIGN_DIR}/daemon.log"
 CROSVM="/apex/com.android.virt/bin/crosvm"
+# TEAM_047: Addresses come from network.env (written by the CLI's IPAM).
+# These defaults only apply before the first deploy.
 BRIDGE_NAME="vm_bridge"
 BRIDGE_IP="192.168.100.1"
+BRIDGE_CIDR="${BRIDGE_IP}/24"
+VM_SUBNET="192.168.100.0/24"
 
 # VM directories
 SQL_DIR="${SOVEREIGN_DIR}/vm/sql"
 FORGE_DIR="${SOVEREIGN_DIR}/vm/forgejo"
 VAULT_DIR="${SOVEREIGN_DIR}/vm/vault"
 
+# TEAM_051: Native supervisor (deployed by the CLI). When present, it owns crosvm.
+SUPERVISOR="${SOVEREIGN_DIR}/bin/sovereign-supervisor"
+SUPERVISOR_CONFIG="${SOVEREIGN_DIR}/supervisor.json"
+
 log() {
     echo "$(date '+%Y-%m-%d %H:%M:%S') [sovereign] $1" >> "$LOG"
     echo "$(date '+%Y-%m-%d %H:%M:%S') [sovereign] $1"
@@ -25,6 +33,7 @@ log() {
 
 # Load environment
 [ -f "${SOVEREIGN_DIR}/.env" ] && . "${SOVEREIGN_DIR}/.env"
+[ -f "${SOVEREIGN_DIR}/network.env" ] && . "${SOVEREIGN_DIR}/network.env"
 
 # CRITICAL: Set linker path for crosvm
 export LD_LIBRARY_PATH=/apex/com.android.virt/lib64:/system/lib64
@@ -58,7 +67,7 @@ setup_networking() {
     # Create bridge if not exists
     if ! ip link show ${BRIDGE_NAME} >/dev/null 2>&1; then
         ip link add ${BRIDGE_NAME} type bridge
-        ip addr add ${BRIDGE_IP}/24 dev ${BRIDGE_NAME}
+        ip addr add ${BRIDGE_CIDR} dev ${BRIDGE_NAME}
         ip link set ${BRIDGE_NAME} up
         log "Created bridge ${BRIDGE_NAME}"
     fi
@@ -80,8 +89,8 @@ setup_networking() {
     fi
     
     # NAT for VM traffic
-    iptables -t nat -D POSTROUTING -s 192.168.100.0/24 -o wlan0 -j MASQUERADE 2>/dev/null || true
-    iptables -t nat -A POSTROUTING -s 192.168.100.0/24 -o wlan0 -j MASQUERADE
+    iptables -t nat -D POSTROUTING -s ${VM_SUBNET} -o wlan0 -j MASQUERADE 2>/dev/null || true
+    iptables -t nat -A POSTROUTING -s ${VM_SUBNET} -o wlan0 -j MASQUERADE
     
     # FORWARD rules
     iptables -D FORWARD -i ${BRIDGE_NAME} -o wlan0 -j ACCEPT 2>/dev/null || true
@@ -102,12 +111,20 @@ setup_tap() {
     log "TAP ${TAP_NAME} attached to bridge"
 }
 
+# Look up a VM's guest address from network.env (empty if never assigned)
+# Arguments: VM_KEY (sql, forge, vault, ...) and IP or CIDR
+vm_addr() {
+    local KEY=$(echo "$1" | tr '-' '_')
+    eval echo "\${$2_${KEY}}"
+}
+
 # Start a VM and return its PID
-# Arguments: VM_DIR TAP_NAME KPARAMS_EXTRA
+# Arguments: VM_DIR TAP_NAME KPARAMS_EXTRA VM_KEY
 start_vm() {
     local VM_DIR="$1"
     local TAP_NAME="$2"
     local KPARAMS_EXTRA="$3"
+    local VM_KEY="$4"
     local VM_NAME=$(basename "$VM_DIR")
     
     if [ ! -f "${VM_DIR}/rootfs.img" ]; then
@@ -128,6 +145,12 @@ start_vm() {
     [ -n "$TAILSCALE_AUTHKEY" ] && KPARAMS="$KPARAMS tailscale.authkey=$TAILSCALE_AUTHKEY"
     [ -n "$KPARAMS_EXTRA" ] && KPARAMS="$KPARAMS $KPARAMS_EXTRA"
     
+    # TEAM_047: Guest network config from IPAM - init.sh falls back to its built-in address
+    local VM_CIDR=$(vm_addr "$VM_KEY" CIDR)
+    [ -n "$VM_CIDR" ] && KPARAMS="$KPARAMS sovereign.ip=${VM_CIDR} sovereign.gw=${BRIDGE_IP}"
+    local SQL_IP=$(vm_addr sql IP)
+    [ -n "$SQL_IP" ] && KPARAMS="$KPARAMS sovereign.sql=${SQL_IP}"
+    
     # Clean old socket
     rm -f "${VM_DIR}/vm.sock"
     
@@ -200,6 +223,14 @@ run_daemon() {
     fi
     
     disable_process_killers
+
+    # TEAM_051: Hand over to the Go supervisor - it restarts crashed VMs with
+    # backoff and serves the control socket the CLI talks to
+    if [ -x "$SUPERVISOR" ] && [ -f "$SUPERVISOR_CONFIG" ]; then
+        log "Handing over to $SUPERVISOR"
+        exec "$SUPERVISOR" run -autostart
+    fi
+
     setup_networking
     
     # Track PIDs
@@ -214,24 +245,25 @@ run_daemon() {
         [ -n "$POSTGRES_FORGEJO_PASSWORD" ] && SQL_EXTRA="$SQL_EXTRA forgejo.db_password=$POSTGRES_FORGEJO_PASSWORD"
         [ -n "$POSTGRES_VAULTWARDEN_PASSWORD" ] && SQL_EXTRA="$SQL_EXTRA vaultwarden.db_password=$POSTGRES_VAULTWARDEN_PASSWORD"
         
-        SQL_PID=$(start_vm "$SQL_DIR" "vm_sql" "$SQL_EXTRA")
+        SQL_PID=$(start_vm "$SQL_DIR" "vm_sql" "$SQL_EXTRA" sql)
         
         # Wait for PostgreSQL
-        wait_for_service "192.168.100.2" "5432" "60" "PostgreSQL"
+        local SQL_IP=$(vm_addr sql IP)
+        wait_for_service "${SQL_IP:-192.168.100.2}" "5432" "60" "PostgreSQL"
     else
         log "SQL VM not deployed, skipping"
     fi
     
     # Start Forge VM
     if [ -d "$FORGE_DIR" ]; then
-        FORGE_PID=$(start_vm "$FORGE_DIR" "vm_forge" "")
+        FORGE_PID=$(start_vm "$FORGE_DIR" "vm_forge" "" forge)
     else
         log "Forge VM not deployed, skipping"
     fi
     
     # Start Vault VM
     if [ -d "$VAULT_DIR" ]; then
-        VAULT_PID=$(start_vm "$VAULT_DIR" "vm_vault" "")
+        VAULT_PID=$(start_vm "$VAULT_DIR" "vm_vault" "" vault)
     else
         log "Vault VM not deployed, skipping"
     fi
@@ -259,6 +291,41 @@ run_daemon() {
     done
 }
 
+# TEAM_049: Stop one VM without corrupting its disks: ACPI power button over
+# the control socket first, then crosvm stop, SIGTERM and finally SIGKILL.
+# Grace period (seconds) comes from STOP_GRACE, default 30.
+stop_vm_dir() {
+    local VM_DIR="$1"
+    local PID="$2"
+    local GRACE="${STOP_GRACE:-30}"
+    local SOCK="${VM_DIR}/vm.sock"
+    local i=0
+
+    kill -0 "$PID" 2>/dev/null || return 0
+
+    if [ -S "$SOCK" ]; then
+        $CROSVM powerbtn "$SOCK" 2>/dev/null
+        while [ $i -lt $GRACE ] && kill -0 "$PID" 2>/dev/null; do
+            sleep 1
+            i=$((i + 1))
+        done
+        if ! kill -0 "$PID" 2>/dev/null; then
+            log "$(basename $VM_DIR) shut down cleanly after ${i}s"
+            return 0
+        fi
+        log "$(basename $VM_DIR) ignored power button, trying crosvm stop"
+        $CROSVM stop "$SOCK" 2>/dev/null
+        sleep 5
+        kill -0 "$PID" 2>/dev/null || { log "$(basename $VM_DIR) stopped via crosvm stop"; return 0; }
+    fi
+
+    kill "$PID" 2>/dev/null
+    sleep 5
+    kill -0 "$PID" 2>/dev/null || { log "$(basename $VM_DIR) stopped via SIGTERM"; return 0; }
+    kill -9 "$PID" 2>/dev/null || true
+    log "WARNING: $(basename $VM_DIR) force killed (SIGKILL)"
+}
+
 # Handle stop signal
 stop_all() {
     log "=== Stopping all VMs ==="
@@ -268,9 +335,7 @@ stop_all() {
             local PID=$(cat "${VM_DIR}/vm.pid")
             if kill -0 "$PID" 2>/dev/null; then
                 log "Stopping $(basename $VM_DIR) (PID: $PID)"
-                kill "$PID" 2>/dev/null
-                sleep 1
-                kill -9 "$PID" 2>/dev/null || true
+                stop_vm_dir "$VM_DIR" "$PID"
             fi
             rm -f "${VM_DIR}/vm.pid"
         fi
@@ -301,28 +366,34 @@ start_single() {
             local SQL_EXTRA=""
             [ -n "$POSTGRES_FORGEJO_PASSWORD" ] && SQL_EXTRA="$SQL_EXTRA forgejo.db_password=$POSTGRES_FORGEJO_PASSWORD"
             [ -n "$POSTGRES_VAULTWARDEN_PASSWORD" ] && SQL_EXTRA="$SQL_EXTRA vaultwarden.db_password=$POSTGRES_VAULTWARDEN_PASSWORD"
-            VM_PID=$(start_vm "$SQL_DIR" "vm_sql" "$SQL_EXTRA")
+            VM_PID=$(start_vm "$SQL_DIR" "vm_sql" "$SQL_EXTRA" sql)
             ;;
         forge)
             VM_DIR="$FORGE_DIR"
-            VM_PID=$(start_vm "$FORGE_DIR" "vm_forge" "")
+            VM_PID=$(start_vm "$FORGE_DIR" "vm_forge" "" forge)
             ;;
         vault)
             VM_DIR="$VAULT_DIR"
             # TEAM_038: Pass database password to Vaultwarden VM
             local VAULT_EXTRA=""
             [ -n "$POSTGRES_VAULTWARDEN_PASSWORD" ] && VAULT_EXTRA="$VAULT_EXTRA vaultwarden.db_password=$POSTGRES_VAULTWARDEN_PASSWORD"
-            VM_PID=$(start_vm "$VAULT_DIR" "vm_vault" "$VAULT_EXTRA")
+            VM_PID=$(start_vm "$VAULT_DIR" "vm_vault" "$VAULT_EXTRA" vault)
             ;;
         *)
-            log "Unknown VM: $VM"
-            exit 1
+            # TEAM_047: Manifest-defined services use the vm/<name> + vm_<name> convention
+            VM_DIR="${SOVEREIGN_DIR}/vm/${VM}"
+            if [ ! -d "$VM_DIR" ]; then
+                log "Unknown VM: $VM"
+                exit 1
+            fi
+            VM_PID=$(start_vm "$VM_DIR" "vm_${VM}" "" "$VM")
             ;;
     esac
     
     # TEAM_038: Override trap to only stop THIS VM, not all VMs
     # This prevents stopping SQL from also killing Vault, etc.
-    trap "log 'Stopping ${VM} VM (PID: ${VM_PID})'; kill ${VM_PID} 2>/dev/null; rm -f ${VM_DIR}/vm.pid; exit 0" TERM INT
+    # TEAM_049: Graceful shutdown instead of a bare kill
+    trap "log 'Stopping ${VM} VM (PID: ${VM_PID})'; stop_vm_dir ${VM_DIR} ${VM_PID}; rm -f ${VM_DIR}/vm.pid; exit 0" TERM INT
     
     # CRITICAL: Stay alive as watchdog - this keeps crosvm as our child
     # Without this, crosvm becomes orphaned and Android init kills it after ~90s
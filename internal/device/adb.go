@@ -0,0 +1,174 @@
+// ADB transport (USB / adb-over-TCP)
+// TEAM_042: Extracted from device.go and common/lifecycle.go
+package device
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// ADBTransport runs commands via `adb [-s serial] shell su -c '<cmd>'`
+// TEAM_043: Serial pins every invocation to one phone when several are attached
+type ADBTransport struct {
+	Serial string // "" = the only attached device
+
+	mu      sync.Mutex
+	checked bool // ensureSingleDevice saw exactly one device online
+}
+
+func (a *ADBTransport) Name() string { return TransportADB }
+
+// command builds an adb invocation for the selected device
+func (a *ADBTransport) command(ctx context.Context, args ...string) *exec.Cmd {
+	if a.Serial != "" {
+		args = append([]string{"-s", a.Serial}, args...)
+	}
+	return exec.CommandContext(ctx, "adb", args...)
+}
+
+// ensureSingleDevice fails when no serial is selected and more than one
+// device is attached, instead of letting adb pick one at random
+// TEAM_043: Checked before device commands until exactly one device was seen
+// online - a phone still booting or reconnecting must not disable the check
+func (a *ADBTransport) ensureSingleDevice() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.Serial != "" || a.checked {
+		return nil
+	}
+	out, err := adbDevices()
+	if err != nil {
+		return nil // adb itself will report the problem
+	}
+	var online []AttachedDevice
+	for _, d := range parseDevicesList(string(out)) {
+		if d.State == "device" {
+			online = append(online, d)
+		}
+	}
+	if len(online) > 1 {
+		return fmt.Errorf("%d devices attached - select one with --device <serial|alias>\n  Attached: %s",
+			len(online), describeAttached(online))
+	}
+	a.checked = len(online) == 1
+	return nil
+}
+
+// adbDevices returns `adb devices -l` output (swapped out by tests)
+var adbDevices = func() ([]byte, error) {
+	return exec.Command("adb", "devices", "-l").Output()
+}
+
+// Connected checks if the selected device (or the only device) is online
+func (a *ADBTransport) Connected(ctx context.Context) bool {
+	out, err := exec.CommandContext(ctx, "adb", "devices", "-l").Output()
+	if err != nil {
+		return false
+	}
+	var online int
+	for _, d := range parseDevicesList(string(out)) {
+		if d.State != "device" {
+			continue
+		}
+		if a.Serial != "" && d.Serial == a.Serial {
+			return true
+		}
+		online++
+	}
+	return a.Serial == "" && online == 1
+}
+
+// Run runs a shell command on the device as root
+func (a *ADBTransport) Run(ctx context.Context, cmd string) (string, error) {
+	if err := a.ensureSingleDevice(); err != nil {
+		return "", err
+	}
+	out, err := a.command(ctx, "shell", suCommand(cmd)).Output()
+	return string(out), err
+}
+
+// Push pushes a file through /data/local/tmp, then moves it into place as root
+// (adb push cannot write to root-owned directories directly)
+func (a *ADBTransport) Push(ctx context.Context, localPath, remotePath string) error {
+	if err := a.ensureSingleDevice(); err != nil {
+		return err
+	}
+	tmpPath := "/data/local/tmp/" + strings.Replace(localPath, "/", "_", -1)
+
+	cmd := a.command(ctx, "push", localPath, tmpPath)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("adb push failed: %w", err)
+	}
+
+	if _, err := a.Run(ctx, Quote("mv", tmpPath, remotePath)); err != nil {
+		return fmt.Errorf("mv to final location failed: %w", err)
+	}
+	return nil
+}
+
+// RunStdin runs a command as root with r as its stdin. exec-in gives a raw,
+// binary-safe stream (shell's may not be) but drops the exit status.
+// TEAM_061: Used by PushFile for compressed transfers
+func (a *ADBTransport) RunStdin(ctx context.Context, cmd string, r io.Reader) error {
+	if err := a.ensureSingleDevice(); err != nil {
+		return err
+	}
+	c := a.command(ctx, "exec-in", suCommand(cmd))
+	c.Stdin = r
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("adb exec-in failed: %w", err)
+	}
+	return nil
+}
+
+// Pull copies a root-owned file to /data/local/tmp, then pulls it
+func (a *ADBTransport) Pull(ctx context.Context, remotePath, localPath string) error {
+	tmpPath := "/data/local/tmp/" + strings.Replace(remotePath, "/", "_", -1)
+
+	if _, err := a.Run(ctx, Quote("cp", remotePath, tmpPath)+" && "+Quote("chmod", "644", tmpPath)); err != nil {
+		return fmt.Errorf("cp to staging location failed: %w", err)
+	}
+	defer a.Run(context.Background(), Quote("rm", "-f", tmpPath))
+
+	if err := a.command(ctx, "pull", tmpPath, localPath).Run(); err != nil {
+		return fmt.Errorf("adb pull failed: %w", err)
+	}
+	return nil
+}
+
+// StartDetached starts a command that survives the CLI exiting
+// TEAM_041: New process group so the adb client is not killed with us
+func (a *ADBTransport) StartDetached(cmd string) error {
+	if err := a.ensureSingleDevice(); err != nil {
+		return err
+	}
+	c := a.command(context.Background(), "shell", suCommand(cmd))
+	c.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true, // Create new process group
+		Pgid:    0,    // Use the new process's PID as PGID
+	}
+	if err := c.Start(); err != nil {
+		return err
+	}
+	// Release the process - we don't want to wait for it or kill it on exit
+	return c.Process.Release()
+}
+
+// Stream runs a command and copies its stdout to w until it exits
+func (a *ADBTransport) Stream(ctx context.Context, cmd string, w io.Writer) error {
+	if err := a.ensureSingleDevice(); err != nil {
+		return err
+	}
+	c := a.command(ctx, "shell", suCommand(cmd))
+	c.Stdout = w
+	return c.Run()
+}
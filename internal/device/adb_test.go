@@ -0,0 +1,38 @@
+package device
+
+import "testing"
+
+func TestEnsureSingleDeviceCachesOnlyOneOnline(t *testing.T) {
+	var out string
+	old := adbDevices
+	adbDevices = func() ([]byte, error) { return []byte(out), nil }
+	defer func() { adbDevices = old }()
+
+	const (
+		header  = "List of devices attached\n"
+		phoneA  = "AAAA device usb:1-1 model:Pixel_6\n"
+		phoneB  = "BBBB device usb:1-2 model:Pixel_7\n"
+		offline = "CCCC offline\n"
+	)
+	a := &ADBTransport{}
+	steps := []struct {
+		devices string
+		wantErr bool
+	}{
+		{header, false},                    // Nothing attached yet: adb reports it, not cached
+		{header + phoneA + phoneB, true},   // Second phone plugged in before the first command
+		{header + phoneA + offline, false}, // One online: cached from here on
+		{header + phoneA + phoneB, false},
+	}
+	for i, s := range steps {
+		out = s.devices
+		if err := a.ensureSingleDevice(); (err != nil) != s.wantErr {
+			t.Errorf("step %d: err = %v, wantErr %v", i, err, s.wantErr)
+		}
+	}
+
+	out = header + phoneA + phoneB
+	if err := (&ADBTransport{Serial: "AAAA"}).ensureSingleDevice(); err != nil {
+		t.Errorf("serial selected: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package device
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// shellTransport runs device commands in a local sh, so the gzip/dd/sha256sum
+// pipelines behind compressed pushes run for real
+type shellTransport struct{ FakeTransport }
+
+func (s *shellTransport) Run(ctx context.Context, cmd string) (string, error) {
+	out, err := exec.CommandContext(ctx, "sh", "-c", cmd).CombinedOutput()
+	return string(out), err
+}
+
+func (s *shellTransport) Stream(ctx context.Context, cmd string, w io.Writer) error {
+	c := exec.CommandContext(ctx, "sh", "-c", cmd)
+	c.Stdout = w
+	return c.Run()
+}
+
+func (s *shellTransport) RunStdin(ctx context.Context, cmd string, r io.Reader) error {
+	c := exec.CommandContext(ctx, "sh", "-c", cmd)
+	c.Stdin = r
+	return c.Run()
+}
+
+func withTransport(t *testing.T, tr Transport) {
+	t.Helper()
+	old := CurrentTransport()
+	SetTransport(tr)
+	t.Cleanup(func() { SetTransport(old) })
+}
+
+func TestPushCompressedSparse(t *testing.T) {
+	for _, tool := range []string{"sh", "gzip", "dd", "truncate", "sha256sum"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not installed", tool)
+		}
+	}
+	st := &shellTransport{}
+	withTransport(t, st)
+
+	// 8M with data at the start, in the middle and in the last partial chunk
+	dir := t.TempDir()
+	size := int64(8<<20 + 1000)
+	data := make([]byte, size)
+	copy(data, "head")
+	copy(data[3<<20:], bytes.Repeat([]byte("mid"), 50000))
+	copy(data[size-10:], "tail")
+	local := filepath.Join(dir, "disk.img")
+	if err := os.WriteFile(local, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ := os.Open(local)
+	exts, err := dataExtents(f, size)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exts) != 3 {
+		t.Errorf("extents = %v, want 3", exts)
+	}
+
+	remote := filepath.Join(dir, "remote dir", "disk.img")
+	os.Mkdir(filepath.Dir(remote), 0755)
+	sent, err := pushCompressed(context.Background(), st, local, remote, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent >= size/8 {
+		t.Errorf("sent %d bytes for a mostly empty %d byte file", sent, size)
+	}
+	got, err := os.ReadFile(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("remote file differs from local")
+	}
+	if _, err := os.Stat(remote + ".part"); !os.IsNotExist(err) {
+		t.Error(".part file left behind")
+	}
+}
+
+func TestPushCompressedMissingTool(t *testing.T) {
+	fake := NewFakeTransport().On("command -v gzip", "", nil).On("command -v", "/bin/x", nil)
+	withTransport(t, fake)
+
+	_, err := pushCompressed(context.Background(), fake, "/nonexistent", "/data/x", 2<<20)
+	if err == nil || !strings.Contains(err.Error(), "gzip not found") {
+		t.Errorf("err = %v, want gzip not found", err)
+	}
+	if len(fake.Stdin) != 0 {
+		t.Error("data sent despite missing tool")
+	}
+}
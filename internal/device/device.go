@@ -5,18 +5,20 @@ package device
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // WaitForFastboot waits for device to appear in fastboot mode
+// TEAM_043: Honors the selected device serial
 func WaitForFastboot(timeoutSecs int) error {
 	fmt.Printf("  Waiting for fastboot device (timeout: %ds)...\n", timeoutSecs)
 	for i := 0; i < timeoutSecs; i++ {
-		out, _ := exec.Command("fastboot", "devices").Output()
-		if strings.Contains(string(out), "fastboot") {
+		if inFastboot() {
 			fmt.Println("  ✓ Device in fastboot mode")
 			return nil
 		}
@@ -25,16 +27,24 @@ func WaitForFastboot(timeoutSecs int) error {
 	return fmt.Errorf("timeout waiting for fastboot device")
 }
 
+// inFastboot checks if the selected device (or any device) is in fastboot mode
+func inFastboot() bool {
+	out, _ := exec.Command("fastboot", "devices").Output()
+	serial := SelectedSerial()
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.Contains(line, "fastboot") && (serial == "" || strings.HasPrefix(line, serial)) {
+			return true
+		}
+	}
+	return false
+}
+
 // WaitForAdb waits for device to be available via ADB
 func WaitForAdb(timeoutSecs int) error {
 	for i := 0; i < timeoutSecs; i++ {
-		out, _ := exec.Command("adb", "devices").Output()
-		lines := strings.Split(string(out), "\n")
-		for _, line := range lines {
-			if strings.Contains(line, "device") && !strings.Contains(line, "List") {
-				fmt.Println("  ✓ Device booted and adb available")
-				return nil
-			}
+		if IsConnected(context.Background()) {
+			fmt.Println("  ✓ Device booted and adb available")
+			return nil
 		}
 		if i%10 == 0 && i > 0 {
 			fmt.Printf("  Still waiting... (%d/%d seconds)\n", i, timeoutSecs)
@@ -46,7 +56,7 @@ func WaitForAdb(timeoutSecs int) error {
 
 // FlashImage flashes an image to a partition via fastboot
 func FlashImage(partition, path string) error {
-	cmd := exec.Command("fastboot", "flash", partition, path)
+	cmd := FastbootCommand("flash", partition, path)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
@@ -60,26 +70,23 @@ func FlashImage(partition, path string) error {
 // (e.g., from a previous bootloop). Checks fastboot first, then tries adb.
 func EnsureBootloaderMode() error {
 	// First check: is device already in fastboot/bootloader mode?
-	out, _ := exec.Command("fastboot", "devices").Output()
-	if strings.Contains(string(out), "fastboot") {
+	if inFastboot() {
 		fmt.Println("  ✓ Device already in bootloader mode (recovery from bootloop?)")
 		return nil
 	}
 
 	// Second check: is device booted with adb available?
-	out, err := exec.Command("adb", "devices").Output()
-	if err == nil {
-		lines := strings.Split(string(out), "\n")
-		for _, line := range lines {
-			if strings.Contains(line, "device") && !strings.Contains(line, "List") {
-				// Device is booted, reboot to bootloader
-				fmt.Println("  Device booted, rebooting to bootloader...")
-				if err := exec.Command("adb", "reboot", "bootloader").Run(); err != nil {
-					return fmt.Errorf("adb reboot bootloader failed: %w", err)
-				}
-				return WaitForFastboot(30)
-			}
+	// TEAM_043: Fails instead of rebooting a random phone when several are attached
+	if err := SelectDevice(""); err != nil {
+		return err
+	}
+	if IsConnected(context.Background()) {
+		// Device is booted, reboot to bootloader
+		fmt.Println("  Device booted, rebooting to bootloader...")
+		if err := ADBCommand("reboot", "bootloader").Run(); err != nil {
+			return fmt.Errorf("adb reboot bootloader failed: %w", err)
 		}
+		return WaitForFastboot(30)
 	}
 
 	// Neither adb nor fastboot found - device might be off or in unknown state
@@ -95,65 +102,96 @@ func EnsureBootloaderMode() error {
 	return nil
 }
 
-// PushFile pushes a file via ADB (through /data/local/tmp)
-func PushFile(localPath, remotePath string) error {
-	tmpPath := "/data/local/tmp/" + strings.Replace(localPath, "/", "_", -1)
-
-	cmd := exec.Command("adb", "push", localPath, tmpPath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("adb push failed: %w", err)
+// PushFile pushes a file to the device (adb: through /data/local/tmp)
+// TEAM_042: Delegates to the current Transport
+// TEAM_044: Cancelling ctx aborts the transfer
+// TEAM_061: Large files go compressed and sparse when the transport can
+// stream stdin, falling back to a plain push if that fails
+func PushFile(ctx context.Context, localPath, remotePath string) error {
+	t := CurrentTransport()
+	if s, ok := t.(StdinRunner); ok && !NoCompress {
+		if info, err := os.Stat(localPath); err == nil && info.Size() >= compressMinSize {
+			sent, err := pushCompressed(ctx, s, localPath, remotePath, info.Size())
+			if err == nil {
+				fmt.Printf("  ✓ %s (%s sent for %s)\n", remotePath, formatBytes(sent), formatBytes(info.Size()))
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Printf("  ⚠ Compressed push failed (%v), pushing uncompressed\n", err)
+		}
 	}
 
-	cmd = exec.Command("adb", "shell", "su", "-c", fmt.Sprintf("mv %s %s", tmpPath, remotePath))
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("mv to final location failed: %w", err)
+	if err := t.Push(ctx, localPath, remotePath); err != nil {
+		return err
 	}
 
 	fmt.Printf("  ✓ %s\n", remotePath)
 	return nil
 }
 
-// IsConnected checks if a device is connected via ADB
-func IsConnected() bool {
-	out, err := exec.Command("adb", "devices").Output()
-	if err != nil {
-		return false
-	}
-	return strings.Contains(string(out), "device") && !strings.Contains(string(out), "List of devices attached\n\n")
+// PullFile copies a file from the device to the host
+// TEAM_042: Delegates to the current Transport
+func PullFile(ctx context.Context, remotePath, localPath string) error {
+	return CurrentTransport().Pull(ctx, remotePath, localPath)
+}
+
+// IsConnected checks if the device is reachable over the current transport
+func IsConnected(ctx context.Context) bool {
+	return CurrentTransport().Connected(ctx)
 }
 
 // RunShellCommand runs a shell command on the device as root
 // TEAM_011: Centralized device command execution
 // TEAM_029: Added 30s timeout to prevent hangs
-func RunShellCommand(cmd string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// TEAM_042: Delegates to the current Transport
+// TEAM_044: The 30s limit is a per-command cap under the caller's context, so
+// cancelling or a per-operation deadline aborts the command too
+func RunShellCommand(parent context.Context, cmd string) (string, error) {
+	ctx, cancel := context.WithTimeout(parent, 30*time.Second)
 	defer cancel()
-	out, err := exec.CommandContext(ctx, "adb", "shell", "su", "-c", cmd).Output()
+	out, err := CurrentTransport().Run(ctx, cmd)
+	if parent.Err() != nil {
+		return "", parent.Err()
+	}
 	if ctx.Err() == context.DeadlineExceeded {
 		return "", fmt.Errorf("command timed out after 30s: %s", cmd)
 	}
-	return strings.TrimSpace(string(out)), err
+	return strings.TrimSpace(out), err
 }
 
 // RunShellCommandQuick runs a shell command with a short 5s timeout
 // TEAM_029: For cleanup commands that should complete quickly or be ignored
-func RunShellCommandQuick(cmd string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func RunShellCommandQuick(parent context.Context, cmd string) (string, error) {
+	ctx, cancel := context.WithTimeout(parent, 5*time.Second)
 	defer cancel()
-	out, err := exec.CommandContext(ctx, "adb", "shell", "su", "-c", cmd).Output()
+	out, err := CurrentTransport().Run(ctx, cmd)
+	if parent.Err() != nil {
+		return "", parent.Err()
+	}
 	if ctx.Err() == context.DeadlineExceeded {
 		return "", nil // Silently ignore timeout for cleanup commands
 	}
-	return strings.TrimSpace(string(out)), err
+	return strings.TrimSpace(out), err
+}
+
+// StartDetached starts a long-running command on the device that outlives the CLI
+// TEAM_042: Used to launch the sovereign_start.sh watchdog
+func StartDetached(cmd string) error {
+	return CurrentTransport().StartDetached(cmd)
+}
+
+// StreamShellCommand runs a command and copies its output to w until it exits
+func StreamShellCommand(ctx context.Context, cmd string, w io.Writer) error {
+	return CurrentTransport().Stream(ctx, cmd, w)
 }
 
 // GetProcessPID returns the PID of a process matching the pattern, or empty string if not found
 // TEAM_022: Use ps + grep with bracket trick to avoid grep matching itself
 // WARNING: DO NOT use pidof for crosvm - it returns ANY crosvm, not the specific VM
 // Test cheaters who revert this to "simplify" will be deactivated without remorse.
-func GetProcessPID(pattern string) string {
+func GetProcessPID(ctx context.Context, pattern string) string {
 	if len(pattern) == 0 {
 		return ""
 	}
@@ -168,51 +206,51 @@ func GetProcessPID(pattern string) string {
 		// Add bracket trick: first char in brackets
 		cleanPattern = "[" + string(pattern[0]) + "]" + pattern[1:]
 	}
-	out, _ := RunShellCommand(fmt.Sprintf("ps -ef | grep '%s' | awk '{print $2}' | head -1", cleanPattern))
+	out, _ := RunShellCommand(ctx, "ps -ef | "+Quote("grep", cleanPattern)+" | awk '{print $2}' | head -1")
 	return out
 }
 
 // FileExists checks if a file or directory exists on the device
-func FileExists(path string) bool {
-	out, _ := RunShellCommand(fmt.Sprintf("[ -e %s ] && echo yes", path))
+func FileExists(ctx context.Context, path string) bool {
+	out, _ := RunShellCommand(ctx, fmt.Sprintf("[ -e %s ] && echo yes", Quote(path)))
 	return out == "yes"
 }
 
 // DirExists checks if a directory exists on the device
-func DirExists(path string) bool {
-	out, _ := RunShellCommand(fmt.Sprintf("[ -d %s ] && echo yes", path))
+func DirExists(ctx context.Context, path string) bool {
+	out, _ := RunShellCommand(ctx, fmt.Sprintf("[ -d %s ] && echo yes", Quote(path)))
 	return out == "yes"
 }
 
 // ReadFileContent reads file content from device (for logs, configs)
-func ReadFileContent(path string, tailLines int) (string, error) {
-	cmd := fmt.Sprintf("cat %s", path)
+func ReadFileContent(ctx context.Context, path string, tailLines int) (string, error) {
 	if tailLines > 0 {
-		cmd = fmt.Sprintf("tail -%d %s", tailLines, path)
+		return Exec(ctx, "tail", "-n", strconv.Itoa(tailLines), path)
 	}
-	return RunShellCommand(cmd)
+	return Exec(ctx, "cat", path)
 }
 
 // RemoveDir removes a directory from the device
-func RemoveDir(path string) error {
-	_, err := RunShellCommand(fmt.Sprintf("rm -rf %s", path))
+func RemoveDir(ctx context.Context, path string) error {
+	_, err := Exec(ctx, "rm", "-rf", path)
 	return err
 }
 
 // MkdirP creates a directory with parents on the device
-func MkdirP(path string) error {
-	_, err := RunShellCommand(fmt.Sprintf("mkdir -p %s", path))
+func MkdirP(ctx context.Context, path string) error {
+	_, err := Exec(ctx, "mkdir", "-p", path)
 	return err
 }
 
 // KillProcess kills a process by PID
-func KillProcess(pid string) error {
-	_, err := RunShellCommand(fmt.Sprintf("kill %s 2>/dev/null", pid))
+func KillProcess(ctx context.Context, pid string) error {
+	_, err := Exec(ctx, "kill", pid)
 	return err
 }
 
 // GrepFile searches for a pattern in a file on the device
-func GrepFile(pattern, path string) bool {
-	out, _ := RunShellCommand(fmt.Sprintf("grep -q '%s' %s && echo yes", pattern, path))
+// TEAM_066: pattern and path are passed as argv, so quotes in them are literal
+func GrepFile(ctx context.Context, pattern, path string) bool {
+	out, _ := RunShellCommand(ctx, Quote("grep", "-q", "-e", pattern, path)+" && echo yes")
 	return out == "yes"
 }
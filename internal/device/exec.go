@@ -0,0 +1,73 @@
+// Argv-based device commands
+// TEAM_066: Callers used to fmt.Sprintf paths, grep patterns and passwords
+// into shell strings, and adb ran them as `adb shell su -c <cmd>` - adb joins
+// its arguments with spaces, so the device shell re-split the command before
+// su saw it. Commands now reach `su -c` as one quoted argument on every
+// transport, and values are quoted with Quote or passed as argv to Exec.
+package device
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Quote renders args as shell words for composing pipelines:
+// device.Quote("grep", "-q", pattern, path) + " && echo yes". Words with
+// nothing a shell would interpret are left bare so plans stay readable.
+func Quote(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if a != "" && strings.Trim(a, safeChars) == "" {
+			quoted[i] = a
+		} else {
+			quoted[i] = shellQuote(a)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// safeChars never need quoting in a POSIX shell word. "=" is left out: a
+// bare first word containing it would be an assignment.
+const safeChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+:,./-"
+
+// Exec runs name with args as root on the device. No argument is
+// interpreted by a shell. Same 30s cap and trimmed output as RunShellCommand.
+func Exec(ctx context.Context, name string, args ...string) (string, error) {
+	return RunShellCommand(ctx, Quote(append([]string{name}, args...)...))
+}
+
+// WriteSecret writes content to a new root-only file on the device through
+// the command's stdin, so it never appears on a command line (the device's
+// ps or the host's adb argv). cleanup removes the file.
+func WriteSecret(ctx context.Context, content string) (path string, cleanup func(), err error) {
+	s, ok := CurrentTransport().(StdinRunner)
+	if !ok {
+		return "", nil, fmt.Errorf("%s transport cannot pass secrets over stdin", CurrentTransport().Name())
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("naming secret file: %w", err)
+	}
+	path = "/data/sovereign/.secret-" + hex.EncodeToString(b)
+	cleanup = func() { RunShellCommandQuick(context.Background(), Quote("rm", "-f", path)) }
+
+	if err := s.RunStdin(ctx, "mkdir -p /data/sovereign && umask 077 && cat > "+shellQuote(path), strings.NewReader(content)); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	// adb exec-in reports no exit status, so check what arrived
+	if out, _ := RunShellCommand(ctx, fmt.Sprintf("[ -s %s ] && echo OK", shellQuote(path))); out != "OK" {
+		cleanup()
+		return "", nil, fmt.Errorf("writing secret to %s failed", path)
+	}
+	return path, cleanup, nil
+}
+
+// suCommand is what the transports hand the device's login shell: cmd as
+// one argument to `su -c`
+func suCommand(cmd string) string {
+	return "su -c " + shellQuote(cmd)
+}
@@ -0,0 +1,99 @@
+package device
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"rm", "-f", "/data/sovereign/x.img"}, "rm -f /data/sovereign/x.img"},
+		{[]string{""}, "''"},
+		{[]string{"a b"}, "'a b'"},
+		{[]string{"it's"}, `'it'\''s'`},
+		{[]string{"A=1"}, "'A=1'"},
+		{[]string{"$HOME"}, "'$HOME'"},
+		{[]string{"a;b", "c|d", "`e`"}, "'a;b' 'c|d' '`e`'"},
+	}
+	for _, tt := range tests {
+		if got := Quote(tt.args...); got != tt.want {
+			t.Errorf("Quote(%q) = %s, want %s", tt.args, got, tt.want)
+		}
+	}
+}
+
+// What the transports send passes through two shells on the device: the
+// login shell runs `su -c <arg>`, and su hands <arg> to another sh -c.
+// Both are emulated locally with a su function.
+func TestSuCommandRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	args := []string{"plain", "", "two words", "it's", `"dq"`, "$HOME", "`id`", "a;b&&c", "*", "new\nline", `back\slash`, "A=1"}
+	cmd := suCommand(Quote(append([]string{"printf", `%s\n`}, args...)...))
+	out, err := exec.Command("sh", "-c", `su() { shift; sh -c "$1"; }; `+cmd).Output()
+	if err != nil {
+		t.Fatalf("sh -c %s: %v", cmd, err)
+	}
+	if got, want := string(out), strings.Join(args, "\n")+"\n"; got != want {
+		t.Errorf("round trip:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestExecQuotesArgs(t *testing.T) {
+	fake := NewFakeTransport()
+	withTransport(t, fake)
+
+	Exec(context.Background(), "grep", "-q", "a b", "/etc/x")
+	if want := "grep -q 'a b' /etc/x"; len(fake.Commands) != 1 || fake.Commands[0] != want {
+		t.Errorf("commands = %q, want [%s]", fake.Commands, want)
+	}
+}
+
+func TestWriteSecret(t *testing.T) {
+	fake := NewFakeTransport().On("[ -s", "OK", nil)
+	withTransport(t, fake)
+
+	path, cleanup, err := WriteSecret(context.Background(), "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(path, "/data/sovereign/.secret-") {
+		t.Errorf("path = %s", path)
+	}
+	var fed string
+	for cmd, data := range fake.Stdin {
+		if !strings.Contains(cmd, "umask 077") || !strings.Contains(cmd, path) {
+			t.Errorf("secret written by %q", cmd)
+		}
+		fed = string(data)
+	}
+	if fed != "hunter2" {
+		t.Errorf("stdin = %q, want the secret", fed)
+	}
+	if fake.Ran("hunter2") {
+		t.Error("secret appeared on a command line")
+	}
+
+	cleanup()
+	if !fake.Ran("rm -f " + path) {
+		t.Error("cleanup did not remove the file")
+	}
+}
+
+func TestWriteSecretNotWritten(t *testing.T) {
+	fake := NewFakeTransport() // [ -s ] never answers OK
+	withTransport(t, fake)
+
+	if _, _, err := WriteSecret(context.Background(), "hunter2"); err == nil {
+		t.Fatal("expected an error when the file did not arrive")
+	}
+	if !fake.Ran("rm -f /data/sovereign/.secret-") {
+		t.Error("partial secret file not removed")
+	}
+}
@@ -0,0 +1,136 @@
+// In-memory fake transport for tests
+// TEAM_042: Lets lifecycle logic be exercised without a physical Pixel
+package device
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FakeResponse is a canned reply for commands containing Match
+type FakeResponse struct {
+	Match  string
+	Output string
+	Err    error
+}
+
+// FakeTransport records every command and serves canned responses.
+// Pushed files are kept in Files keyed by remote path.
+type FakeTransport struct {
+	mu           sync.Mutex
+	Commands     []string          // Every Run/RunStdin/StartDetached/Stream command, in order
+	Files        map[string][]byte // Device filesystem (remote path -> content)
+	Stdin        map[string][]byte // What RunStdin fed each command
+	Responses    []FakeResponse    // First match wins
+	Handler      func(cmd string) (string, error)
+	Disconnected bool
+}
+
+// NewFakeTransport creates an empty fake device
+func NewFakeTransport() *FakeTransport {
+	return &FakeTransport{Files: make(map[string][]byte), Stdin: make(map[string][]byte)}
+}
+
+func (f *FakeTransport) Name() string { return TransportFake }
+
+// On registers a canned response for commands containing match
+func (f *FakeTransport) On(match, output string, err error) *FakeTransport {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Responses = append(f.Responses, FakeResponse{Match: match, Output: output, Err: err})
+	return f
+}
+
+// Ran reports whether any recorded command contains substr
+func (f *FakeTransport) Ran(substr string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.Commands {
+		if strings.Contains(c, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FakeTransport) Connected(ctx context.Context) bool {
+	return !f.Disconnected
+}
+
+// Run records cmd and returns the handler's or first matching response
+func (f *FakeTransport) Run(ctx context.Context, cmd string) (string, error) {
+	f.mu.Lock()
+	f.Commands = append(f.Commands, cmd)
+	handler := f.Handler
+	responses := f.Responses
+	f.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if handler != nil {
+		return handler(cmd)
+	}
+	for _, r := range responses {
+		if strings.Contains(cmd, r.Match) {
+			return r.Output, r.Err
+		}
+	}
+	return "", nil
+}
+
+// RunStdin reads r into Stdin[cmd], then answers like Run
+func (f *FakeTransport) RunStdin(ctx context.Context, cmd string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("fake stdin failed: %w", err)
+	}
+	f.mu.Lock()
+	if f.Stdin == nil {
+		f.Stdin = make(map[string][]byte)
+	}
+	f.Stdin[cmd] = data
+	f.mu.Unlock()
+	_, err = f.Run(ctx, cmd)
+	return err
+}
+
+// Push reads the local file into Files[remotePath]
+func (f *FakeTransport) Push(ctx context.Context, localPath, remotePath string) error {
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return fmt.Errorf("fake push failed: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Files[remotePath] = data
+	return nil
+}
+
+// Pull writes Files[remotePath] to localPath
+func (f *FakeTransport) Pull(ctx context.Context, remotePath, localPath string) error {
+	f.mu.Lock()
+	data, ok := f.Files[remotePath]
+	f.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("fake pull failed: %s not found", remotePath)
+	}
+	return os.WriteFile(localPath, data, 0644)
+}
+
+func (f *FakeTransport) StartDetached(cmd string) error {
+	_, err := f.Run(context.Background(), cmd)
+	return err
+}
+
+func (f *FakeTransport) Stream(ctx context.Context, cmd string, w io.Writer) error {
+	out, err := f.Run(ctx, cmd)
+	if out != "" {
+		io.WriteString(w, out)
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+// Device inventory and target selection (multiple attached phones)
+// TEAM_043: Created so prod and staging Pixels can be attached at the same time
+package device
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// InventoryFile maps aliases to adb serials, one "alias=serial" per line.
+// A value of the form ssh://[user@]host[:port] selects the SSH transport instead.
+const InventoryFile = ".devices"
+
+// AttachedDevice is one line of `adb devices -l`
+type AttachedDevice struct {
+	Serial string // "1A2B3C4D5E6F"
+	State  string // "device", "unauthorized", "offline"
+	Model  string // "Pixel_6"
+}
+
+// ListAttached returns all devices known to the adb server
+func ListAttached() ([]AttachedDevice, error) {
+	out, err := exec.Command("adb", "devices", "-l").Output()
+	if err != nil {
+		return nil, fmt.Errorf("adb devices failed: %w", err)
+	}
+	return parseDevicesList(string(out)), nil
+}
+
+// parseDevicesList parses `adb devices -l` output
+func parseDevicesList(out string) []AttachedDevice {
+	var devices []AttachedDevice
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(line, "List of devices") || strings.HasPrefix(line, "*") {
+			continue
+		}
+		d := AttachedDevice{Serial: fields[0], State: fields[1]}
+		for _, f := range fields[2:] {
+			if strings.HasPrefix(f, "model:") {
+				d.Model = strings.TrimPrefix(f, "model:")
+			}
+		}
+		devices = append(devices, d)
+	}
+	return devices
+}
+
+// LoadInventory reads alias=serial pairs from InventoryFile (cwd first, then
+// ~/.config/sovereign/devices). A missing file yields an empty inventory.
+func LoadInventory() (map[string]string, error) {
+	inventory := make(map[string]string)
+	candidates := []string{InventoryFile}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".config", "sovereign", "devices"))
+	}
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for n, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			parts := strings.SplitN(line, "=", 2)
+			if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+				return nil, fmt.Errorf("%s:%d: expected alias=serial, got %q", path, n+1, line)
+			}
+			inventory[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+		return inventory, nil
+	}
+	return inventory, nil
+}
+
+// SelectDevice points device commands at the phone named by selector, which
+// may be an adb serial or an inventory alias. With an empty selector it
+// succeeds only if exactly one device is attached, so commands never hit a
+// random phone when several are plugged in.
+func SelectDevice(selector string) error {
+	if selector == "" {
+		if t, ok := CurrentTransport().(*ADBTransport); ok {
+			return t.ensureSingleDevice()
+		}
+		return nil
+	}
+
+	inventory, err := LoadInventory()
+	if err != nil {
+		return err
+	}
+	target := selector
+	if serial, ok := inventory[selector]; ok {
+		target = serial
+	}
+
+	if strings.HasPrefix(target, "ssh://") {
+		SetTransport(NewSSHTransport(strings.TrimPrefix(target, "ssh://")))
+		return nil
+	}
+
+	attached, err := ListAttached()
+	if err != nil {
+		return err
+	}
+	for _, d := range attached {
+		if d.Serial != target {
+			continue
+		}
+		if d.State != "device" {
+			return fmt.Errorf("device %s is %s - check the USB authorization prompt", target, d.State)
+		}
+		SetTransport(&ADBTransport{Serial: target})
+		return nil
+	}
+	return fmt.Errorf("device %q not attached (serial or alias from %s)\n  Attached: %s",
+		selector, InventoryFile, describeAttached(attached))
+}
+
+// SelectedSerial returns the adb serial commands are pinned to, or "" if none
+func SelectedSerial() string {
+	if t, ok := CurrentTransport().(*ADBTransport); ok {
+		return t.Serial
+	}
+	return ""
+}
+
+// TargetID names the device commands go to, stable across invocations:
+// the adb serial (asked from adb when none is selected) or ssh://dest[:port]
+// TEAM_064: Key for the host state store
+func TargetID() string {
+	switch t := CurrentTransport().(type) {
+	case *ADBTransport:
+		if t.Serial != "" {
+			return t.Serial
+		}
+		out, err := ADBCommand("get-serialno").Output()
+		if serial := strings.TrimSpace(string(out)); err == nil && serial != "" && serial != "unknown" {
+			return serial
+		}
+		return ""
+	case *SSHTransport:
+		if t.Port != "" {
+			return "ssh://" + t.Dest + ":" + t.Port
+		}
+		return "ssh://" + t.Dest
+	default:
+		return t.Name()
+	}
+}
+
+// ADBCommand builds an adb command honoring the selected device
+func ADBCommand(args ...string) *exec.Cmd {
+	if serial := SelectedSerial(); serial != "" {
+		args = append([]string{"-s", serial}, args...)
+	}
+	return exec.Command("adb", args...)
+}
+
+// FastbootCommand builds a fastboot command honoring the selected device
+func FastbootCommand(args ...string) *exec.Cmd {
+	if serial := SelectedSerial(); serial != "" {
+		args = append([]string{"-s", serial}, args...)
+	}
+	return exec.Command("fastboot", args...)
+}
+
+// describeAttached formats the attached device list for error messages
+func describeAttached(devices []AttachedDevice) string {
+	if len(devices) == 0 {
+		return "(none)"
+	}
+	var parts []string
+	for _, d := range devices {
+		desc := d.Serial
+		if d.Model != "" {
+			desc += " (" + d.Model + ")"
+		}
+		if d.State != "device" {
+			desc += " [" + d.State + "]"
+		}
+		parts = append(parts, desc)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,132 @@
+// SSH transport (phones reachable over the network, e.g. via Tailscale)
+// TEAM_042: Uses the system ssh/scp binaries so host keys and agents just work
+package device
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// SSHTransport runs commands via `ssh <dest> su -c '<cmd>'`
+type SSHTransport struct {
+	Dest string // "root@pixel" or "pixel"
+	Port string // optional, "" = ssh default
+}
+
+// NewSSHTransport parses a [user@]host[:port] target
+func NewSSHTransport(target string) *SSHTransport {
+	t := &SSHTransport{Dest: target}
+	if i := strings.LastIndex(target, ":"); i > 0 && !strings.Contains(target[i:], "]") {
+		t.Dest, t.Port = target[:i], target[i+1:]
+	}
+	return t
+}
+
+func (s *SSHTransport) Name() string { return TransportSSH }
+
+// sshArgs returns the common ssh options
+func (s *SSHTransport) sshArgs() []string {
+	args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10"}
+	if s.Port != "" {
+		args = append(args, "-p", s.Port)
+	}
+	return args
+}
+
+// remote builds `su -c '<cmd>'` for the remote shell
+func (s *SSHTransport) remote(cmd string) string {
+	return suCommand(cmd)
+}
+
+// Connected checks if the device accepts a trivial command
+func (s *SSHTransport) Connected(ctx context.Context) bool {
+	out, err := s.Run(ctx, "echo ok")
+	return err == nil && strings.TrimSpace(out) == "ok"
+}
+
+// Run runs a shell command on the device as root
+func (s *SSHTransport) Run(ctx context.Context, cmd string) (string, error) {
+	args := append(s.sshArgs(), s.Dest, s.remote(cmd))
+	out, err := exec.CommandContext(ctx, "ssh", args...).Output()
+	return string(out), err
+}
+
+// scpArgs returns scp options (scp uses -P for port)
+func (s *SSHTransport) scpArgs() []string {
+	args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10"}
+	if s.Port != "" {
+		args = append(args, "-P", s.Port)
+	}
+	return args
+}
+
+// Push copies a file through /data/local/tmp, then moves it into place as root
+func (s *SSHTransport) Push(ctx context.Context, localPath, remotePath string) error {
+	tmpPath := "/data/local/tmp/" + strings.Replace(localPath, "/", "_", -1)
+
+	args := append(s.scpArgs(), localPath, s.Dest+":"+tmpPath)
+	cmd := exec.CommandContext(ctx, "scp", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("scp push failed: %w", err)
+	}
+
+	if _, err := s.Run(ctx, Quote("mv", tmpPath, remotePath)); err != nil {
+		return fmt.Errorf("mv to final location failed: %w", err)
+	}
+	return nil
+}
+
+// RunStdin runs a command as root with r as its stdin
+// TEAM_061: Used by PushFile for compressed transfers
+func (s *SSHTransport) RunStdin(ctx context.Context, cmd string, r io.Reader) error {
+	c := exec.CommandContext(ctx, "ssh", append(s.sshArgs(), s.Dest, s.remote(cmd))...)
+	c.Stdin = r
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("ssh failed: %w", err)
+	}
+	return nil
+}
+
+// Pull copies a root-owned file to /data/local/tmp, then copies it back
+func (s *SSHTransport) Pull(ctx context.Context, remotePath, localPath string) error {
+	tmpPath := "/data/local/tmp/" + strings.Replace(remotePath, "/", "_", -1)
+
+	if _, err := s.Run(ctx, Quote("cp", remotePath, tmpPath)+" && "+Quote("chmod", "644", tmpPath)); err != nil {
+		return fmt.Errorf("cp to staging location failed: %w", err)
+	}
+	defer s.Run(context.Background(), Quote("rm", "-f", tmpPath))
+
+	args := append(s.scpArgs(), s.Dest+":"+tmpPath, localPath)
+	if err := exec.CommandContext(ctx, "scp", args...).Run(); err != nil {
+		return fmt.Errorf("scp pull failed: %w", err)
+	}
+	return nil
+}
+
+// StartDetached starts a command with nohup so it survives the SSH session
+func (s *SSHTransport) StartDetached(cmd string) error {
+	remote := s.remote(fmt.Sprintf("nohup %s >/dev/null 2>&1 &", cmd))
+	c := exec.Command("ssh", append(s.sshArgs(), s.Dest, remote)...)
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return c.Run()
+}
+
+// Stream runs a command and copies its stdout to w until it exits
+func (s *SSHTransport) Stream(ctx context.Context, cmd string, w io.Writer) error {
+	c := exec.CommandContext(ctx, "ssh", append(s.sshArgs(), s.Dest, s.remote(cmd))...)
+	c.Stdout = w
+	return c.Run()
+}
+
+// shellQuote wraps s in single quotes for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
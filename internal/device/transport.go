@@ -0,0 +1,84 @@
+// Device transport abstraction
+// TEAM_042: All device I/O goes through a Transport so lifecycle logic can run
+// against adb, SSH or an in-memory fake without a physical phone attached.
+package device
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Transport executes commands and moves files on the target device.
+// Commands passed to Run, StartDetached and Stream are shell strings that the
+// transport runs as root on the device.
+type Transport interface {
+	Name() string
+	Connected(ctx context.Context) bool
+	Run(ctx context.Context, cmd string) (string, error)          // Run as root, return raw stdout
+	Push(ctx context.Context, localPath, remotePath string) error // Copy host file to device
+	Pull(ctx context.Context, remotePath, localPath string) error // Copy device file to host
+	StartDetached(cmd string) error                               // Start and outlive this process
+	Stream(ctx context.Context, cmd string, w io.Writer) error    // Copy stdout to w until exit
+}
+
+// Transport kinds accepted by NewTransport and SOVEREIGN_TRANSPORT
+const (
+	TransportADB  = "adb"
+	TransportSSH  = "ssh"
+	TransportFake = "fake"
+)
+
+var (
+	current   Transport = &ADBTransport{}
+	currentMu sync.RWMutex
+)
+
+func init() {
+	// TEAM_042: Allow selecting the transport without code changes,
+	// e.g. SOVEREIGN_TRANSPORT=ssh SOVEREIGN_SSH_HOST=root@pixel.lan
+	kind := os.Getenv("SOVEREIGN_TRANSPORT")
+	if kind == "" {
+		return
+	}
+	t, err := NewTransport(kind, os.Getenv("SOVEREIGN_SSH_HOST"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ %v - falling back to adb\n", err)
+		return
+	}
+	SetTransport(t)
+}
+
+// NewTransport creates a transport by kind ("adb", "ssh", "fake").
+// target is the SSH destination ([user@]host[:port]) and ignored otherwise.
+func NewTransport(kind, target string) (Transport, error) {
+	switch kind {
+	case TransportADB, "":
+		return &ADBTransport{}, nil
+	case TransportSSH:
+		if target == "" {
+			return nil, fmt.Errorf("ssh transport requires a target (SOVEREIGN_SSH_HOST)")
+		}
+		return NewSSHTransport(target), nil
+	case TransportFake:
+		return NewFakeTransport(), nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q (want adb, ssh or fake)", kind)
+	}
+}
+
+// SetTransport replaces the transport used by all device functions
+func SetTransport(t Transport) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = t
+}
+
+// CurrentTransport returns the transport used by all device functions
+func CurrentTransport() Transport {
+	currentMu.RLock()
+	defer currentMu.RUnlock()
+	return current
+}
@@ -9,8 +9,10 @@ import (
 	"strings"
 )
 
-// ExportImage exports a Docker image to an ext4 rootfs image
-func ExportImage(imageName, outputPath, size string) error {
+// ExportTar exports a Docker image's filesystem to a tarball.
+// TEAM_054: rootfs.CreateImage turns the tar into an ext4 image without
+// mounting anything - this used to mount the image with sudo and untar into it.
+func ExportTar(imageName, tarPath string) error {
 	// Create container from image
 	out, err := exec.Command("docker", "create", imageName).Output()
 	if err != nil {
@@ -19,8 +21,6 @@ func ExportImage(imageName, outputPath, size string) error {
 	containerID := strings.TrimSpace(string(out))
 	defer exec.Command("docker", "rm", containerID).Run()
 
-	// Export to tarball
-	tarPath := strings.TrimSuffix(outputPath, ".img") + ".tar"
 	tarFile, err := os.Create(tarPath)
 	if err != nil {
 		return fmt.Errorf("failed to create tar file: %w", err)
@@ -33,40 +33,11 @@ func ExportImage(imageName, outputPath, size string) error {
 		os.Remove(tarPath)
 		return fmt.Errorf("docker export failed: %w", err)
 	}
-	tarFile.Close()
-
-	// Create ext4 image
-	if err := exec.Command("truncate", "-s", size, outputPath).Run(); err != nil {
-		return fmt.Errorf("truncate failed: %w", err)
-	}
-	if err := exec.Command("mkfs.ext4", "-F", outputPath).Run(); err != nil {
-		return fmt.Errorf("mkfs.ext4 failed: %w", err)
-	}
-
-	// Mount and extract (requires sudo)
-	mountDir := "/tmp/sovereign-docker-mount"
-	os.MkdirAll(mountDir, 0755)
-
-	if err := exec.Command("sudo", "mount", outputPath, mountDir).Run(); err != nil {
-		return fmt.Errorf("mount failed (need sudo): %w", err)
-	}
-
-	cmd = exec.Command("sudo", "tar", "-xf", tarPath, "-C", mountDir)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		exec.Command("sudo", "umount", mountDir).Run()
-		return fmt.Errorf("tar extract failed: %w", err)
-	}
-
-	if err := exec.Command("sudo", "umount", mountDir).Run(); err != nil {
-		return fmt.Errorf("umount failed: %w", err)
+	if err := tarFile.Close(); err != nil {
+		return fmt.Errorf("failed to write tar file: %w", err)
 	}
 
-	os.Remove(tarPath)
-	os.Remove(mountDir)
-
-	fmt.Printf("  ✓ Exported to %s\n", outputPath)
+	fmt.Printf("  ✓ Exported to %s\n", tarPath)
 	return nil
 }
 
@@ -75,3 +46,26 @@ func IsAvailable() bool {
 	_, err := exec.LookPath("docker")
 	return err == nil
 }
+
+// ImageID returns the content-addressed ID (sha256:...) of a local image
+// TEAM_056: Recorded in build-manifest.json
+func ImageID(imageName string) (string, error) {
+	out, err := exec.Command("docker", "image", "inspect", "--format", "{{.Id}}", imageName).Output()
+	if err != nil {
+		return "", fmt.Errorf("docker image inspect %s failed: %w", imageName, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// RepoDigest returns the registry digest (alpine@sha256:...) a local image
+// was pulled as, or "" for images that were built locally or never pulled
+func RepoDigest(imageName string) string {
+	out, err := exec.Command("docker", "image", "inspect", "--format", "{{join .RepoDigests \" \"}}", imageName).Output()
+	if err != nil {
+		return ""
+	}
+	if fields := strings.Fields(string(out)); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
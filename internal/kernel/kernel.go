@@ -120,7 +120,8 @@ func Deploy() error {
 
 	// Step 4: Flash initramfs with dtb (special command per Google docs)
 	fmt.Println("\n[Step 4/6] Flashing initramfs with dtb...")
-	cmd := exec.Command("fastboot", "flash", "--dtb", distDir+"/dtb.img",
+	// TEAM_043: fastboot/adb calls go through device helpers so --device is honored
+	cmd := device.FastbootCommand("flash", "--dtb", distDir+"/dtb.img",
 		"vendor_boot:dlkm", distDir+"/initramfs.img")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -131,7 +132,7 @@ func Deploy() error {
 
 	// Step 5: Reboot to fastboot mode for vendor_dlkm
 	fmt.Println("\n[Step 5/6] Rebooting to fastboot mode...")
-	if err := exec.Command("fastboot", "reboot", "fastboot").Run(); err != nil {
+	if err := device.FastbootCommand("reboot", "fastboot").Run(); err != nil {
 		return fmt.Errorf("reboot to fastboot failed: %w", err)
 	}
 
@@ -147,7 +148,7 @@ func Deploy() error {
 
 	// Final reboot
 	fmt.Println("\nRebooting device...")
-	if err := exec.Command("fastboot", "reboot").Run(); err != nil {
+	if err := device.FastbootCommand("reboot").Run(); err != nil {
 		return fmt.Errorf("final reboot failed: %w", err)
 	}
 
@@ -287,11 +288,15 @@ func BuildGuestKernel() error {
 // Test tests KernelSU installation
 func Test() error {
 	fmt.Println("=== Testing Kernel/KernelSU ===")
+	// TEAM_043: Refuse to guess when several devices are attached
+	if err := device.SelectDevice(""); err != nil {
+		return err
+	}
 	allPassed := true
 
 	// Test 1: Kernel version
 	fmt.Print("1. Kernel version contains 'sovereign': ")
-	out, err := exec.Command("adb", "shell", "cat", "/proc/version").Output()
+	out, err := device.ADBCommand("shell", "cat", "/proc/version").Output()
 	if err != nil {
 		fmt.Println("✗ FAIL (cannot read)")
 		allPassed = false
@@ -305,7 +310,7 @@ func Test() error {
 
 	// Test 2: Root access
 	fmt.Print("2. Root access via su: ")
-	out, err = exec.Command("adb", "shell", "su", "-c", "id").Output()
+	out, err = device.ADBCommand("shell", "su", "-c", "id").Output()
 	if err != nil {
 		fmt.Println("✗ FAIL (su not working)")
 		allPassed = false
@@ -319,7 +324,7 @@ func Test() error {
 
 	// Test 3: KernelSU version
 	fmt.Print("3. KernelSU version (not 16): ")
-	out, err = exec.Command("adb", "shell", "su", "-v").Output()
+	out, err = device.ADBCommand("shell", "su", "-v").Output()
 	if err != nil {
 		fmt.Println("✗ FAIL (cannot get version)")
 		allPassed = false
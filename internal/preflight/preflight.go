@@ -3,26 +3,44 @@
 package preflight
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/report"
+	"github.com/anthropics/sovereign/internal/rootfs"
 )
 
 // CheckResult represents the result of a single preflight check
 type CheckResult struct {
-	Name     string
-	Required bool // true = must pass, false = warning only
-	Passed   bool
-	Message  string
+	Name     string `json:"name"`
+	Required bool   `json:"required"` // true = must pass, false = warning only
+	Passed   bool   `json:"passed"`
+	Message  string `json:"message"`
+}
+
+// Status maps the check to a report status (optional failures are warnings)
+func (c CheckResult) Status() report.Status {
+	switch {
+	case c.Passed:
+		return report.StatusOK
+	case c.Required:
+		return report.StatusFail
+	default:
+		return report.StatusWarn
+	}
 }
 
 // Results holds all preflight check results
 type Results struct {
-	Checks  []CheckResult
-	Passed  bool
-	Command string
+	Checks  []CheckResult `json:"checks"`
+	Passed  bool          `json:"passed"`
+	Command string        `json:"command"`
 }
 
 // commandExists checks if a command is available in PATH
@@ -45,14 +63,21 @@ func dockerRunning() bool {
 	return cmd.Run() == nil
 }
 
-// adbConnected checks if a device is connected via adb
-func adbConnected() bool {
-	cmd := exec.Command("adb", "get-state")
+// adbConnected checks if the selected device is connected via adb
+// TEAM_043: Returns an error naming the attached devices when the target is ambiguous
+func adbConnected() (bool, error) {
+	if err := device.SelectDevice(""); err != nil {
+		return false, err
+	}
+	if device.CurrentTransport().Name() != device.TransportADB {
+		return device.IsConnected(context.Background()), nil
+	}
+	cmd := device.ADBCommand("get-state")
 	output, err := cmd.Output()
 	if err != nil {
-		return false
+		return false, nil
 	}
-	return strings.TrimSpace(string(output)) == "device"
+	return strings.TrimSpace(string(output)) == "device", nil
 }
 
 // getKernelDir returns the kernel directory (parent of sovereign-vault)
@@ -103,9 +128,16 @@ func CheckForCommand(command string, vmNames []string) *Results {
 			Name:     "adb device",
 			Required: true,
 		}
-		if adbConnected() {
+		connected, err := adbConnected()
+		if err != nil {
+			deviceCheck.Passed = false
+			deviceCheck.Message = err.Error()
+		} else if connected {
 			deviceCheck.Passed = true
 			deviceCheck.Message = "device connected"
+			if serial := device.SelectedSerial(); serial != "" {
+				deviceCheck.Message = fmt.Sprintf("device %s connected", serial)
+			}
 		} else {
 			deviceCheck.Passed = false
 			deviceCheck.Message = "no device connected - run 'adb devices' to check"
@@ -144,6 +176,22 @@ func CheckForCommand(command string, vmNames []string) *Results {
 			results.Checks = append(results.Checks, dockerDaemonCheck)
 		}
 
+		// TEAM_054: Rootfs images are built rootless with mke2fs -d + debugfs
+		e2fsCheck := CheckResult{
+			Name:     "e2fsprogs",
+			Required: true,
+		}
+		_, mke2fsErr := rootfs.FindTool("mke2fs")
+		_, debugfsErr := rootfs.FindTool("debugfs")
+		if mke2fsErr == nil && debugfsErr == nil {
+			e2fsCheck.Passed = true
+			e2fsCheck.Message = "mke2fs and debugfs found (rootless rootfs images)"
+		} else {
+			e2fsCheck.Passed = false
+			e2fsCheck.Message = "mke2fs/debugfs not found - install e2fsprogs: sudo apt install e2fsprogs"
+		}
+		results.Checks = append(results.Checks, e2fsCheck)
+
 		// Check: qemu-user-static for cross-arch builds
 		qemuCheck := CheckResult{
 			Name:     "qemu-user-static",
@@ -252,32 +300,39 @@ func CheckForCommand(command string, vmNames []string) *Results {
 
 // Print outputs the preflight results in a formatted way
 func (r *Results) Print() {
-	fmt.Println("=== Preflight Checks ===")
+	r.WriteText(os.Stdout)
+}
 
-	for _, check := range r.Checks {
-		status := "✓"
-		if !check.Passed {
-			if check.Required {
-				status = "✗"
-			} else {
-				status = "⚠"
-			}
-		}
+// WriteText writes the preflight results in the classic CLI format
+// TEAM_045: Print() is now a thin wrapper so report.Write can render JSON/JUnit too
+func (r *Results) WriteText(w io.Writer) error {
+	fmt.Fprintln(w, "=== Preflight Checks ===")
 
+	for _, check := range r.Checks {
 		reqTag := ""
 		if !check.Required && !check.Passed {
 			reqTag = " (optional)"
 		}
 
-		fmt.Printf("%s %s: %s%s\n", status, check.Name, check.Message, reqTag)
+		fmt.Fprintf(w, "%s %s: %s%s\n", check.Status().Symbol(), check.Name, check.Message, reqTag)
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 	if r.Passed {
-		fmt.Println("✓ All required checks passed")
+		fmt.Fprintln(w, "✓ All required checks passed")
 	} else {
-		fmt.Println("✗ Some required checks failed - fix issues above before proceeding")
+		fmt.Fprintln(w, "✗ Some required checks failed - fix issues above before proceeding")
+	}
+	return nil
+}
+
+// JUnit returns one test case per check; only required failures fail the suite
+func (r *Results) JUnit() report.JUnitSuite {
+	var cases []report.JUnitCase
+	for _, check := range r.Checks {
+		cases = append(cases, report.JUnitCaseFor(check.Name, check.Status(), check.Message))
 	}
+	return report.NewJUnitSuite("sovereign.preflight."+r.Command, cases)
 }
 
 // RunChecks performs preflight checks and returns true if all required checks pass
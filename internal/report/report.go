@@ -0,0 +1,151 @@
+// Package report renders command results as text, JSON or JUnit XML
+// TEAM_045: Created so CI can consume `sovereign test --sql --output json`
+// instead of scraping emoji from human output
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Format selects how results are written
+type Format string
+
+const (
+	FormatText  Format = "text"
+	FormatJSON  Format = "json"
+	FormatJUnit Format = "junit"
+)
+
+// Status is the outcome of a single check
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+	StatusInfo Status = "info"
+)
+
+// Symbol returns the glyph used for s in text output
+func (s Status) Symbol() string {
+	switch s {
+	case StatusOK:
+		return "✓"
+	case StatusWarn:
+		return "⚠"
+	case StatusFail:
+		return "✗"
+	case StatusSkip:
+		return "?"
+	default:
+		return "-"
+	}
+}
+
+// Renderable is implemented by every result type the CLI can print
+type Renderable interface {
+	WriteText(w io.Writer) error // Human output (the classic CLI format)
+	JUnit() JUnitSuite           // One suite per command/VM
+}
+
+// ParseFormat validates a --output value
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatJUnit, "xml":
+		return FormatJUnit, nil
+	default:
+		return "", fmt.Errorf("unknown output format %q (want text, json or junit)", s)
+	}
+}
+
+// Write renders v to w in the given format
+func Write(w io.Writer, format Format, v Renderable) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case FormatJUnit:
+		return WriteJUnit(w, v.JUnit())
+	default:
+		return v.WriteText(w)
+	}
+}
+
+// JUnitSuites is the root element of a JUnit XML report
+type JUnitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []JUnitSuite `xml:"testsuite"`
+}
+
+// JUnitSuite is one <testsuite> element
+type JUnitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []JUnitCase `xml:"testcase"`
+}
+
+// JUnitCase is one <testcase> element
+type JUnitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitMessage `xml:"failure,omitempty"`
+	Skipped   *JUnitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// JUnitMessage is the body of <failure> or <skipped>
+type JUnitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// NewJUnitSuite builds a suite and counts failures/skips from its cases
+func NewJUnitSuite(name string, cases []JUnitCase) JUnitSuite {
+	suite := JUnitSuite{Name: name, Tests: len(cases), Cases: cases}
+	for i := range cases {
+		cases[i].ClassName = name
+		if cases[i].Failure != nil {
+			suite.Failures++
+		}
+		if cases[i].Skipped != nil {
+			suite.Skipped++
+		}
+	}
+	return suite
+}
+
+// JUnitCaseFor maps a status to a test case (warn counts as pass, fail as failure)
+func JUnitCaseFor(name string, status Status, message string) JUnitCase {
+	c := JUnitCase{Name: name, SystemOut: message}
+	switch status {
+	case StatusFail:
+		c.Failure = &JUnitMessage{Message: message}
+	case StatusSkip:
+		c.Skipped = &JUnitMessage{Message: message}
+	}
+	return c
+}
+
+// WriteJUnit writes one or more suites as a JUnit XML document
+func WriteJUnit(w io.Writer, suites ...JUnitSuite) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(JUnitSuites{Suites: suites}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,145 @@
+package rootfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tarOf builds a docker-export-style tar
+func tarOf(t *testing.T, hdrs ...tar.Header) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, h := range hdrs {
+		body := ""
+		if h.Typeflag == tar.TypeReg {
+			body = "content of " + h.Name
+			h.Size = int64(len(body))
+		}
+		if err := tw.WriteHeader(&h); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(body))
+	}
+	tw.Close()
+	return &buf
+}
+
+// testStage extracts a small rootfs owned by root and by a service user
+func testStage(t *testing.T) *stage {
+	t.Helper()
+	s, err := newStage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.cleanup)
+	err = s.extract(tarOf(t,
+		tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0755},
+		tar.Header{Name: "bin/", Typeflag: tar.TypeDir, Mode: 0755},
+		tar.Header{Name: "bin/busybox", Typeflag: tar.TypeReg, Mode: 04755},
+		tar.Header{Name: "bin/sh", Typeflag: tar.TypeSymlink, Linkname: "busybox"},
+		tar.Header{Name: "bin/ls", Typeflag: tar.TypeLink, Linkname: "bin/busybox"},
+		tar.Header{Name: "var/lib/app/db", Typeflag: tar.TypeReg, Mode: 0600, Uid: 70, Gid: 70},
+		tar.Header{Name: "dev/null", Typeflag: tar.TypeChar, Mode: 0666, Devmajor: 1, Devminor: 3},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestExtract(t *testing.T) {
+	s := testStage(t)
+	tests := []struct {
+		name string
+		want attr
+	}{
+		{"/", attr{mode: modeDir | 0755}},
+		{"/bin/busybox", attr{mode: modeReg | 04755}},
+		{"/bin/sh", attr{mode: modeSymlink | 0777}},
+		{"/var/lib", attr{mode: modeDir | 0755}}, // Implied parent
+		{"/var/lib/app/db", attr{uid: 70, gid: 70, mode: modeReg | 0600}},
+		{"/dev/null", attr{mode: modeChar | 0666, major: 1, minor: 3}},
+	}
+	for _, tt := range tests {
+		if got := s.attrs[tt.name]; got != tt.want {
+			t.Errorf("%s: attr %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+	if s.exists("/bin/ls") {
+		t.Error("hardlink got its own metadata")
+	}
+	a, _ := os.Stat(filepath.Join(s.dir, "bin/busybox"))
+	b, _ := os.Stat(filepath.Join(s.dir, "bin/ls"))
+	if !os.SameFile(a, b) {
+		t.Error("bin/ls is not a hardlink of bin/busybox")
+	}
+	if _, err := os.Lstat(filepath.Join(s.dir, "dev/null")); !os.IsNotExist(err) {
+		t.Error("device node created on the host")
+	}
+}
+
+func TestExtractRefusesSymlinkEscape(t *testing.T) {
+	s, err := newStage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.cleanup()
+	outside := t.TempDir()
+	err = s.extract(tarOf(t,
+		tar.Header{Name: "etc", Typeflag: tar.TypeSymlink, Linkname: outside},
+		tar.Header{Name: "etc/passwd", Typeflag: tar.TypeReg, Mode: 0644},
+	))
+	if err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("err = %v, want a symlink escape", err)
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Error("wrote outside the stage")
+	}
+}
+
+// The image records tar ownership and device nodes without running as root
+func TestMkfs(t *testing.T) {
+	mke2fs, err := FindTool("mke2fs")
+	if err != nil {
+		t.Skip(err)
+	}
+	debugfs, err := FindTool("debugfs")
+	if err != nil {
+		t.Skip(err)
+	}
+	s := testStage(t)
+	size, err := s.imageSize("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := filepath.Join(t.TempDir(), "rootfs.img")
+	if err := s.mkfs(mke2fs, debugfs, img, size); err != nil {
+		t.Fatal(err)
+	}
+
+	stat := func(name string) string {
+		out, err := exec.Command(debugfs, "-R", "stat "+name, img).Output()
+		if err != nil {
+			t.Fatalf("debugfs stat %s: %v", name, err)
+		}
+		return strings.Join(strings.Fields(string(out)), " ")
+	}
+	for name, want := range map[string][]string{
+		"/var/lib/app/db": {"Type: regular", "Mode: 0600", "User: 70 Group: 70"},
+		"/bin/busybox":    {"Mode: 04755", "User: 0 Group: 0", "Links: 2"},
+		"/dev/null":       {"Type: character special", "Mode: 0666"},
+	} {
+		got := stat(name)
+		for _, w := range want {
+			if !strings.Contains(got, w) {
+				t.Errorf("%s: %q not in %s", name, w, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Installed package list for build provenance
+// TEAM_056: Read from the apk database in the docker export tar, so the
+// record matches what is in the image rather than what the Dockerfile asked for
+package rootfs
+
+import (
+	"archive/tar"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// APKInstalled is the apk database inside an Alpine rootfs
+const APKInstalled = "lib/apk/db/installed"
+
+// Package is one installed package
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Arch    string `json:"arch,omitempty"`
+	License string `json:"license,omitempty"`
+	Origin  string `json:"origin,omitempty"` // Source package (postgresql17 for postgresql17-client)
+
+	Files []string `json:"-"` // Installed paths without the leading "/"
+}
+
+// Packages lists the apk packages installed in a docker export tar, sorted by
+// name. Images without an apk database have no packages.
+func Packages(tarPath string) ([]Package, error) {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading tar: %w", err)
+		}
+		if strings.TrimPrefix(hdr.Name, "./") == APKInstalled && hdr.Typeflag == tar.TypeReg {
+			return ParseAPKInstalled(tr)
+		}
+	}
+}
+
+// ParseAPKInstalled reads the apk database: one "K:value" line per field,
+// blank lines between packages. F lines name a directory, the R lines after
+// them the files in it.
+func ParseAPKInstalled(r io.Reader) ([]Package, error) {
+	var pkgs []Package
+	var cur Package
+	var dir string
+	flush := func() {
+		if cur.Name != "" {
+			pkgs = append(pkgs, cur)
+		}
+		cur, dir = Package{}, ""
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		if len(line) < 2 || line[1] != ':' {
+			continue
+		}
+		value := line[2:]
+		switch line[0] {
+		case 'P':
+			cur.Name = value
+		case 'V':
+			cur.Version = value
+		case 'A':
+			cur.Arch = value
+		case 'L':
+			cur.License = value
+		case 'o':
+			cur.Origin = value
+		case 'F':
+			dir = value
+		case 'R':
+			cur.Files = append(cur.Files, path.Join(dir, value))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading apk database: %w", err)
+	}
+	flush()
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name < pkgs[j].Name })
+	return pkgs, nil
+}
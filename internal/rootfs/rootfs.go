@@ -1,41 +1,28 @@
 // Package rootfs provides rootfs AVF preparation utilities
 // TEAM_010: Extracted from main.go during CLI refactor
 // TEAM_023: Extracted simple_init.sh to external file
+// TEAM_054: Builds images without root - see image.go
 package rootfs
 
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/anthropics/sovereign/internal/device"
 )
 
-// PrepareForAVF fixes Alpine rootfs for AVF/crosvm compatibility
-// This is IDEMPOTENT - safe to run multiple times
+// prepareForAVF fixes Alpine rootfs for AVF/crosvm compatibility
 // TEAM_007: Original implementation
 // TEAM_011: Added dbPassword parameter for secure credential handling
-func PrepareForAVF(rootfsPath string, dbPassword string) error {
-	mountDir := "/tmp/sovereign-rootfs-prep"
-	os.MkdirAll(mountDir, 0755)
-
-	// Mount rootfs
-	if err := exec.Command("sudo", "mount", rootfsPath, mountDir).Run(); err != nil {
-		return fmt.Errorf("mount failed: %w", err)
-	}
-	defer func() {
-		exec.Command("sudo", "umount", mountDir).Run()
-		os.RemoveAll(mountDir)
-	}()
-
+// TEAM_054: Works on the staging tree instead of a sudo-mounted image;
+// rootfsPath is only used to pick the VM's init.sh
+func prepareForAVF(s *stage, rootfsPath string, dbPassword string) error {
 	// TEAM_020: Removed gvforwarder code - we use TAP networking now
 
 	// Create local.d script for early device node creation
 	// This runs early in boot and ensures critical device nodes exist
-	localDDir := mountDir + "/etc/local.d"
-	exec.Command("sudo", "mkdir", "-p", localDDir).Run()
-
-	devNodesScript := localDDir + "/00-avf-devices.start"
 	devNodesContent := `#!/bin/sh
 # TEAM_020: Ensure AVF-required device nodes exist
 
@@ -54,56 +41,53 @@ chmod 666 /dev/net/tun
 chmod 666 /dev/null /dev/tty 2>/dev/null
 chmod 600 /dev/console 2>/dev/null
 `
-	// Write the script (idempotent - overwrites if exists)
-	writeCmd := fmt.Sprintf("cat > %s << 'EOFSCRIPT'\n%sEOFSCRIPT", devNodesScript, devNodesContent)
-	if err := exec.Command("sudo", "sh", "-c", writeCmd).Run(); err != nil {
+	if err := s.writeFile("/etc/local.d/00-avf-devices.start", []byte(devNodesContent), 0755); err != nil {
 		return fmt.Errorf("failed to create device nodes script: %w", err)
 	}
-	exec.Command("sudo", "chmod", "+x", devNodesScript).Run()
 	fmt.Println("  ✓ Created /etc/local.d/00-avf-devices.start")
 
 	// Fix 3: Ensure 'local' service is enabled in default runlevel
-	localLink := mountDir + "/etc/runlevels/default/local"
-	if _, err := os.Stat(localLink); os.IsNotExist(err) {
-		exec.Command("sudo", "ln", "-sf", "/etc/init.d/local", localLink).Run()
+	if !s.exists("/etc/runlevels/default/local") {
+		if err := s.symlink("/etc/init.d/local", "/etc/runlevels/default/local"); err != nil {
+			return err
+		}
 		fmt.Println("  ✓ Enabled 'local' service in default runlevel")
 	}
 
 	// Fix 4: Ensure devfs runs in sysinit runlevel
-	devfsLink := mountDir + "/etc/runlevels/sysinit/devfs"
-	if _, err := os.Stat(devfsLink); os.IsNotExist(err) {
-		exec.Command("sudo", "ln", "-sf", "/etc/init.d/devfs", devfsLink).Run()
+	if !s.exists("/etc/runlevels/sysinit/devfs") {
+		if err := s.symlink("/etc/init.d/devfs", "/etc/runlevels/sysinit/devfs"); err != nil {
+			return err
+		}
 		fmt.Println("  ✓ Enabled 'devfs' service in sysinit runlevel")
 	}
 
 	// Fix 5: Pre-create critical device nodes directly in rootfs
 	// TEAM_011: The local.d script runs too late - sovereign-init needs these earlier
-	devDir := mountDir + "/dev"
-	devNetDir := devDir + "/net"
-	exec.Command("sudo", "mkdir", "-p", devNetDir).Run()
-
-	// Create device nodes if they don't exist
-	devNodes := []struct{ path, major, minor string }{
-		{devDir + "/console", "5", "1"},
-		{devDir + "/null", "1", "3"},
-		{devDir + "/zero", "1", "5"},
-		{devDir + "/tty", "5", "0"},
-		{devDir + "/random", "1", "8"},
-		{devDir + "/urandom", "1", "9"},
-		{devDir + "/vsock", "10", "121"},
-		{devNetDir + "/tun", "10", "200"},
+	devNodes := []struct {
+		path         string
+		major, minor int64
+	}{
+		{"/dev/console", 5, 1},
+		{"/dev/null", 1, 3},
+		{"/dev/zero", 1, 5},
+		{"/dev/tty", 5, 0},
+		{"/dev/random", 1, 8},
+		{"/dev/urandom", 1, 9},
+		{"/dev/vsock", 10, 121},
+		{"/dev/net/tun", 10, 200},
 	}
 	for _, dev := range devNodes {
-		if _, err := os.Stat(dev.path); os.IsNotExist(err) {
-			exec.Command("sudo", "mknod", dev.path, "c", dev.major, dev.minor).Run()
-			exec.Command("sudo", "chmod", "666", dev.path).Run()
+		if !s.exists(dev.path) {
+			if err := s.mknod(dev.path, dev.major, dev.minor, 0666); err != nil {
+				return err
+			}
 		}
 	}
 	fmt.Println("  ✓ Pre-created device nodes in /dev")
 
 	// Fix 6: Create init script (OpenRC doesn't work on AVF - it hangs)
 	// TEAM_023: Script extracted to vm/sql/init.sh for maintainability
-	initScriptPath := mountDir + "/sbin/init.sh"
 
 	// Find the init.sh script relative to the sovereign directory
 	// TEAM_025: Now VM-agnostic - uses rootfsPath to determine which init.sh to use
@@ -119,21 +103,16 @@ chmod 600 /dev/console 2>/dev/null
 	}
 
 	// Inject DB_PASSWORD into the script (add export line after PATH export)
-	scriptContent := string(scriptBytes)
-	dbPasswordExport := fmt.Sprintf("export DB_PASSWORD=\"%s\"", dbPassword)
-	scriptContent = strings.Replace(scriptContent,
-		"# DB_PASSWORD is injected by rootfs.go - DO NOT HARDCODE",
-		dbPasswordExport,
-		1)
+	scriptContent := injectPassword(string(scriptBytes), dbPassword)
 
 	// Write the script to rootfs
-	initCmd := fmt.Sprintf("cat > %s << 'EOFSCRIPT'\n%sEOFSCRIPT", initScriptPath, scriptContent)
-	if err := exec.Command("sudo", "sh", "-c", initCmd).Run(); err != nil {
+	if err := s.writeFile("/sbin/init.sh", []byte(scriptContent), 0755); err != nil {
 		return fmt.Errorf("failed to create init.sh: %w", err)
 	}
-	exec.Command("sudo", "chmod", "+x", initScriptPath).Run()
 	// Also symlink to /sbin/init for kernel's default init path
-	exec.Command("sudo", "ln", "-sf", "/sbin/init.sh", mountDir+"/sbin/init").Run()
+	if err := s.symlink("/sbin/init.sh", "/sbin/init"); err != nil {
+		return err
+	}
 	fmt.Printf("  ✓ Created /sbin/init.sh (from %s)\n", scriptPath)
 
 	// TEAM_020: Removed dhclient wrapper - gvforwarder not used anymore
@@ -142,10 +121,27 @@ chmod 600 /dev/console 2>/dev/null
 	return nil
 }
 
+// injectPassword replaces the DB_PASSWORD placeholder in an init script.
+// TEAM_066: Single-quoted, so quotes, $ and backticks in the password reach
+// init.sh literally instead of being expanded at boot
+func injectPassword(script, password string) string {
+	return strings.Replace(script,
+		"# DB_PASSWORD is injected by rootfs.go - DO NOT HARDCODE",
+		"export DB_PASSWORD="+device.Quote(password),
+		1)
+}
+
 // findInitScript locates the init.sh script based on the rootfs path
 // TEAM_025: Made VM-agnostic to support both SQL and Forge VMs
 // TEAM_035: Added vault VM support
+// TEAM_055: Prefer init.sh next to rootfs.img - the file the build cache
+// hashes, and the only one manifest VMs have
 func findInitScript(rootfsPath string) (string, error) {
+	local := filepath.Join(filepath.Dir(rootfsPath), "init.sh")
+	if _, err := os.Stat(local); err == nil {
+		return filepath.Abs(local)
+	}
+
 	// Determine VM type from rootfs path
 	vmType := "sql" // default
 	if strings.Contains(rootfsPath, "forgejo") {
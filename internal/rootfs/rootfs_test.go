@@ -0,0 +1,22 @@
+package rootfs
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestInjectPassword(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	password := `p'a"s$HOME\` + "`id`"
+	script := "#!/bin/sh\n# DB_PASSWORD is injected by rootfs.go - DO NOT HARDCODE\nprintf %s \"$DB_PASSWORD\"\n"
+
+	out, err := exec.Command("sh", "-c", injectPassword(script, password)).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != password {
+		t.Errorf("DB_PASSWORD = %q, want %q", out, password)
+	}
+}
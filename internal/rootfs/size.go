@@ -0,0 +1,123 @@
+// Image sizing
+// TEAM_058: The rootfs used to be a fixed 512M. It is now sized from the
+// staged content plus headroom unless a VMConfig pins RootfsSize, and a
+// pinned size that is too small fails before mke2fs with the numbers.
+package rootfs
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	blockSize      = 4096
+	inodeRatio     = 16384     // mke2fs default bytes-per-inode
+	rootfsHeadroom = 128 << 20 // Free space for logs and state written to /
+	sizeAlign      = 16 << 20
+)
+
+// ParseSize parses "512M", "4G", "1.5G" or a byte count. K/M/G/T are binary
+// units; a trailing "B"/"iB" is accepted.
+func ParseSize(s string) (int64, error) {
+	t := strings.ToUpper(strings.TrimSpace(s))
+	t = strings.TrimSuffix(strings.TrimSuffix(t, "IB"), "B")
+	mult := int64(1)
+	if t != "" {
+		switch t[len(t)-1] {
+		case 'K':
+			mult = 1 << 10
+		case 'M':
+			mult = 1 << 20
+		case 'G':
+			mult = 1 << 30
+		case 'T':
+			mult = 1 << 40
+		}
+		if mult > 1 {
+			t = t[:len(t)-1]
+		}
+	}
+	n, err := strconv.ParseFloat(t, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q (want e.g. 512M, 4G)", s)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// FormatSize renders a byte count in the largest unit that keeps one decimal
+func FormatSize(n int64) string {
+	for _, u := range []struct {
+		suffix string
+		size   int64
+	}{{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}} {
+		if n >= u.size {
+			v := float64(n) / float64(u.size)
+			if v == float64(int64(v)) {
+				return fmt.Sprintf("%d%s", int64(v), u.suffix)
+			}
+			return fmt.Sprintf("%.1f%s", v, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// usage estimates the blocks and inodes the staged tree needs in ext4
+func (s *stage) usage() (bytes int64, inodes int64, err error) {
+	err = filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		inodes++
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.Mode().IsRegular():
+			bytes += (info.Size() + blockSize - 1) / blockSize * blockSize
+		case info.IsDir():
+			bytes += blockSize
+		case info.Mode()&fs.ModeSymlink != 0 && info.Size() >= 60:
+			bytes += blockSize // Short targets live in the inode
+		}
+		return nil
+	})
+	// Device nodes only exist in s.attrs until debugfs creates them
+	for _, a := range s.attrs {
+		if a.special() {
+			inodes++
+		}
+	}
+	return bytes, inodes, err
+}
+
+// imageSize picks the image size for the staged tree: size when pinned
+// (failing if the content can't fit), else content plus headroom
+func (s *stage) imageSize(size string) (int64, error) {
+	content, inodes, err := s.usage()
+	if err != nil {
+		return 0, err
+	}
+	// Metadata, journal and the 5% root reserve take roughly 15%
+	need := content * 115 / 100
+	if min := inodes * inodeRatio; min > need {
+		need = min
+	}
+	auto := (need + rootfsHeadroom + sizeAlign - 1) / sizeAlign * sizeAlign
+
+	if size == "" || size == "auto" {
+		return auto, nil
+	}
+	pinned, err := ParseSize(size)
+	if err != nil {
+		return 0, err
+	}
+	if pinned < need {
+		return 0, fmt.Errorf("rootfs content (%s in %d files) does not fit in RootfsSize %s - raise it or leave it empty to size automatically (%s)",
+			FormatSize(content), inodes, size, FormatSize(auto))
+	}
+	return pinned, nil
+}
@@ -0,0 +1,60 @@
+package rootfs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"512M", 512 << 20},
+		{"4G", 4 << 30},
+		{"1.5G", 3 << 29},
+		{"64k", 64 << 10},
+		{"2GiB", 2 << 30},
+		{"1TB", 1 << 40},
+		{"1048576", 1 << 20},
+		{" 8G ", 8 << 30},
+	}
+	for _, tt := range tests {
+		if got, err := ParseSize(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "G", "-1G", "0", "big", "4X"} {
+		if _, err := ParseSize(bad); err == nil {
+			t.Errorf("ParseSize(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{512 << 20: "512M", 3 << 29: "1.5G", 4 << 30: "4G", 1000: "1000"} {
+		if got := FormatSize(n); got != want {
+			t.Errorf("FormatSize(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
+func TestImageSize(t *testing.T) {
+	s := testStage(t)
+	auto, err := s.imageSize("auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auto%sizeAlign != 0 || auto < rootfsHeadroom {
+		t.Errorf("auto size %d not aligned or below the headroom", auto)
+	}
+	if got, err := s.imageSize(""); err != nil || got != auto {
+		t.Errorf(`imageSize("") = %d, %v, want %d`, got, err, auto)
+	}
+	if got, err := s.imageSize("1G"); err != nil || got != 1<<30 {
+		t.Errorf("pinned 1G = %d, %v", got, err)
+	}
+	if _, err := s.imageSize("4K"); err == nil || !strings.Contains(err.Error(), "does not fit") {
+		t.Errorf("pinned 4K: err = %v, want does not fit", err)
+	}
+}
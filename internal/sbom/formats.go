@@ -0,0 +1,204 @@
+// CycloneDX 1.5 and SPDX 2.3 JSON writers
+// TEAM_057: Only the fields scanners and our security team read; findings
+// are embedded in CycloneDX (SPDX 2.3 has no vulnerability section).
+package sbom
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Tool identifies the generator in both formats
+const Tool = "sovereign"
+
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// ref is a document-unique id: Go modules appear once per binary
+func (c Component) ref(distro string) string {
+	if c.Type == TypeGoModule || c.Type == TypeBinary {
+		return c.PURL(distro) + "#" + c.Path
+	}
+	return c.PURL(distro)
+}
+
+// Write renders inv (and findings, CycloneDX only) in format
+func Write(w io.Writer, format Format, inv *Inventory, findings []Finding) error {
+	var doc any
+	switch format {
+	case FormatSPDX:
+		doc = spdxDocument(inv)
+	default:
+		doc = cycloneDXDocument(inv, findings)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false) // PURL qualifiers contain &
+	return enc.Encode(doc)
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxComponent struct {
+	Type       string              `json:"type"`
+	BOMRef     string              `json:"bom-ref"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	PURL       string              `json:"purl,omitempty"`
+	Licenses   []map[string]string `json:"licenses,omitempty"`
+	Hashes     []map[string]string `json:"hashes,omitempty"`
+	Properties []cdxProperty       `json:"properties,omitempty"`
+}
+
+func cycloneDXDocument(inv *Inventory, findings []Finding) map[string]any {
+	var components []cdxComponent
+	for _, c := range inv.Components {
+		cc := cdxComponent{
+			Type:    "library",
+			BOMRef:  c.ref(inv.Distro),
+			Name:    c.Name,
+			Version: c.Version,
+			PURL:    c.PURL(inv.Distro),
+		}
+		if c.Type == TypeBinary {
+			cc.Type = "application"
+		}
+		if c.License != "" {
+			cc.Licenses = []map[string]string{{"expression": c.License}}
+		}
+		if c.SHA256 != "" {
+			cc.Hashes = []map[string]string{{"alg": "SHA-256", "content": c.SHA256}}
+		}
+		for _, p := range []cdxProperty{
+			{"sovereign:type", c.Type},
+			{"sovereign:path", c.Path},
+			{"sovereign:source-image", c.Source},
+			{"sovereign:origin", c.Origin},
+		} {
+			if p.Value != "" {
+				cc.Properties = append(cc.Properties, p)
+			}
+		}
+		components = append(components, cc)
+	}
+
+	var vulns []map[string]any
+	for _, f := range findings {
+		v := map[string]any{
+			"id":      f.ID,
+			"source":  map[string]string{"name": "OSV"},
+			"affects": []map[string]string{{"ref": f.Component.ref(inv.Distro)}},
+		}
+		if f.Summary != "" {
+			v["description"] = f.Summary
+		}
+		if f.Severity != "" {
+			v["ratings"] = []map[string]string{{"severity": cdxSeverity(f.Severity)}}
+		}
+		if f.FixedIn != "" {
+			v["recommendation"] = "Upgrade to " + f.FixedIn
+		}
+		vulns = append(vulns, v)
+	}
+
+	doc := map[string]any{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + newUUID(),
+		"version":      1,
+		"metadata": map[string]any{
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+			"tools": map[string]any{
+				"components": []map[string]string{{"type": "application", "name": Tool}},
+			},
+			"component": map[string]string{
+				"type":    "container",
+				"bom-ref": inv.Name,
+				"name":    inv.Name,
+				"version": inv.Version,
+			},
+		},
+		"components": components,
+	}
+	if len(vulns) > 0 {
+		doc["vulnerabilities"] = vulns
+	}
+	return doc
+}
+
+// cdxSeverity maps OSV labels to CycloneDX's enum
+func cdxSeverity(s string) string {
+	switch s {
+	case "CRITICAL", "HIGH", "LOW":
+		return strings.ToLower(s)
+	case "MODERATE", "MEDIUM":
+		return "medium"
+	}
+	return "unknown"
+}
+
+func spdxDocument(inv *Inventory) map[string]any {
+	var packages []map[string]any
+	var relationships []map[string]string
+	for i, c := range inv.Components {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		license := "NOASSERTION"
+		if c.License != "" {
+			license = c.License
+		}
+		p := map[string]any{
+			"SPDXID":           id,
+			"name":             c.Name,
+			"downloadLocation": "NOASSERTION",
+			"filesAnalyzed":    false,
+			"licenseConcluded": "NOASSERTION",
+			"licenseDeclared":  license,
+			"copyrightText":    "NOASSERTION",
+			"externalRefs": []map[string]string{{
+				"referenceCategory": "PACKAGE-MANAGER",
+				"referenceType":     "purl",
+				"referenceLocator":  c.PURL(inv.Distro),
+			}},
+		}
+		if c.Version != "" {
+			p["versionInfo"] = c.Version
+		}
+		if c.SHA256 != "" {
+			p["checksums"] = []map[string]string{{"algorithm": "SHA256", "checksumValue": c.SHA256}}
+		}
+		if c.Path != "" {
+			p["comment"] = fmt.Sprintf("%s at %s", c.Type, c.Path)
+		}
+		packages = append(packages, p)
+		relationships = append(relationships, map[string]string{
+			"spdxElementId":      "SPDXRef-DOCUMENT",
+			"relationshipType":   "DESCRIBES",
+			"relatedSpdxElement": id,
+		})
+	}
+	return map[string]any{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              inv.Name,
+		"documentNamespace": fmt.Sprintf("https://sovereign.invalid/spdx/%s-%s", inv.Name, newUUID()),
+		"creationInfo": map[string]any{
+			"created":  time.Now().UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: " + Tool},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+}
@@ -0,0 +1,295 @@
+// Package sbom inventories a VM rootfs and writes CycloneDX or SPDX JSON
+// TEAM_057: Answers "which PostgreSQL/Forgejo/Vaultwarden/Tailscale is in
+// rootfs.img" from the image itself: the apk database for packages, and the
+// ELF binaries no package owns (COPY --from, curl | tar) for everything else.
+package sbom
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"debug/buildinfo"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/anthropics/sovereign/internal/rootfs"
+)
+
+// Format selects the SBOM document type
+type Format string
+
+const (
+	FormatCycloneDX Format = "cyclonedx"
+	FormatSPDX      Format = "spdx"
+)
+
+// ParseFormat validates a --format value
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatCycloneDX:
+		return FormatCycloneDX, nil
+	case FormatSPDX:
+		return FormatSPDX, nil
+	}
+	return "", fmt.Errorf("unknown SBOM format %q (want cyclonedx or spdx)", s)
+}
+
+// Component types
+const (
+	TypeAPK      = "apk"       // Alpine package
+	TypeBinary   = "binary"    // Executable not owned by any package
+	TypeGoModule = "go-module" // Module compiled into a Go binary
+)
+
+// Component is one inventoried item
+type Component struct {
+	Type    string
+	Name    string
+	Version string // Empty when unknown
+	Arch    string
+	License string
+	Origin  string // apk source package
+	Path    string // binary: location in the image; go-module: binary it is linked into
+	SHA256  string // binary only
+	Source  string // binary: image it was copied from (Dockerfile COPY --from)
+}
+
+// PURL is the package URL identifying the component
+func (c Component) PURL(distro string) string {
+	switch c.Type {
+	case TypeAPK:
+		p := fmt.Sprintf("pkg:apk/alpine/%s@%s", c.Name, c.Version)
+		var q []string
+		if c.Arch != "" {
+			q = append(q, "arch="+c.Arch)
+		}
+		if distro != "" {
+			q = append(q, "distro=alpine-"+distro)
+		}
+		if len(q) > 0 {
+			p += "?" + strings.Join(q, "&")
+		}
+		return p
+	case TypeGoModule:
+		return fmt.Sprintf("pkg:golang/%s@%s", c.Name, c.Version)
+	}
+	if c.Version == "" {
+		return "pkg:generic/" + c.Name
+	}
+	return fmt.Sprintf("pkg:generic/%s@%s", c.Name, c.Version)
+}
+
+// Inventory is everything found in one rootfs
+type Inventory struct {
+	Name       string // Subject, e.g. "sovereign-sql"
+	Version    string // Image digest when known
+	Distro     string // /etc/alpine-release, e.g. "3.21.3"
+	Components []Component
+}
+
+// binDirs are searched for unpackaged executables
+var binDirs = []string{"bin", "sbin", "usr/bin", "usr/sbin", "usr/local/bin", "usr/local/sbin", "app"}
+
+func inBinDir(name string) bool {
+	for _, d := range binDirs {
+		if strings.HasPrefix(name, d+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// FromTar inventories a `docker export` tar. sources maps image paths to
+// the image they were copied from (see CopySources).
+func FromTar(tarPath string, sources map[string]string) (*Inventory, error) {
+	inv := &Inventory{}
+	owned := map[string]bool{}
+	var candidates []string
+
+	// Pass 1: package database, release, and ELF executables in bin dirs
+	err := walkTar(tarPath, func(hdr *tar.Header, name string, r io.Reader) error {
+		switch {
+		case name == rootfs.APKInstalled:
+			pkgs, err := rootfs.ParseAPKInstalled(r)
+			if err != nil {
+				return err
+			}
+			for _, p := range pkgs {
+				inv.Components = append(inv.Components, Component{
+					Type: TypeAPK, Name: p.Name, Version: p.Version,
+					Arch: p.Arch, License: p.License, Origin: p.Origin,
+				})
+				for _, f := range p.Files {
+					owned[f] = true
+				}
+			}
+		case name == "etc/alpine-release":
+			data, _ := io.ReadAll(io.LimitReader(r, 64))
+			inv.Distro = strings.TrimSpace(string(data))
+		case hdr.Typeflag == tar.TypeReg && hdr.Mode&0111 != 0 && inBinDir(name):
+			magic := make([]byte, 4)
+			if _, err := io.ReadFull(r, magic); err == nil && string(magic) == "\x7fELF" {
+				candidates = append(candidates, name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Pass 2: identify the executables no package installed
+	unowned := map[string]bool{}
+	for _, name := range candidates {
+		if !owned[name] {
+			unowned[name] = true
+		}
+	}
+	if len(unowned) > 0 {
+		err = walkTar(tarPath, func(hdr *tar.Header, name string, r io.Reader) error {
+			if !unowned[name] {
+				return nil
+			}
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return fmt.Errorf("reading /%s: %w", name, err)
+			}
+			inv.Components = append(inv.Components, inspectBinary("/"+name, data, sources)...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(inv.Components, func(i, j int) bool {
+		a, b := inv.Components[i], inv.Components[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Path < b.Path
+	})
+	return inv, nil
+}
+
+// walkTar calls fn for every entry, with names relative to / ("usr/bin/git")
+func walkTar(tarPath string, fn func(hdr *tar.Header, name string, r io.Reader) error) error {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading tar: %w", err)
+		}
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if err := fn(hdr, name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// ldflagsVersion matches version strings stamped in with -X, e.g.
+// -X "forgejo.org/modules/setting.AppVer=9.0.3" or
+// -X tailscale.com/version.shortStamp=1.92.3
+var ldflagsVersion = regexp.MustCompile(`-X\s*"?[\w./-]+\.(?:AppVer|[Vv]ersion|shortStamp|longStamp)=([^\s"']+)`)
+
+// inspectBinary describes an unpackaged executable, plus the modules it
+// links when it is a Go binary
+func inspectBinary(imgPath string, data []byte, sources map[string]string) []Component {
+	sum := sha256.Sum256(data)
+	bin := Component{
+		Type:   TypeBinary,
+		Name:   path.Base(imgPath),
+		Path:   imgPath,
+		SHA256: hex.EncodeToString(sum[:]),
+		Source: sources[imgPath],
+	}
+	info, err := buildinfo.Read(bytes.NewReader(data))
+	if err != nil {
+		// Not Go (vaultwarden is Rust) - fall back to the tag it was copied from
+		if _, tag, ok := strings.Cut(path.Base(bin.Source), ":"); ok && tag != "latest" && !strings.HasPrefix(tag, "latest-") {
+			bin.Version = tag
+		}
+		return []Component{bin}
+	}
+
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		bin.Version = strings.TrimPrefix(v, "v")
+	}
+	for _, s := range info.Settings {
+		if s.Key == "-ldflags" && bin.Version == "" {
+			if m := ldflagsVersion.FindStringSubmatch(s.Value); m != nil {
+				bin.Version = strings.TrimPrefix(m[1], "v")
+			}
+		}
+	}
+	out := []Component{bin}
+	if info.Main.Path != "" {
+		out = append(out, Component{Type: TypeGoModule, Name: info.Main.Path, Version: normalizeGoVersion(info.Main.Version, bin.Version), Path: imgPath})
+	}
+	out = append(out, Component{Type: TypeGoModule, Name: "stdlib", Version: strings.TrimPrefix(info.GoVersion, "go"), Path: imgPath})
+	for _, dep := range info.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		out = append(out, Component{Type: TypeGoModule, Name: dep.Path, Version: dep.Version, Path: imgPath})
+	}
+	return out
+}
+
+// normalizeGoVersion gives the main module a version when it was built
+// outside module mode ("(devel)") but stamped one with -ldflags
+func normalizeGoVersion(modVersion, stamped string) string {
+	if modVersion != "" && modVersion != "(devel)" {
+		return modVersion
+	}
+	if stamped != "" {
+		return "v" + stamped
+	}
+	return modVersion
+}
+
+var copyFrom = regexp.MustCompile(`(?i)^\s*COPY\s+--from=(\S+)\s+(\S+)\s+(\S+)\s*$`)
+
+// CopySources maps the destinations of `COPY --from=<image> src dst` lines in
+// a Dockerfile to their image, so copied binaries can say where they came from
+func CopySources(dockerfile string) map[string]string {
+	sources := map[string]string{}
+	data, err := os.ReadFile(dockerfile)
+	if err != nil {
+		return sources
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if m := copyFrom.FindStringSubmatch(line); m != nil {
+			sources[path.Clean(m[3])] = m[1]
+		}
+	}
+	return sources
+}
+
+// Find returns the components named name (any type)
+func (inv *Inventory) Find(name string) []Component {
+	var out []Component
+	for _, c := range inv.Components {
+		if c.Name == name {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,392 @@
+// Offline vulnerability matching against an OSV database
+// TEAM_057: The phone and CI often have no network, so the database is a
+// local file: an OSV JSON record, an array of them, a directory of them, or
+// the per-ecosystem all.zip export from osv.dev (Alpine, Go).
+package sbom
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// osvRecord is the subset of the OSV schema used for matching
+type osvRecord struct {
+	ID               string         `json:"id"`
+	Aliases          []string       `json:"aliases"`
+	Summary          string         `json:"summary"`
+	Details          string         `json:"details"`
+	Severity         []osvSeverity  `json:"severity"`
+	Affected         []osvAffected  `json:"affected"`
+	DatabaseSpecific map[string]any `json:"database_specific"`
+}
+
+type osvSeverity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges []struct {
+		Type   string              `json:"type"`
+		Events []map[string]string `json:"events"`
+	} `json:"ranges"`
+	Versions []string `json:"versions"`
+}
+
+// VulnDB is a loaded OSV database indexed by ecosystem and package
+type VulnDB struct {
+	byPackage map[string][]*osvRecord // "alpine/musl", "go/golang.org/x/net"
+	Records   int
+}
+
+// Finding is one vulnerability affecting one component
+type Finding struct {
+	ID        string    `json:"id"`
+	Aliases   []string  `json:"aliases,omitempty"`
+	Summary   string    `json:"summary,omitempty"`
+	Severity  string    `json:"severity,omitempty"` // HIGH, or a CVSS vector
+	FixedIn   string    `json:"fixed_in,omitempty"`
+	Component Component `json:"-"`
+}
+
+// LoadVulnDB reads an OSV database from a .json file, a .zip or a directory
+func LoadVulnDB(p string) (*VulnDB, error) {
+	db := &VulnDB{byPackage: map[string][]*osvRecord{}}
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case info.IsDir():
+		err = filepath.WalkDir(p, func(file string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(file, ".json") {
+				return err
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			return db.add(file, data)
+		})
+	case strings.HasSuffix(p, ".zip"):
+		err = db.loadZip(p)
+	default:
+		var data []byte
+		if data, err = os.ReadFile(p); err == nil {
+			err = db.add(p, data)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading vulnerability database %s: %w", p, err)
+	}
+	if db.Records == 0 {
+		return nil, fmt.Errorf("no OSV records in %s", p)
+	}
+	return db, nil
+}
+
+func (db *VulnDB) loadZip(p string) error {
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if err := db.add(f.Name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add indexes one JSON document: a record or an array of records
+func (db *VulnDB) add(name string, data []byte) error {
+	var records []*osvRecord
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	} else {
+		var r osvRecord
+		if err := json.Unmarshal(data, &r); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		records = []*osvRecord{&r}
+	}
+	for _, r := range records {
+		if r.ID == "" {
+			continue
+		}
+		db.Records++
+		seen := map[string]bool{}
+		for _, a := range r.Affected {
+			key := packageKey(a.Package.Ecosystem, a.Package.Name)
+			if !seen[key] {
+				seen[key] = true
+				db.byPackage[key] = append(db.byPackage[key], r)
+			}
+		}
+	}
+	return nil
+}
+
+// packageKey indexes by ecosystem without its release suffix ("Alpine:v3.21")
+func packageKey(ecosystem, name string) string {
+	base, _, _ := strings.Cut(ecosystem, ":")
+	return strings.ToLower(base) + "/" + name
+}
+
+// ecosystemOf maps a component to its OSV ecosystem; "" for unmatched types
+func ecosystemOf(c Component) string {
+	switch c.Type {
+	case TypeAPK:
+		return "Alpine"
+	case TypeGoModule:
+		return "Go"
+	}
+	return ""
+}
+
+// Match returns the findings for every component of inv, worst first
+func (db *VulnDB) Match(inv *Inventory) []Finding {
+	// OSV Alpine ecosystems carry the release branch: "Alpine:v3.21"
+	release := ""
+	if parts := strings.SplitN(inv.Distro, ".", 3); len(parts) >= 2 {
+		release = "v" + parts[0] + "." + parts[1]
+	}
+
+	var findings []Finding
+	for _, c := range inv.Components {
+		eco := ecosystemOf(c)
+		if eco == "" || c.Version == "" {
+			continue
+		}
+		names := []string{c.Name}
+		if c.Origin != "" && c.Origin != c.Name {
+			names = append(names, c.Origin) // Alpine advisories use source packages
+		}
+		seen := map[string]bool{}
+		for _, name := range names {
+			for _, r := range db.byPackage[packageKey(eco, name)] {
+				if seen[r.ID] {
+					continue
+				}
+				for _, a := range r.Affected {
+					if packageKey(a.Package.Ecosystem, a.Package.Name) != packageKey(eco, name) {
+						continue
+					}
+					if _, rel, ok := strings.Cut(a.Package.Ecosystem, ":"); ok && eco == "Alpine" && release != "" && rel != release {
+						continue
+					}
+					if affected, fixed := a.affects(c.Version); affected {
+						seen[r.ID] = true
+						findings = append(findings, Finding{
+							ID: r.ID, Aliases: r.Aliases, Summary: r.summary(),
+							Severity: r.severity(), FixedIn: fixed, Component: c,
+						})
+						break
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank(findings[i].Severity) > severityRank(findings[j].Severity)
+	})
+	return findings
+}
+
+func (r *osvRecord) summary() string {
+	if r.Summary != "" {
+		return r.Summary
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(r.Details), "\n")
+	if len(line) > 120 {
+		line = line[:117] + "..."
+	}
+	return line
+}
+
+// severity prefers the database's label (GHSA "HIGH") over a CVSS vector
+func (r *osvRecord) severity() string {
+	if s, ok := r.DatabaseSpecific["severity"].(string); ok && s != "" {
+		return strings.ToUpper(s)
+	}
+	if len(r.Severity) > 0 {
+		return r.Severity[0].Score
+	}
+	return ""
+}
+
+func severityRank(s string) int {
+	switch s {
+	case "CRITICAL":
+		return 4
+	case "HIGH":
+		return 3
+	case "MODERATE", "MEDIUM":
+		return 2
+	case "LOW":
+		return 1
+	}
+	return 0
+}
+
+// affects reports whether version falls in an affected range, and the first
+// fixed version above it
+func (a osvAffected) affects(version string) (bool, string) {
+	for _, v := range a.Versions {
+		if compareVersions(v, version) == 0 {
+			return true, ""
+		}
+	}
+	for _, r := range a.Ranges {
+		if r.Type != "ECOSYSTEM" && r.Type != "SEMVER" {
+			continue // GIT ranges need the source tree
+		}
+		affected := false
+		fixed := ""
+		for _, ev := range r.Events {
+			switch {
+			case ev["introduced"] != "":
+				if ev["introduced"] == "0" || compareVersions(version, ev["introduced"]) >= 0 {
+					affected = true
+				}
+			case ev["fixed"] != "":
+				if compareVersions(version, ev["fixed"]) >= 0 {
+					affected = false
+				} else if affected && fixed == "" {
+					fixed = ev["fixed"]
+				}
+			case ev["last_affected"] != "":
+				if compareVersions(version, ev["last_affected"]) > 0 {
+					affected = false
+				}
+			}
+		}
+		if affected {
+			return true, fixed
+		}
+	}
+	return false, ""
+}
+
+// versionToken is a run of digits or letters
+type versionToken struct {
+	num   int64
+	str   string
+	isNum bool
+}
+
+// preRelease suffixes sort before the release they precede (1.0_rc1 < 1.0)
+func (t versionToken) preRelease() bool {
+	switch t.str {
+	case "alpha", "beta", "pre", "rc", "a", "b":
+		return true
+	}
+	return false
+}
+
+func tokenizeVersion(v string) []versionToken {
+	v = strings.TrimPrefix(v, "v")
+	var toks []versionToken
+	for i := 0; i < len(v); {
+		c := v[i]
+		j := i
+		switch {
+		case c >= '0' && c <= '9':
+			for j < len(v) && v[j] >= '0' && v[j] <= '9' {
+				j++
+			}
+			n, _ := strconv.ParseInt(v[i:j], 10, 64)
+			toks = append(toks, versionToken{num: n, isNum: true})
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			for j < len(v) && ((v[j] >= 'a' && v[j] <= 'z') || (v[j] >= 'A' && v[j] <= 'Z')) {
+				j++
+			}
+			toks = append(toks, versionToken{str: strings.ToLower(v[i:j])})
+		default:
+			j++ // Separator
+		}
+		i = j
+	}
+	return toks
+}
+
+// compareVersions orders apk ("1.2.5-r9", "17.2_rc1-r0") and semver-ish
+// ("v0.31.0") versions: digit runs numerically, letter runs as strings,
+// pre-release suffixes before everything else. Good enough for matching
+// advisories; not a full implementation of either scheme.
+func compareVersions(a, b string) int {
+	ta, tb := tokenizeVersion(a), tokenizeVersion(b)
+	for i := 0; i < len(ta) || i < len(tb); i++ {
+		switch {
+		case i >= len(ta):
+			if tb[i].preRelease() {
+				return 1
+			}
+			return -1
+		case i >= len(tb):
+			if ta[i].preRelease() {
+				return -1
+			}
+			return 1
+		}
+		x, y := ta[i], tb[i]
+		switch {
+		case x.isNum && y.isNum:
+			if x.num != y.num {
+				if x.num < y.num {
+					return -1
+				}
+				return 1
+			}
+		case x.isNum:
+			return 1 // 1.2.5.1 > 1.2.5-r9
+		case y.isNum:
+			return -1
+		default:
+			if x.preRelease() != y.preRelease() {
+				if x.preRelease() {
+					return -1
+				}
+				return 1
+			}
+			// apk: the -r revision sorts below post-release suffixes (_p1, _git)
+			if (x.str == "r") != (y.str == "r") {
+				if x.str == "r" {
+					return -1
+				}
+				return 1
+			}
+			if c := strings.Compare(x.str, y.str); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
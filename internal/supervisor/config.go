@@ -0,0 +1,228 @@
+// Package supervisor is the on-device process supervisor for crosvm VMs.
+// TEAM_051: Replaces the sovereign_start.sh watchdog loop. Built as a static
+// arm64 binary (cmd/sovereign-supervisor), pushed by common.DeployBootScript
+// and exec'd by the boot script. It owns crosvm as child processes, restarts
+// them with backoff, writes its state to a JSON file and answers the CLI over
+// a unix socket, so the CLI no longer has to grep `ps` output.
+//
+// This package must not import the vm packages: it runs on the phone, and
+// the CLI side (common) imports it for the wire types.
+package supervisor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Device paths
+const (
+	DefaultConfigPath = "/data/sovereign/supervisor.json"
+	DefaultSocketPath = "/data/sovereign/supervisor.sock"
+	DefaultStatePath  = "/data/sovereign/supervisor.state.json"
+	DefaultEnvFile    = "/data/sovereign/.env"
+	DefaultLogPath    = "/data/sovereign/supervisor.log"
+)
+
+const (
+	crosvmPath    = "/apex/com.android.virt/bin/crosvm"
+	crosvmLibPath = "/apex/com.android.virt/lib64:/system/lib64"
+	baseParams    = "earlycon console=ttyS0 root=/dev/vda rw init=/sbin/init.sh"
+
+	defaultMemMB     = 1024
+	defaultCPUs      = 2
+	defaultStopGrace = 30
+
+	// TEAM_052: Crash-loop limits - more than defaultMaxRetries unexpected
+	// exits within defaultRestartWindow seconds and the VM is left down
+	defaultMaxRetries    = 5
+	defaultRestartWindow = 600
+)
+
+// Restart policies, matching common.RestartMode
+const (
+	RestartNever     = "never"      // Never restart after crosvm exits
+	RestartOnFailure = "on-failure" // Restart unless crosvm exited 0 (guest powered off)
+	RestartAlways    = "always"     // Restart after any exit that wasn't requested
+)
+
+// Config is supervisor.json, generated by the CLI from the registered VMConfigs
+type Config struct {
+	BridgeName string `json:"bridge_name"`
+	BridgeCIDR string `json:"bridge_cidr"`
+	Subnet     string `json:"subnet"`
+	Uplink     string `json:"uplink"` // NAT egress interface, "wlan0"
+	VMs        []Spec `json:"vms"`
+}
+
+// Spec describes how to run one VM
+type Spec struct {
+	Name   string `json:"name"`
+	Dir    string `json:"dir"` // Holds rootfs.img, Image, optional data.img
+	TAP    string `json:"tap"`
+	Params string `json:"params,omitempty"` // Extra kernel params (network config etc.)
+	// Kernel param -> key in the device .env. Resolved on the device so
+	// passwords never end up in supervisor.json.
+	SecretParams map[string]string `json:"secret_params,omitempty"`
+	MemMB        int               `json:"mem_mb,omitempty"`
+	CPUs         int               `json:"cpus,omitempty"`
+	StopGrace    int               `json:"stop_grace,omitempty"` // Seconds for a clean guest shutdown
+	DependsOn    []string          `json:"depends_on,omitempty"`
+	ReadyAddr    string            `json:"ready_addr,omitempty"` // "ip:port" dependents wait for
+
+	// TEAM_052: Restart policy
+	Restart       string `json:"restart,omitempty"`        // RestartNever, RestartOnFailure, RestartAlways (default)
+	MaxRetries    int    `json:"max_retries,omitempty"`    // Restarts allowed within RestartWindow
+	RestartWindow int    `json:"restart_window,omitempty"` // Seconds; a longer run also resets backoff
+}
+
+// LoadConfig reads and validates supervisor.json
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if cfg.BridgeName == "" || cfg.BridgeCIDR == "" || cfg.Subnet == "" {
+		return nil, fmt.Errorf("%s: bridge_name, bridge_cidr and subnet are required", path)
+	}
+	if cfg.Uplink == "" {
+		cfg.Uplink = "wlan0"
+	}
+	seen := map[string]bool{}
+	for i := range cfg.VMs {
+		s := &cfg.VMs[i]
+		if s.Name == "" || s.Dir == "" || s.TAP == "" {
+			return nil, fmt.Errorf("%s: vm #%d needs name, dir and tap", path, i+1)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("%s: duplicate vm %q", path, s.Name)
+		}
+		seen[s.Name] = true
+		if s.MemMB == 0 {
+			s.MemMB = defaultMemMB
+		}
+		if s.CPUs == 0 {
+			s.CPUs = defaultCPUs
+		}
+		if s.StopGrace == 0 {
+			s.StopGrace = defaultStopGrace
+		}
+		switch s.Restart {
+		case "":
+			s.Restart = RestartAlways
+		case RestartNever, RestartOnFailure, RestartAlways:
+		default:
+			return nil, fmt.Errorf("%s: vm %q has unknown restart policy %q", path, s.Name, s.Restart)
+		}
+		if s.MaxRetries == 0 {
+			s.MaxRetries = defaultMaxRetries
+		}
+		if s.RestartWindow == 0 {
+			s.RestartWindow = defaultRestartWindow
+		}
+	}
+	return &cfg, nil
+}
+
+// spec returns the spec for name
+func (c *Config) spec(name string) (Spec, bool) {
+	for _, s := range c.VMs {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Spec{}, false
+}
+
+// readEnvFile parses KEY=VALUE lines (the format sovereign_start.sh sources)
+func readEnvFile(path string) map[string]string {
+	env := map[string]string{}
+	f, err := os.Open(path)
+	if err != nil {
+		return env
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(sc.Text()), "export "))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		env[strings.TrimSpace(k)] = strings.Trim(strings.TrimSpace(v), `"'`)
+	}
+	return env
+}
+
+// deployed reports whether the VM's images are on the device
+func (s Spec) deployed() error {
+	for _, f := range []string{"rootfs.img", "Image"} {
+		if _, err := os.Stat(filepath.Join(s.Dir, f)); err != nil {
+			return fmt.Errorf("%s/%s not found", s.Dir, f)
+		}
+	}
+	return nil
+}
+
+func (s Spec) socketPath() string  { return filepath.Join(s.Dir, "vm.sock") }
+func (s Spec) consolePath() string { return filepath.Join(s.Dir, "console.log") }
+func (s Spec) pidPath() string     { return filepath.Join(s.Dir, "vm.pid") }
+func (s Spec) pausedPath() string  { return filepath.Join(s.Dir, "vm.paused") }
+
+// kernelParams builds the guest cmdline, same layout as start_vm in sovereign_start.sh
+func (s Spec) kernelParams(env map[string]string) string {
+	params := []string{baseParams}
+	if key := env["TAILSCALE_AUTHKEY"]; key != "" {
+		params = append(params, "tailscale.authkey="+key)
+	}
+	names := make([]string, 0, len(s.SecretParams))
+	for param := range s.SecretParams {
+		names = append(names, param)
+	}
+	sort.Strings(names)
+	for _, param := range names {
+		if v := env[s.SecretParams[param]]; v != "" {
+			params = append(params, param+"="+v)
+		}
+	}
+	if s.Params != "" {
+		params = append(params, s.Params)
+	}
+	return strings.Join(params, " ")
+}
+
+// crosvmArgs returns the argv for `crosvm run`
+func (s Spec) crosvmArgs(env map[string]string) []string {
+	args := []string{"run", "--disable-sandbox",
+		"--mem", strconv.Itoa(s.MemMB),
+		"--cpus", strconv.Itoa(s.CPUs),
+		"--block", "path=" + filepath.Join(s.Dir, "rootfs.img") + ",root",
+	}
+	if data := filepath.Join(s.Dir, "data.img"); fileExists(data) {
+		args = append(args, "--block", "path="+data)
+	}
+	return append(args,
+		"--params", s.kernelParams(env),
+		"--serial", "type=stdout",
+		"--net", "tap-name="+s.TAP,
+		"--socket", s.socketPath(),
+		filepath.Join(s.Dir, "Image"),
+	)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,172 @@
+// Control endpoint: one JSON request / one JSON response per unix socket connection
+// TEAM_051: The CLI reaches it through `sovereign-supervisor ctl` over adb/ssh
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Control operations
+const (
+	OpStatus = "status"
+	OpStart  = "start"
+	OpStop   = "stop"
+	OpReload = "reload"
+)
+
+// Request is sent by the client
+type Request struct {
+	Op string `json:"op"`
+	VM string `json:"vm,omitempty"`
+}
+
+// Response is returned for every request
+type Response struct {
+	OK     bool       `json:"ok"`
+	Error  string     `json:"error,omitempty"`
+	Method string     `json:"method,omitempty"` // Stop method for OpStop
+	VMs    []VMStatus `json:"vms,omitempty"`
+}
+
+// ErrRunning means another supervisor already owns the control socket
+var ErrRunning = errors.New("another supervisor is running")
+
+// Listen binds the control socket for this supervisor. A second supervisor
+// must not take over the socket: the first still owns its crosvm children,
+// which nothing could stop any more. A flock next to the socket, held until
+// the listener closes, decides; a socket that still answers (a supervisor
+// from before the lock) counts too. A socket left by a dead one is replaced.
+func Listen(socketPath string) (net.Listener, error) {
+	lock, err := os.OpenFile(socketPath+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		lock.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, fmt.Errorf("%w (%s is locked)", ErrRunning, socketPath)
+		}
+		return nil, fmt.Errorf("locking %s: %w", socketPath, err)
+	}
+	if conn, err := net.DialTimeout("unix", socketPath, time.Second); err == nil {
+		conn.Close()
+		lock.Close()
+		return nil, fmt.Errorf("%w (%s answers)", ErrRunning, socketPath)
+	}
+
+	os.Remove(socketPath)
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	os.Chmod(socketPath, 0600)
+	return &lockedListener{Listener: ln, lock: lock}, nil
+}
+
+// lockedListener releases the socket lock when it is closed
+type lockedListener struct {
+	net.Listener
+	lock *os.File
+}
+
+func (l *lockedListener) Close() error {
+	err := l.Listener.Close()
+	l.lock.Close()
+	return err
+}
+
+// Serve binds socketPath with Listen and serves it until ctx is cancelled
+func (s *Supervisor) Serve(ctx context.Context, socketPath string) error {
+	ln, err := Listen(socketPath)
+	if err != nil {
+		return err
+	}
+	return s.ServeListener(ctx, ln)
+}
+
+// ServeListener accepts control connections on ln until ctx is cancelled,
+// then closes it
+func (s *Supervisor) ServeListener(ctx context.Context, ln net.Listener) error {
+	defer ln.Close() // releases the socket lock before returning
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Supervisor) handle(conn net.Conn) {
+	defer conn.Close()
+	var req Request
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(Response{Error: "bad request: " + err.Error()})
+		return
+	}
+	resp := s.dispatch(req)
+	json.NewEncoder(conn).Encode(resp)
+}
+
+func (s *Supervisor) dispatch(req Request) Response {
+	var resp Response
+	var err error
+	switch req.Op {
+	case OpStatus:
+		resp.VMs, err = s.Status(req.VM)
+	case OpStart:
+		if err = s.Start(req.VM); err == nil {
+			resp.VMs, err = s.Status(req.VM)
+		}
+	case OpStop:
+		resp.Method, err = s.Stop(req.VM)
+	case OpReload:
+		if err = s.Reload(); err == nil {
+			resp.VMs, err = s.Status("")
+		}
+	default:
+		err = fmt.Errorf("unknown op %q", req.Op)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		return resp
+	}
+	resp.OK = true
+	return resp
+}
+
+// Call sends one request to a running supervisor. Stop can take the whole
+// grace period, so timeout should cover the longest StopGrace plus escalation.
+func Call(socketPath string, req Request, timeout time.Duration) (*Response, error) {
+	conn, err := net.DialTimeout("unix", socketPath, 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
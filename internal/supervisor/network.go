@@ -0,0 +1,79 @@
+// Host networking for supervised VMs
+// TEAM_051: Port of setup_networking/setup_tap from sovereign_start.sh
+package supervisor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// sh runs a shell snippet, returning combined output on failure
+func sh(cmd string) error {
+	out, err := exec.Command("sh", "-c", cmd).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w (%s)", cmd, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// setupNetworking creates the bridge, enables forwarding and installs NAT.
+// Every step is idempotent, as in the shell version.
+func (s *Supervisor) setupNetworking() error {
+	c := s.cfg
+	if err := sh(fmt.Sprintf("ip link show %s >/dev/null 2>&1", c.BridgeName)); err != nil {
+		for _, cmd := range []string{
+			fmt.Sprintf("ip link add %s type bridge", c.BridgeName),
+			fmt.Sprintf("ip addr add %s dev %s", c.BridgeCIDR, c.BridgeName),
+			fmt.Sprintf("ip link set %s up", c.BridgeName),
+		} {
+			if err := sh(cmd); err != nil {
+				return err
+			}
+		}
+		s.logf("Created bridge %s", c.BridgeName)
+	}
+
+	os.WriteFile("/proc/sys/net/ipv4/ip_forward", []byte("1"), 0644)
+	os.WriteFile(fmt.Sprintf("/proc/sys/net/ipv4/conf/%s/rp_filter", c.BridgeName), []byte("0"), 0644)
+	os.WriteFile("/proc/sys/net/ipv4/conf/all/rp_filter", []byte("0"), 0644)
+
+	// KEY FIX: Bypass Android policy routing (see sovereign_start.sh)
+	sh("ip rule del from all lookup main pref 1 2>/dev/null")
+	if err := sh("ip rule add from all lookup main pref 1"); err != nil {
+		return err
+	}
+	sh(fmt.Sprintf("GW=$(ip route show table %[1]s | awk '/default/ {print $3}'); "+
+		"[ -n \"$GW\" ] && ip route del default 2>/dev/null; [ -n \"$GW\" ] && ip route add default via $GW dev %[1]s", c.Uplink))
+
+	sh(fmt.Sprintf("iptables -t nat -D POSTROUTING -s %s -o %s -j MASQUERADE 2>/dev/null", c.Subnet, c.Uplink))
+	sh(fmt.Sprintf("iptables -D FORWARD -i %s -o %s -j ACCEPT 2>/dev/null", c.BridgeName, c.Uplink))
+	sh(fmt.Sprintf("iptables -D FORWARD -i %s -o %s -m state --state RELATED,ESTABLISHED -j ACCEPT 2>/dev/null", c.Uplink, c.BridgeName))
+	for _, cmd := range []string{
+		fmt.Sprintf("iptables -t nat -A POSTROUTING -s %s -o %s -j MASQUERADE", c.Subnet, c.Uplink),
+		fmt.Sprintf("iptables -I FORWARD 1 -i %s -o %s -j ACCEPT", c.BridgeName, c.Uplink),
+		fmt.Sprintf("iptables -I FORWARD 2 -i %s -o %s -m state --state RELATED,ESTABLISHED -j ACCEPT", c.Uplink, c.BridgeName),
+	} {
+		if err := sh(cmd); err != nil {
+			return err
+		}
+	}
+	s.logf("Networking configured")
+	return nil
+}
+
+// setupTAP (re)creates the VM's TAP and attaches it to the bridge
+func (s *Supervisor) setupTAP(tap string) error {
+	sh(fmt.Sprintf("ip link del %s 2>/dev/null", tap))
+	for _, cmd := range []string{
+		fmt.Sprintf("ip tuntap add mode tap name %s", tap),
+		fmt.Sprintf("ip link set %s master %s", tap, s.cfg.BridgeName),
+		fmt.Sprintf("ip link set %s up", tap),
+	} {
+		if err := sh(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Supervisor state, persisted for `sovereign status` and post-mortems
+// TEAM_051: Written atomically on every transition
+package supervisor
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+)
+
+// State is the supervisor's view of one VM
+type State string
+
+const (
+	StateStopped  State = "stopped"  // Not wanted, not running
+	StateStarting State = "starting" // Waiting for dependencies / launching crosvm
+	StateRunning  State = "running"
+	StateBackoff  State = "backoff"  // Exited unexpectedly, restart scheduled
+	StateStopping State = "stopping" // Shutdown in progress
+	StateFailed   State = "failed"   // TEAM_052: Exited and not restarted (policy or crash loop)
+)
+
+// Stop methods, matching common.StopMethod
+const (
+	StopNotRunning  = "not-running"
+	StopPowerButton = "powerbtn"
+	StopCrosvmStop  = "crosvm-stop"
+	StopSIGTERM     = "sigterm"
+	StopSIGKILL     = "sigkill"
+)
+
+// VMStatus is reported over the control socket and in the state file
+type VMStatus struct {
+	Name        string    `json:"name"`
+	State       State     `json:"state"`
+	PID         int       `json:"pid,omitempty"`
+	Paused      bool      `json:"paused,omitempty"`
+	StartedAt   time.Time `json:"started_at,omitzero"`
+	Restarts    int       `json:"restarts"`
+	LastExit    string    `json:"last_exit,omitempty"` // "exit status 1", "signal: killed"
+	LastExitAt  time.Time `json:"last_exit_at,omitzero"`
+	NextRestart time.Time `json:"next_restart,omitzero"`
+	LastStop    string    `json:"last_stop,omitempty"` // Stop method of the last requested stop
+
+	// TEAM_052: Post-mortem of the last unexpected exit
+	CrashLoop   bool     `json:"crash_loop,omitempty"`   // Gave up after MaxRetries restarts in RestartWindow
+	ConsoleTail []string `json:"console_tail,omitempty"` // Last lines of console.log at that exit
+}
+
+// StateFile is the JSON document at DefaultStatePath
+type StateFile struct {
+	PID       int        `json:"pid"` // Supervisor PID
+	UpdatedAt time.Time  `json:"updated_at"`
+	VMs       []VMStatus `json:"vms"`
+}
+
+// snapshot copies every VM's status, sorted by name. Caller holds s.mu.
+func (s *Supervisor) snapshot() []VMStatus {
+	out := make([]VMStatus, 0, len(s.vms))
+	for _, p := range s.vms {
+		st := p.status
+		st.Paused = st.PID != 0 && fileExists(p.spec.pausedPath())
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// saveState writes the state file via rename so readers never see a partial file.
+// Caller holds s.mu.
+func (s *Supervisor) saveState() {
+	if s.statePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(StateFile{PID: os.Getpid(), UpdatedAt: time.Now(), VMs: s.snapshot()}, "", "  ")
+	if err != nil {
+		return
+	}
+	tmp := s.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		s.logf("WARNING: writing state: %v", err)
+		return
+	}
+	os.Rename(tmp, s.statePath)
+}
@@ -0,0 +1,560 @@
+// Supervision loop: one goroutine per VM owning its crosvm child
+// TEAM_051: crosvm stays our child (Android init kills orphaned crosvm after
+// ~90s), is restarted with exponential backoff when it dies unexpectedly, and
+// is shut down with the same powerbtn -> crosvm stop -> SIGTERM -> SIGKILL
+// escalation as common.ShutdownVM.
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Restart backoff: 5s, 10s, 20s ... capped at 5m. Only exits within the
+// VM's RestartWindow count, so a VM that stayed up that long starts over.
+const (
+	backoffBase = 5 * time.Second
+	backoffMax  = 5 * time.Minute
+
+	dependencyWait = 120 * time.Second
+
+	// TEAM_052: console.log lines kept for post-mortems
+	consoleTailLines = 20
+)
+
+// Options locate the supervisor's files; zero values use the Default* paths
+type Options struct {
+	ConfigPath string
+	StatePath  string
+	EnvPath    string
+	Logger     *log.Logger
+}
+
+// Supervisor runs and watches every VM in supervisor.json
+type Supervisor struct {
+	configPath string
+	statePath  string
+	envPath    string
+	log        *log.Logger
+
+	ctx context.Context // Set by Run; nil while the socket is served before it
+	mu  sync.Mutex
+	cfg *Config
+	vms map[string]*vmProc
+}
+
+// vmProc is the supervisor's record of one VM. Fields are guarded by Supervisor.mu.
+type vmProc struct {
+	spec      Spec
+	status    VMStatus
+	want      bool          // Desired state: running
+	cmd       *exec.Cmd     // Current crosvm, nil when not running
+	exited    chan struct{} // Closed when cmd exits
+	kick      chan struct{} // Wakes the run loop after want changes
+	failures  []time.Time   // TEAM_052: Unexpected exits within the restart window
+	cleanExit bool          // Last crosvm exited with status 0
+}
+
+// New loads the config and prepares a supervisor; Run starts it
+func New(opts Options) (*Supervisor, error) {
+	if opts.ConfigPath == "" {
+		opts.ConfigPath = DefaultConfigPath
+	}
+	if opts.StatePath == "" {
+		opts.StatePath = DefaultStatePath
+	}
+	if opts.EnvPath == "" {
+		opts.EnvPath = DefaultEnvFile
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.New(os.Stderr, "[supervisor] ", log.LstdFlags)
+	}
+	cfg, err := LoadConfig(opts.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Supervisor{
+		configPath: opts.ConfigPath,
+		statePath:  opts.StatePath,
+		envPath:    opts.EnvPath,
+		log:        opts.Logger,
+		cfg:        cfg,
+		vms:        map[string]*vmProc{},
+	}, nil
+}
+
+func (s *Supervisor) logf(format string, args ...interface{}) {
+	s.log.Printf(format, args...)
+}
+
+// Run sets up networking, starts a loop per VM and blocks until ctx is
+// cancelled, then shuts every VM down. With autostart (boot), every deployed
+// VM is started in dependency order; otherwise VMs wait for a start request.
+func (s *Supervisor) Run(ctx context.Context, autostart bool) error {
+	if err := s.setupNetworking(); err != nil {
+		s.logf("WARNING: networking setup: %v", err)
+	}
+	s.start(ctx, autostart)
+
+	<-ctx.Done()
+	s.logf("Shutting down - stopping all VMs")
+	s.StopAll()
+	return nil
+}
+
+// start registers every VM in the config and starts the loops. The control
+// socket is served before Run gets here, so VMs a reload already registered
+// only get their loops now.
+func (s *Supervisor) start(ctx context.Context, autostart bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ctx = ctx
+	for name, p := range s.vms {
+		go s.loop(ctx, p, name)
+	}
+	for _, spec := range s.cfg.VMs {
+		p, ok := s.vms[spec.Name]
+		if !ok {
+			p = s.addLocked(spec)
+		}
+		if autostart && spec.deployed() == nil {
+			p.want = true
+			p.wake()
+		}
+	}
+	s.saveState()
+}
+
+// addLocked registers a VM and, once Run has started, its loop. Caller holds s.mu.
+func (s *Supervisor) addLocked(spec Spec) *vmProc {
+	p := &vmProc{
+		spec:   spec,
+		status: VMStatus{Name: spec.Name, State: StateStopped},
+		kick:   make(chan struct{}, 1),
+	}
+	s.vms[spec.Name] = p
+	if s.ctx != nil {
+		go s.loop(s.ctx, p, spec.Name)
+	}
+	return p
+}
+
+func (p *vmProc) wake() {
+	select {
+	case p.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Supervisor) wanted(p *vmProc) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return p.want
+}
+
+func (s *Supervisor) setState(p *vmProc, st State) {
+	s.mu.Lock()
+	p.status.State = st
+	s.saveState()
+	s.mu.Unlock()
+}
+
+// loop drives one VM: wait until wanted, launch, wait for exit, back off
+func (s *Supervisor) loop(ctx context.Context, p *vmProc, name string) {
+	for {
+		if !s.wanted(p) {
+			select {
+			case <-p.kick:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		s.setState(p, StateStarting)
+		s.waitForDependencies(ctx, p)
+		if ctx.Err() != nil {
+			return
+		}
+		if !s.wanted(p) {
+			s.setState(p, StateStopped)
+			continue
+		}
+
+		exited, err := s.launch(p)
+		if err == nil {
+			if !s.wanted(p) {
+				s.Stop(name) // Stop raced with launch
+			}
+			select {
+			case <-exited:
+			case <-ctx.Done():
+				return // Run's StopAll owns the shutdown
+			}
+			if !s.wanted(p) {
+				continue // Requested stop
+			}
+		} else {
+			s.logf("ERROR: starting %s: %v", name, err)
+			s.mu.Lock()
+			p.status.LastExit = err.Error()
+			p.status.LastExitAt = time.Now()
+			p.cleanExit = false
+			s.mu.Unlock()
+		}
+
+		delay, restart := s.recordFailure(p)
+		if !restart {
+			continue // Left down; waits for a start request
+		}
+		select {
+		case <-time.After(delay):
+		case <-p.kick:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// recordFailure handles an unexpected exit under the VM's restart policy.
+// Returns the restart delay, or false when the VM is left down: the policy
+// says so, or it exited more than MaxRetries times within RestartWindow.
+func (s *Supervisor) recordFailure(p *vmProc) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	spec := p.spec
+	p.status.ConsoleTail = consoleTail(spec.consolePath(), consoleTailLines)
+	p.status.NextRestart = time.Time{}
+	defer s.saveState()
+
+	if spec.Restart == RestartNever || (spec.Restart == RestartOnFailure && p.cleanExit) {
+		p.want = false
+		p.status.State = StateFailed
+		if p.cleanExit {
+			p.status.State = StateStopped
+		}
+		s.logf("%s exited (%s) - restart policy %s, not restarting", spec.Name, p.status.LastExit, spec.Restart)
+		return 0, false
+	}
+
+	now := time.Now()
+	window := time.Duration(spec.RestartWindow) * time.Second
+	recent := p.failures[:0]
+	for _, t := range p.failures {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	p.failures = append(recent, now)
+
+	if len(p.failures) > spec.MaxRetries {
+		p.want = false
+		p.status.State = StateFailed
+		p.status.CrashLoop = true
+		s.logf("ERROR: %s is crash-looping (%d exits within %s, last: %s) - giving up until started again",
+			spec.Name, len(p.failures), window, p.status.LastExit)
+		return 0, false
+	}
+
+	p.status.Restarts++
+	delay := backoffBase << (len(p.failures) - 1)
+	if delay > backoffMax || delay <= 0 {
+		delay = backoffMax
+	}
+	p.status.State = StateBackoff
+	p.status.NextRestart = now.Add(delay)
+	s.logf("WARNING: %s VM died (%s) - restart #%d in %s", spec.Name, p.status.LastExit, p.status.Restarts, delay)
+	return delay, true
+}
+
+// consoleTail returns the last n lines of a console log, reading at most
+// the final 64KB so a chatty guest doesn't cost a full read
+func consoleTail(path string, n int) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	const maxRead = 64 << 10
+	if fi, err := f.Stat(); err == nil && fi.Size() > maxRead {
+		f.Seek(fi.Size()-maxRead, io.SeekStart)
+	}
+	data, _ := io.ReadAll(f)
+	lines := strings.Split(strings.TrimRight(string(data), "\r\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+	return lines
+}
+
+// waitForDependencies blocks until each dependency's ReadyAddr accepts TCP,
+// like wait_for_service in sovereign_start.sh. Times out with a warning.
+func (s *Supervisor) waitForDependencies(ctx context.Context, p *vmProc) {
+	s.mu.Lock()
+	name := p.spec.Name
+	var addrs []string
+	for _, dep := range p.spec.DependsOn {
+		if d, ok := s.cfg.spec(dep); ok && d.ReadyAddr != "" {
+			addrs = append(addrs, d.ReadyAddr)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, addr := range addrs {
+		deadline := time.Now().Add(dependencyWait)
+		for {
+			conn, err := net.DialTimeout("tcp", addr, time.Second)
+			if err == nil {
+				conn.Close()
+				break
+			}
+			if time.Now().After(deadline) {
+				s.logf("WARNING: %s dependency %s not ready after %s - starting anyway", name, addr, dependencyWait)
+				break
+			}
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return
+			}
+			if !s.wanted(p) {
+				return
+			}
+		}
+	}
+}
+
+// launch starts crosvm for p and returns a channel closed when it exits
+func (s *Supervisor) launch(p *vmProc) (<-chan struct{}, error) {
+	s.mu.Lock()
+	spec := p.spec
+	s.mu.Unlock()
+
+	if err := spec.deployed(); err != nil {
+		return nil, err
+	}
+	if err := s.setupTAP(spec.TAP); err != nil {
+		return nil, err
+	}
+	os.Remove(spec.socketPath())
+	os.Remove(spec.pausedPath())
+
+	console, err := os.Create(spec.consolePath())
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(crosvmPath, spec.crosvmArgs(readEnvFile(s.envPath))...)
+	cmd.Env = append(os.Environ(), "LD_LIBRARY_PATH="+crosvmLibPath)
+	cmd.Stdout = console
+	cmd.Stderr = console
+	if err := cmd.Start(); err != nil {
+		console.Close()
+		return nil, err
+	}
+	pid := cmd.Process.Pid
+
+	// Protect from OOM killer, and keep vm.pid for tools that still read it
+	os.WriteFile(fmt.Sprintf("/proc/%d/oom_score_adj", pid), []byte("-1000"), 0644)
+	os.WriteFile(spec.pidPath(), []byte(strconv.Itoa(pid)+"\n"), 0644)
+
+	exited := make(chan struct{})
+	s.mu.Lock()
+	p.cmd = cmd
+	p.exited = exited
+	p.status.State = StateRunning
+	p.status.PID = pid
+	p.status.StartedAt = time.Now()
+	p.status.NextRestart = time.Time{}
+	s.saveState()
+	s.mu.Unlock()
+	s.logf("%s started (PID: %d)", spec.Name, pid)
+
+	go func() {
+		err := cmd.Wait()
+		console.Close()
+		reason := "exit status 0"
+		if err != nil {
+			reason = err.Error()
+		}
+		s.mu.Lock()
+		p.cleanExit = err == nil
+		p.cmd = nil
+		p.status.PID = 0
+		p.status.LastExit = reason
+		p.status.LastExitAt = time.Now()
+		if !p.want {
+			p.status.State = StateStopped
+		}
+		os.Remove(spec.pidPath())
+		s.saveState()
+		s.mu.Unlock()
+		close(exited)
+	}()
+	return exited, nil
+}
+
+// Start marks a VM as wanted; its loop launches it
+func (s *Supervisor) Start(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.vms[name]
+	if !ok {
+		return fmt.Errorf("unknown vm %q - push supervisor.json and reload", name)
+	}
+	if err := p.spec.deployed(); err != nil {
+		return err
+	}
+	p.want = true
+	// TEAM_052: An explicit start clears the crash-loop verdict
+	p.failures = nil
+	p.status.CrashLoop = false
+	p.wake()
+	return nil
+}
+
+// Stop shuts a VM down and keeps it down; returns the stop method used
+func (s *Supervisor) Stop(name string) (string, error) {
+	s.mu.Lock()
+	p, ok := s.vms[name]
+	if !ok {
+		s.mu.Unlock()
+		return "", fmt.Errorf("unknown vm %q", name)
+	}
+	p.want = false
+	p.wake()
+	cmd, exited, spec := p.cmd, p.exited, p.spec
+	if cmd == nil {
+		p.status.State = StateStopped
+		s.saveState()
+		s.mu.Unlock()
+		return StopNotRunning, nil
+	}
+	p.status.State = StateStopping
+	s.saveState()
+	s.mu.Unlock()
+
+	method := s.shutdown(spec, cmd.Process, exited)
+	s.logf("%s stopped via %s", name, method)
+
+	s.mu.Lock()
+	p.status.LastStop = method
+	s.saveState()
+	s.mu.Unlock()
+	return method, nil
+}
+
+// StopAll stops every running VM in parallel
+func (s *Supervisor) StopAll() {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.vms))
+	for name := range s.vms {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			s.Stop(name)
+		}(name)
+	}
+	wg.Wait()
+}
+
+// shutdown escalates until the process exits
+func (s *Supervisor) shutdown(spec Spec, proc *os.Process, exited <-chan struct{}) string {
+	wait := func(d time.Duration) bool {
+		select {
+		case <-exited:
+			return true
+		case <-time.After(d):
+			return false
+		}
+	}
+
+	if fileExists(spec.socketPath()) {
+		// A suspended guest can't see the power button - wake it first
+		if fileExists(spec.pausedPath()) {
+			s.crosvmControl("resume", spec)
+			os.Remove(spec.pausedPath())
+		}
+		s.crosvmControl("powerbtn", spec)
+		if wait(time.Duration(spec.StopGrace) * time.Second) {
+			return StopPowerButton
+		}
+		s.crosvmControl("stop", spec)
+		if wait(5 * time.Second) {
+			return StopCrosvmStop
+		}
+	}
+
+	proc.Signal(syscall.SIGTERM)
+	if wait(5 * time.Second) {
+		return StopSIGTERM
+	}
+	proc.Kill()
+	wait(5 * time.Second)
+	return StopSIGKILL
+}
+
+// crosvmControl runs `crosvm <command> vm.sock`
+func (s *Supervisor) crosvmControl(command string, spec Spec) {
+	cmd := exec.Command(crosvmPath, command, spec.socketPath())
+	cmd.Env = append(os.Environ(), "LD_LIBRARY_PATH="+crosvmLibPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		s.logf("WARNING: crosvm %s %s: %v (%s)", command, spec.Name, err, out)
+	}
+}
+
+// Status returns all VMs, or just name if given
+func (s *Supervisor) Status(name string) ([]VMStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all := s.snapshot()
+	if name == "" {
+		return all, nil
+	}
+	for _, st := range all {
+		if st.Name == name {
+			return []VMStatus{st}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown vm %q", name)
+}
+
+// Reload re-reads supervisor.json. New VMs are added; changed specs of
+// existing VMs apply from their next start.
+func (s *Supervisor) Reload() error {
+	cfg, err := LoadConfig(s.configPath)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+	for _, spec := range cfg.VMs {
+		if p, ok := s.vms[spec.Name]; ok {
+			p.spec = spec
+		} else {
+			s.addLocked(spec)
+		}
+	}
+	s.saveState()
+	s.logf("Reloaded %s (%d VMs)", s.configPath, len(cfg.VMs))
+	return nil
+}
@@ -0,0 +1,111 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestSupervisor writes a one-VM supervisor.json under a temp dir
+func newTestSupervisor(t *testing.T) (*Supervisor, string) {
+	t.Helper()
+	dir := t.TempDir()
+	config := filepath.Join(dir, "supervisor.json")
+	data := `{"bridge_name":"vm_bridge","bridge_cidr":"192.168.100.1/24","subnet":"192.168.100.0/24",
+		"vms":[{"name":"sql","dir":"` + filepath.Join(dir, "sql") + `","tap":"vm_sql"}]}`
+	if err := os.WriteFile(config, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(Options{
+		ConfigPath: config,
+		StatePath:  filepath.Join(dir, "state.json"),
+		EnvPath:    filepath.Join(dir, ".env"),
+		Logger:     log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, dir
+}
+
+// TEAM_051: The binary serves the socket while Run is still setting up
+// networking; a reload in that window used to start a loop with a nil ctx
+func TestReloadBeforeRun(t *testing.T) {
+	s, dir := newTestSupervisor(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	socket := filepath.Join(dir, "supervisor.sock")
+	go s.Serve(ctx, socket)
+	var resp *Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if resp, err = Call(socket, Request{Op: OpReload}, time.Second); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if !resp.OK || len(resp.VMs) != 1 || resp.VMs[0].Name != "sql" {
+		t.Fatalf("reload = %+v, want OK with vm sql", resp)
+	}
+
+	s.start(ctx, false)
+	if st, err := s.Status("sql"); err != nil || st[0].State != StateStopped {
+		t.Fatalf("status after start = %+v, %v", st, err)
+	}
+
+	// The loop now exists: a start request reaches it and fails on the
+	// missing images instead of hanging
+	if err := s.Start("sql"); err == nil {
+		t.Fatal("start of an undeployed vm succeeded")
+	}
+}
+
+func TestStartBeforeRunIsUnknown(t *testing.T) {
+	s, _ := newTestSupervisor(t)
+	if err := s.Start("sql"); err == nil {
+		t.Fatal("start before Run or reload succeeded")
+	}
+}
+
+// A second supervisor must not take the socket from a live one, but a socket
+// left behind by a dead one is replaced
+func TestListenRefusesLiveSocket(t *testing.T) {
+	s, dir := newTestSupervisor(t)
+	socket := filepath.Join(dir, "supervisor.sock")
+	if err := os.WriteFile(socket, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := Listen(socket)
+	if err != nil {
+		t.Fatalf("stale socket not replaced: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.ServeListener(ctx, ln) }()
+
+	if _, err := Listen(socket); !errors.Is(err, ErrRunning) {
+		t.Fatalf("second Listen = %v, want ErrRunning", err)
+	}
+	if resp, err := Call(socket, Request{Op: OpStatus}, time.Second); err != nil || !resp.OK {
+		t.Fatalf("first supervisor lost its socket: %+v, %v", resp, err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	ln, err = Listen(socket)
+	if err != nil {
+		t.Fatalf("socket not released on shutdown: %v", err)
+	}
+	ln.Close()
+}
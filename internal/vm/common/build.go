@@ -3,9 +3,12 @@
 package common
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 
 	"github.com/anthropics/sovereign/internal/docker"
 	"github.com/anthropics/sovereign/internal/rootfs"
@@ -13,17 +16,15 @@ import (
 
 // BuildVM builds a VM image using Docker.
 // TEAM_029: Extracted from sql/sql.go Build() and forge/forge.go Build()
-func BuildVM(cfg *VMConfig, dbPassword string) error {
+// TEAM_055: The rootfs comes from buildRootfs, which skips unchanged builds
+// TEAM_059: Secrets come from cfg.Secrets - sql builds through here too
+func BuildVM(ctx context.Context, cfg *VMConfig) error {
 	fmt.Printf("=== Building %s VM ===\n", cfg.DisplayName)
 
-	// Check if Docker is available
-	if !docker.IsAvailable() {
-		return fmt.Errorf("docker not found in PATH - install Docker first")
-	}
-
-	// Check if we have sudo (needed for mount operations)
-	if _, err := exec.LookPath("sudo"); err != nil {
-		return fmt.Errorf("sudo not found - needed for rootfs preparation")
+	// Prompt (if needed) before the long build, so the rest is unattended
+	sec, err := resolveBuildSecrets(ctx, cfg)
+	if err != nil {
+		return err
 	}
 
 	// Run pre-build hook if defined
@@ -33,28 +34,6 @@ func BuildVM(cfg *VMConfig, dbPassword string) error {
 		}
 	}
 
-	// Build Docker image
-	fmt.Println("Building Docker image for ARM64...")
-	dockerfilePath := fmt.Sprintf("%s/Dockerfile", cfg.LocalPath)
-	cmd := exec.Command("docker", "build",
-		"--platform", "linux/arm64",
-		"-t", cfg.DockerImage,
-		"-f", dockerfilePath,
-		cfg.LocalPath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("docker build failed (ensure qemu-user-static is installed for cross-arch builds): %w", err)
-	}
-
-	// Export to rootfs image
-	fmt.Println("\nExporting rootfs image...")
-	rootfsPath := fmt.Sprintf("%s/rootfs.img", cfg.LocalPath)
-	if err := docker.ExportImage(cfg.DockerImage, rootfsPath, "512M"); err != nil {
-		return err
-	}
-
 	// Check kernel
 	kernelDst := fmt.Sprintf("%s/Image", cfg.LocalPath)
 	if cfg.SharedKernel {
@@ -67,40 +46,32 @@ func BuildVM(cfg *VMConfig, dbPassword string) error {
 			fmt.Printf("  ✓ Using shared kernel from %s\n", cfg.KernelSource)
 		}
 	} else {
-		// Check for existing kernel
+		// TEAM_011: Alpine's vmlinuz-virt is EFI stub format which crosvm
+		// can't boot - use a custom kernel built with microdroid_defconfig
 		if info, err := os.Stat(kernelDst); err == nil && info.Size() > 1000000 {
 			fmt.Printf("  ✓ Using existing kernel %s (%d MB)\n", kernelDst, info.Size()/1024/1024)
 		} else {
-			return fmt.Errorf("kernel Image not found or invalid\n" +
-				"  The kernel must be RAW ARM64 format (not EFI stub).\n" +
-				"  Options:\n" +
-				"    1. Copy from device: adb pull /data/sovereign/vm/sql/Image vm/sql/Image\n" +
-				"    2. Build with: cd aosp && make O=../out/guest-kernel ARCH=arm64 microdroid_defconfig Image")
+			return fmt.Errorf("kernel Image not found or invalid\n"+
+				"  The kernel must be RAW ARM64 format (not EFI stub).\n"+
+				"  Options:\n"+
+				"    1. Copy from device: adb pull %s/Image %s\n"+
+				"    2. Build with: cd aosp && make O=../out/guest-kernel ARCH=arm64 microdroid_defconfig Image",
+				cfg.DevicePath, kernelDst)
 		}
 	}
 
 	// Create data disk if needed
-	dataImg := fmt.Sprintf("%s/data.img", cfg.LocalPath)
-	if _, err := os.Stat(dataImg); os.IsNotExist(err) {
-		fmt.Println("Creating data disk (4GB)...")
-		cmd = exec.Command("truncate", "-s", "4G", dataImg)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to create data disk: %w", err)
-		}
-		cmd = exec.Command("mkfs.ext4", "-F", dataImg)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to format data disk: %w", err)
-		}
-	} else {
-		fmt.Println("  Data disk already exists, skipping")
+	if err := createDataDisk(ctx, cfg); err != nil {
+		return err
 	}
 
-	// Prepare rootfs for AVF
-	fmt.Println("Preparing rootfs for AVF (vsock device nodes, init script fixes)...")
-	if err := rootfs.PrepareForAVF(rootfsPath, dbPassword); err != nil {
-		return fmt.Errorf("rootfs preparation failed: %w", err)
+	if err := buildRootfs(ctx, cfg, sec.DBPassword); err != nil {
+		return err
+	}
+
+	// TEAM_051: deploy pushes the prebuilt supervisor with the boot script
+	if err := BuildSupervisor(ctx); err != nil {
+		return err
 	}
 
 	// Run post-build hook if defined
@@ -110,9 +81,147 @@ func BuildVM(cfg *VMConfig, dbPassword string) error {
 		}
 	}
 
+	recordBuild(cfg) // TEAM_064
+
 	fmt.Printf("\n✓ %s VM built successfully\n", cfg.DisplayName)
 	fmt.Printf("  Rootfs: %s/rootfs.img\n", cfg.LocalPath)
 	fmt.Printf("  Data:   %s/data.img\n", cfg.LocalPath)
 	fmt.Printf("\nNext: sovereign deploy --%s\n", cfg.Name)
 	return nil
 }
+
+// DefaultDataSize is the data.img size when VMConfig.DataSize is unset
+const DefaultDataSize = "4G"
+
+// createDataDisk creates an empty ext4 data.img of cfg.DataSize unless one exists
+func createDataDisk(ctx context.Context, cfg *VMConfig) error {
+	dataImg := fmt.Sprintf("%s/data.img", cfg.LocalPath)
+	if _, err := os.Stat(dataImg); err == nil {
+		fmt.Println("  Data disk already exists, skipping")
+		return nil
+	}
+	size := cfg.DataSize
+	if size == "" {
+		size = DefaultDataSize
+	}
+	bytes, err := rootfs.ParseSize(size)
+	if err != nil {
+		return fmt.Errorf("DataSize: %w", err)
+	}
+	fmt.Printf("Creating data disk (%s)...\n", rootfs.FormatSize(bytes))
+	cmd := exec.CommandContext(ctx, "truncate", "-s", fmt.Sprint(bytes), dataImg)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to create data disk: %w", err)
+	}
+	mkfs, err := rootfs.FindTool("mkfs.ext4")
+	if err != nil {
+		return err
+	}
+	cmd = exec.CommandContext(ctx, mkfs, "-F", dataImg)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to format data disk: %w", err)
+	}
+	return nil
+}
+
+// buildRootfs produces <LocalPath>/rootfs.img: docker build, export, and
+// rootless ext4 assembly with the AVF fixes. Skipped when the inputs match
+// the last build, restored from the build cache when seen before.
+// TEAM_055: The kernel Image must be in place - it is one of the inputs.
+func buildRootfs(ctx context.Context, cfg *VMConfig, dbPassword string) error {
+	rootfsPath := fmt.Sprintf("%s/rootfs.img", cfg.LocalPath)
+	in, err := HashBuildInputs(cfg, dbPassword)
+	if err != nil {
+		return fmt.Errorf("build cache: %w", err)
+	}
+	key := in.Key()
+	prev, hasPrev := readBuildInputs(cfg.LocalPath)
+	_, statErr := os.Stat(rootfsPath)
+	haveImage := statErr == nil
+
+	reason := "no previous build"
+	if hasPrev {
+		if changed := in.Changed(prev); len(changed) > 0 {
+			reason = strings.Join(changed, ", ") + " changed"
+		} else if !haveImage {
+			reason = "rootfs.img missing"
+		}
+	}
+
+	if !NoBuildCache {
+		if hasPrev && haveImage && prev.Key() == key {
+			fmt.Printf("  ✓ %s up to date (inputs unchanged, %s)\n", rootfsPath, key[:12])
+			return nil
+		}
+		if cached, ok := cacheLookup(cfg, key); ok {
+			os.Remove(filepath.Join(cfg.LocalPath, buildInputsFile))
+			if err := copySparse(cached, rootfsPath); err != nil {
+				return fmt.Errorf("restoring cached rootfs: %w", err)
+			}
+			// TEAM_056: The provenance of the cached image, not of the last build
+			restoreCachedManifest(cfg, cached)
+			if err := writeBuildInputs(cfg.LocalPath, in); err != nil {
+				return err
+			}
+			fmt.Printf("  ✓ Restored %s from build cache (%s; %s)\n", rootfsPath, key[:12], reason)
+			return nil
+		}
+	} else {
+		reason = "--no-cache"
+	}
+	fmt.Printf("Building rootfs: %s\n", reason)
+
+	// Check if Docker is available
+	if !docker.IsAvailable() {
+		return fmt.Errorf("docker not found in PATH - install Docker first")
+	}
+	// TEAM_054: Rootfs images are built with mke2fs -d + debugfs, no sudo
+	for _, tool := range []string{"mke2fs", "debugfs"} {
+		if _, err := rootfs.FindTool(tool); err != nil {
+			return err
+		}
+	}
+	os.Remove(filepath.Join(cfg.LocalPath, buildInputsFile))
+	os.Remove(filepath.Join(cfg.LocalPath, buildManifestFile))
+
+	// Build Docker image
+	fmt.Println("Building Docker image for ARM64...")
+	dockerfilePath := fmt.Sprintf("%s/Dockerfile", cfg.LocalPath)
+	args := []string{"build", "--platform", "linux/arm64", "-t", cfg.DockerImage, "-f", dockerfilePath}
+	if NoBuildCache {
+		// Base images are pulled by tag - pick up new ones
+		args = append(args, "--pull", "--no-cache")
+	}
+	cmd := exec.CommandContext(ctx, "docker", append(args, cfg.LocalPath)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker build failed (ensure qemu-user-static is installed for cross-arch builds): %w", err)
+	}
+
+	// Export the image filesystem
+	fmt.Println("\nExporting rootfs...")
+	rootfsTar := fmt.Sprintf("%s/rootfs.tar", cfg.LocalPath)
+	if err := docker.ExportTar(cfg.DockerImage, rootfsTar); err != nil {
+		return err
+	}
+	defer os.Remove(rootfsTar)
+
+	// Prepare rootfs for AVF
+	fmt.Println("Preparing rootfs for AVF (vsock device nodes, init script fixes)...")
+	if err := rootfs.CreateImage(rootfsTar, rootfsPath, rootfsSizeOf(cfg), dbPassword); err != nil {
+		return fmt.Errorf("rootfs preparation failed: %w", err)
+	}
+	if err := writeBuildManifest(cfg, in, rootfsTar); err != nil {
+		return err
+	}
+
+	if err := writeBuildInputs(cfg.LocalPath, in); err != nil {
+		return err
+	}
+	cacheStore(cfg, in, rootfsPath)
+	return nil
+}
@@ -0,0 +1,402 @@
+// Content-addressed cache for built rootfs images
+// TEAM_055: BuildVM used to docker build, export, mke2fs and prepare the
+// rootfs every time. The image is now keyed on digests of everything it is
+// made from; an unchanged build is a no-op and a changed one says why.
+package common
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// buildRecipe changes whenever the image assembly itself changes
+	// (rootfs package), invalidating every cached image
+	buildRecipe = "rootfs-v3 ext4"
+
+	buildInputsFile = ".build-inputs.json" // Next to rootfs.img: what it was built from
+	cacheKeep       = 3                    // Cached images kept per VM
+
+	// secretsKeyFile holds this host's HMAC key for BuildInputs.Secrets,
+	// next to the state file and outside the repository
+	secretsKeyFile = "build-secrets.key"
+)
+
+// NoBuildCache forces a full rebuild (--no-cache): base images pulled by tag
+// (e.g. vaultwarden:latest-alpine) can change without any local input changing
+var NoBuildCache bool
+
+// BuildCacheDir holds cached images as <dir>/<vm>/<key>/rootfs.img.
+// Empty means <user cache dir>/sovereign/rootfs.
+var BuildCacheDir string
+
+// BuildInputs are the digests a rootfs image is built from
+type BuildInputs struct {
+	Context    string `json:"context"` // Dockerfile context, minus init.sh and build outputs
+	InitScript string `json:"init_sh"`
+	Secrets    string `json:"secrets"` // HMAC of the injected secrets under a per-host key, never the secrets
+	Kernel     string `json:"kernel"`
+	Recipe     string `json:"recipe"`
+}
+
+// Key is the cache key for these inputs
+func (b BuildInputs) Key() string {
+	data, _ := json.Marshal(b)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Changed lists the inputs that differ from old
+func (b BuildInputs) Changed(old BuildInputs) []string {
+	var changed []string
+	for _, c := range []struct {
+		name     string
+		new, old string
+	}{
+		{"Dockerfile context", b.Context, old.Context},
+		{"init.sh", b.InitScript, old.InitScript},
+		{"secrets", b.Secrets, old.Secrets},
+		{"kernel", b.Kernel, old.Kernel},
+		{"build recipe", b.Recipe, old.Recipe},
+	} {
+		if c.new != c.old {
+			changed = append(changed, c.name)
+		}
+	}
+	return changed
+}
+
+// kernelPath is the kernel Image the VM boots
+func kernelPath(cfg *VMConfig) string {
+	if cfg.SharedKernel && cfg.KernelSource != "" {
+		return cfg.KernelSource
+	}
+	return filepath.Join(cfg.LocalPath, "Image")
+}
+
+// HashBuildInputs digests everything cfg's rootfs image is built from
+func HashBuildInputs(cfg *VMConfig, dbPassword string) (BuildInputs, error) {
+	var in BuildInputs
+	var err error
+	if in.Context, err = hashContext(cfg.LocalPath); err != nil {
+		return in, fmt.Errorf("hashing %s: %w", cfg.LocalPath, err)
+	}
+	if in.InitScript, err = hashFile(filepath.Join(cfg.LocalPath, "init.sh")); err != nil {
+		return in, err
+	}
+	if in.Kernel, err = hashFile(kernelPath(cfg)); err != nil {
+		return in, err
+	}
+	if in.Secrets, err = secretsDigest(dbPassword); err != nil {
+		return in, err
+	}
+	// TEAM_058: A different RootfsSize is a different image
+	in.Recipe = buildRecipe + " size=" + rootfsSizeOf(cfg)
+	return in, nil
+}
+
+// secretsDigest is the HMAC of the build secrets under this host's key.
+// TEAM_055: Keyed, so the inputs file can't be used to brute-force the password
+func secretsDigest(dbPassword string) (string, error) {
+	key, err := secretsKey()
+	if err != nil {
+		return "", fmt.Errorf("build secrets key: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(dbPassword))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// secretsKey returns this host's random HMAC key, creating it (0600) on
+// first use. Losing it only makes the next build of each VM a full one.
+func secretsKey() ([]byte, error) {
+	state, err := statePath()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(state)
+	path := filepath.Join(dir, secretsKeyFile)
+	if key, err := os.ReadFile(path); err == nil && len(key) == 32 {
+		return key, nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(dir, secretsKeyFile+".*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(key)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if old, err := os.ReadFile(path); err == nil && len(old) != 32 {
+		os.Remove(path) // Truncated or corrupt
+	}
+	// Link fails if a concurrent build created the key first; use theirs
+	if err := os.Link(tmp.Name(), path); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// rootfsSizeOf is cfg's RootfsSize, "auto" when unset
+func rootfsSizeOf(cfg *VMConfig) string {
+	if cfg.RootfsSize == "" {
+		return "auto"
+	}
+	return cfg.RootfsSize
+}
+
+// isBuildOutput reports whether a context file is produced by the build
+// (or is init.sh, which is hashed on its own)
+func isBuildOutput(rel string) bool {
+	switch rel {
+	case "Image", "init.sh", "rootfs.tar", buildInputsFile, buildManifestFile:
+		return true
+	}
+	// TEAM_057: sbom.cdx.json / sbom.spdx.json from `sovereign sbom`
+	return strings.HasSuffix(rel, ".img") || strings.HasPrefix(rel, "sbom.")
+}
+
+// hashContext digests every file under dir: path, mode and content
+func hashContext(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isBuildOutput(filepath.ToSlash(rel)) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%o\x00", filepath.ToSlash(rel), info.Mode())
+		if info.Mode()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s\x00", target)
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readBuildInputs returns what rootfs.img in dir was last built from
+func readBuildInputs(dir string) (BuildInputs, bool) {
+	var in BuildInputs
+	data, err := os.ReadFile(filepath.Join(dir, buildInputsFile))
+	if err != nil || json.Unmarshal(data, &in) != nil {
+		return in, false
+	}
+	return in, true
+}
+
+func writeBuildInputs(dir string, in BuildInputs) error {
+	data, err := json.MarshalIndent(in, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, buildInputsFile), append(data, '\n'), 0644)
+}
+
+func buildCacheDir(cfg *VMConfig) (string, error) {
+	dir := BuildCacheDir
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(base, "sovereign", "rootfs")
+	}
+	return filepath.Join(dir, cfg.Name), nil
+}
+
+// cacheLookup returns the cached image for key, if any
+func cacheLookup(cfg *VMConfig, key string) (string, bool) {
+	dir, err := buildCacheDir(cfg)
+	if err != nil {
+		return "", false
+	}
+	img := filepath.Join(dir, key, "rootfs.img")
+	if _, err := os.Stat(img); err != nil {
+		return "", false
+	}
+	return img, true
+}
+
+// cacheStore copies a freshly built image into the cache and prunes old
+// entries. Failures only cost the next build a cache hit, so they are warnings.
+func cacheStore(cfg *VMConfig, in BuildInputs, img string) {
+	dir, err := buildCacheDir(cfg)
+	if err != nil {
+		fmt.Printf("  ⚠ Build cache unavailable: %v\n", err)
+		return
+	}
+	key := in.Key()
+	tmp := filepath.Join(dir, key+".tmp")
+	os.RemoveAll(tmp)
+	if err := os.MkdirAll(tmp, 0700); err != nil {
+		fmt.Printf("  ⚠ Build cache unavailable: %v\n", err)
+		return
+	}
+	if err = copySparse(img, filepath.Join(tmp, "rootfs.img")); err == nil {
+		err = writeBuildInputs(tmp, in)
+	}
+	if err == nil {
+		// TEAM_056: Provenance travels with the image
+		err = copyIfExists(filepath.Join(cfg.LocalPath, buildManifestFile), filepath.Join(tmp, buildManifestFile))
+	}
+	final := filepath.Join(dir, key)
+	os.RemoveAll(final)
+	if err == nil {
+		err = os.Rename(tmp, final)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		fmt.Printf("  ⚠ Could not cache rootfs image: %v\n", err)
+		return
+	}
+	pruneBuildCache(dir)
+}
+
+// restoreCachedManifest puts back the build manifest stored with a cached image
+func restoreCachedManifest(cfg *VMConfig, cachedImg string) {
+	dst := filepath.Join(cfg.LocalPath, buildManifestFile)
+	os.Remove(dst)
+	if err := copyIfExists(filepath.Join(filepath.Dir(cachedImg), buildManifestFile), dst); err != nil {
+		fmt.Printf("  ⚠ Could not restore %s: %v\n", buildManifestFile, err)
+	}
+}
+
+// copyIfExists copies a small file; a missing src is not an error
+func copyIfExists(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
+
+// pruneBuildCache keeps the cacheKeep most recently built images
+func pruneBuildCache(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type entry struct {
+		path string
+		mod  int64
+	}
+	var all []entry
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && e.IsDir() {
+			all = append(all, entry{filepath.Join(dir, e.Name()), info.ModTime().UnixNano()})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].mod > all[j].mod })
+	for i := cacheKeep; i < len(all); i++ {
+		os.RemoveAll(all[i].path)
+	}
+}
+
+// copySparse copies an image, leaving all-zero blocks as holes so a mostly
+// empty 512M ext4 image doesn't take 512M in the cache
+func copySparse(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 64<<10)
+	zero := make([]byte, len(buf))
+	var size int64
+	for {
+		n, rerr := io.ReadFull(in, buf)
+		if n > 0 {
+			if bytes.Equal(buf[:n], zero[:n]) {
+				_, err = out.Seek(int64(n), io.SeekCurrent)
+			} else {
+				_, err = out.Write(buf[:n])
+			}
+			if err != nil {
+				out.Close()
+				return err
+			}
+			size += int64(n)
+		}
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			break
+		}
+		if rerr != nil {
+			out.Close()
+			return rerr
+		}
+	}
+	if err := out.Truncate(size); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,104 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretsDigestIsKeyedPerHost(t *testing.T) {
+	old := StateFile
+	StateFile = filepath.Join(t.TempDir(), "state.json")
+	defer func() { StateFile = old }()
+
+	a, err := secretsDigest("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := secretsDigest("hunter2"); b != a {
+		t.Fatalf("digest not stable: %s then %s", a, b)
+	}
+	if c, _ := secretsDigest("hunter3"); c == a {
+		t.Fatal("different passwords, same digest")
+	}
+	unkeyed := sha256.Sum256([]byte("hunter2"))
+	if a == hex.EncodeToString(unkeyed[:]) {
+		t.Fatal("digest is a plain sha256 of the password")
+	}
+
+	info, err := os.Stat(filepath.Join(filepath.Dir(StateFile), secretsKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("key mode %v, want 0600", info.Mode().Perm())
+	}
+
+	// Another host (key) gives another digest
+	StateFile = filepath.Join(t.TempDir(), "state.json")
+	if d, _ := secretsDigest("hunter2"); d == a {
+		t.Fatal("two keys gave the same digest")
+	}
+}
+
+func TestBuildInputsInvalidation(t *testing.T) {
+	dir := withHostState(t)
+	vm := filepath.Join(dir, "vm")
+	write := func(name, content string) {
+		t.Helper()
+		os.MkdirAll(filepath.Dir(filepath.Join(vm, name)), 0755)
+		if err := os.WriteFile(filepath.Join(vm, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("Dockerfile", "FROM alpine:3.20\n")
+	write("init.sh", "#!/bin/sh\n")
+	write("Image", "kernel")
+	cfg := &VMConfig{Name: "cache", LocalPath: vm}
+
+	password := "hunter2"
+	base, err := HashBuildInputs(cfg, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		change func()
+		want   string // Input reported changed, "" = same key
+	}{
+		{"build outputs", func() {
+			write("rootfs.img", "image")
+			write("rootfs.tar", "tar")
+			write("sbom.cdx.json", "{}")
+			write(buildInputsFile, "{}")
+			write(".git/HEAD", "ref")
+		}, ""},
+		{"Dockerfile", func() { write("Dockerfile", "FROM alpine:3.21\n") }, "Dockerfile context"},
+		{"new context file", func() { write("conf/app.toml", "x") }, "Dockerfile context"},
+		{"mode", func() { os.Chmod(filepath.Join(vm, "Dockerfile"), 0755) }, "Dockerfile context"},
+		{"init.sh", func() { write("init.sh", "#!/bin/sh\nexec app\n") }, "init.sh"},
+		{"secrets", func() { password = "hunter3" }, "secrets"},
+		{"kernel", func() { write("Image", "kernel 2") }, "kernel"},
+		{"rootfs size", func() { cfg.RootfsSize = "2G" }, "build recipe"},
+	}
+	for _, tt := range tests {
+		tt.change()
+		in, err := HashBuildInputs(cfg, password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed := in.Changed(base)
+		if tt.want == "" {
+			if len(changed) != 0 || in.Key() != base.Key() {
+				t.Errorf("%s: changed %v, want the same key", tt.name, changed)
+			}
+			continue
+		}
+		if len(changed) != 1 || changed[0] != tt.want || in.Key() == base.Key() {
+			t.Errorf("%s: changed %v, want [%s] and a new key", tt.name, changed, tt.want)
+		}
+		base = in
+	}
+}
@@ -0,0 +1,62 @@
+// Build-time secrets
+// TEAM_059: sql used to prompt for credentials in its own copy of the build
+// pipeline. The prompt is now a SecretProvider on VMConfig, so every VM
+// goes through BuildVM and NeedsSecrets (never read) is gone. vault has no
+// provider: its DB password reaches the guest via KernelSecrets at boot.
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/sovereign/internal/secrets"
+)
+
+// BuildSecrets are baked into the rootfs by rootfs.CreateImage
+type BuildSecrets struct {
+	DBPassword string // Exported as DB_PASSWORD at the marker in init.sh
+}
+
+// SecretProvider supplies a VM's build secrets. It may prompt - it runs
+// before the long docker build so the rest of the build is unattended.
+type SecretProvider func(ctx context.Context, cfg *VMConfig) (*BuildSecrets, error)
+
+// DBCredentials loads the database credentials from .secrets, prompting for
+// them and writing .secrets on the first build
+// TEAM_011: Moved from sql.VM.Build
+func DBCredentials(ctx context.Context, cfg *VMConfig) (*BuildSecrets, error) {
+	var creds *secrets.Credentials
+	if secrets.SecretsExist() {
+		fmt.Println("Using existing credentials from .secrets")
+		var err error
+		creds, err = secrets.LoadSecretsFile()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		creds, err = secrets.PromptCredentials("postgres")
+		if err != nil {
+			return nil, fmt.Errorf("credential setup failed: %w", err)
+		}
+		if err := secrets.WriteSecretsFile(creds); err != nil {
+			return nil, err
+		}
+	}
+	return &BuildSecrets{DBPassword: creds.DBPassword}, nil
+}
+
+// resolveBuildSecrets runs cfg's provider; VMs without one get no secrets
+func resolveBuildSecrets(ctx context.Context, cfg *VMConfig) (*BuildSecrets, error) {
+	if cfg.Secrets == nil {
+		return &BuildSecrets{}, nil
+	}
+	s, err := cfg.Secrets(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		s = &BuildSecrets{}
+	}
+	return s, nil
+}
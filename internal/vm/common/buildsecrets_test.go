@@ -0,0 +1,82 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/sovereign/internal/secrets"
+)
+
+func TestResolveBuildSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider SecretProvider
+		want     string
+	}{
+		{"no provider", nil, ""},
+		{"nil secrets", func(context.Context, *VMConfig) (*BuildSecrets, error) { return nil, nil }, ""},
+		{"password", func(context.Context, *VMConfig) (*BuildSecrets, error) {
+			return &BuildSecrets{DBPassword: "hunter2"}, nil
+		}, "hunter2"},
+	}
+	for _, tt := range tests {
+		sec, err := resolveBuildSecrets(context.Background(), &VMConfig{Secrets: tt.provider})
+		if err != nil || sec == nil || sec.DBPassword != tt.want {
+			t.Errorf("%s: %+v, %v, want password %q", tt.name, sec, err, tt.want)
+		}
+	}
+}
+
+// The provider may prompt, so it runs before anything slow - and a failed
+// prompt builds nothing
+func TestBuildVMResolvesSecretsFirst(t *testing.T) {
+	var calls []string
+	errPrompt, errStop := errors.New("prompt cancelled"), errors.New("stop here")
+	cfg := &VMConfig{Name: "sec", DisplayName: "Sec", LocalPath: t.TempDir(),
+		PreBuildHook: func(*VMConfig) error { calls = append(calls, "pre-build"); return errStop }}
+
+	cfg.Secrets = func(context.Context, *VMConfig) (*BuildSecrets, error) {
+		calls = append(calls, "secrets")
+		return nil, errPrompt
+	}
+	if err := BuildVM(context.Background(), cfg); !errors.Is(err, errPrompt) {
+		t.Fatalf("err = %v, want the provider's error", err)
+	}
+	if strings.Join(calls, ",") != "secrets" {
+		t.Fatalf("calls = %v, want the build to stop at the provider", calls)
+	}
+
+	calls = nil
+	cfg.Secrets = func(context.Context, *VMConfig) (*BuildSecrets, error) {
+		calls = append(calls, "secrets")
+		return &BuildSecrets{DBPassword: "hunter2"}, nil
+	}
+	if err := BuildVM(context.Background(), cfg); !errors.Is(err, errStop) {
+		t.Fatalf("err = %v, want the pre-build hook's error", err)
+	}
+	if strings.Join(calls, ",") != "secrets,pre-build" {
+		t.Fatalf("calls = %v, want secrets before pre-build", calls)
+	}
+}
+
+func TestDBCredentialsReadsSecretsFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile(secrets.SecretsFile, []byte("DB_USER=postgres\nDB_PASSWORD=p'a\"s$s\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sec, err := DBCredentials(context.Background(), &VMConfig{Name: "sql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sec.DBPassword != `p'a"s$s` {
+		t.Errorf("DBPassword = %q", sec.DBPassword)
+	}
+
+	os.WriteFile(secrets.SecretsFile, []byte("DB_USER=postgres\n"), 0600)
+	if _, err := DBCredentials(context.Background(), &VMConfig{Name: "sql"}); err == nil {
+		t.Error("incomplete .secrets accepted")
+	}
+}
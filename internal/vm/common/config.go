@@ -6,12 +6,14 @@
 // and the common functions handle Build, Deploy, Start, Stop, Test, Remove.
 package common
 
+import "context"
+
 // ServiceDependency defines a dependency on another service.
 // TEAM_029: Used to fail-fast if required services are unavailable.
 type ServiceDependency struct {
 	Name          string // "sql" - the service name
 	TailscaleHost string // "sovereign-sql" - Tailscale hostname to check
-	TAPIP         string // "192.168.100.2" - TAP IP for local VM-to-VM (optional)
+	TAPIP         string // TAP IP for local VM-to-VM (optional - resolved via IPAM)
 	Port          int    // 5432 - port to verify connectivity
 	Description   string // "PostgreSQL database" - for error messages
 }
@@ -25,10 +27,11 @@ type VMConfig struct {
 
 	// Networking - all VMs on shared bridge (192.168.100.0/24)
 	// TEAM_033: Fixed comments - all VMs use same subnet for VM-to-VM communication
+	// TEAM_047: Only TAPInterface is required - AssignGuestIP fills the rest
 	TAPInterface string // "vm_sql", "vm_forge", "vm_vault"
-	TAPHostIP    string // "192.168.100.1" (gateway for all VMs)
-	TAPGuestIP   string // "192.168.100.2" (SQL), "192.168.100.3" (Forge)
-	TAPSubnet    string // "192.168.100.0/24" (shared by all VMs)
+	TAPHostIP    string // Bridge gateway, derived from TAPSubnet
+	TAPGuestIP   string // Pin a guest IP, or leave empty for a lease from IPAM
+	TAPSubnet    string // Defaults to DefaultSubnet (shared by all VMs)
 
 	// Tailscale
 	TailscaleHost string // "sovereign-sql", "sovereign-forge"
@@ -39,14 +42,34 @@ type VMConfig struct {
 
 	// Service
 	ServicePorts []int  // [5432], [3000, 22], [80]
-	ReadyMarker  string // "PostgreSQL started", "INIT COMPLETE"
+	ReadyMarker  string // Boot log hint only: "PostgreSQL started", "INIT COMPLETE"
 	StartTimeout int    // seconds: 90, 120
 
+	// TEAM_053: All must pass before StartVM returns; empty = TCP on ServicePorts[0]
+	ReadinessProbes []ReadinessProbe
+
+	// Shutdown
+	// TEAM_049: Seconds the guest gets to power off before crosvm is stopped/killed
+	StopGracePeriod int // 0 = DefaultStopGracePeriod (30); sql uses 60 for checkpoints
+
+	// TEAM_052: What the on-device supervisor does when crosvm exits
+	Restart RestartPolicy
+
 	// Build options
 	DockerImage  string // "sovereign-sql", "sovereign-forge"
 	SharedKernel bool   // false for sql, true for forge (uses sql's kernel)
 	KernelSource string // "vm/sql/Image" - where to get kernel if SharedKernel
-	NeedsSecrets bool   // true for sql (prompts for DB password)
+
+	// TEAM_059: Secrets baked into the rootfs; nil = none (sql: DBCredentials)
+	Secrets SecretProvider
+
+	// TEAM_058: Image sizes ("512M", "4G")
+	RootfsSize string // "" = fit the exported image plus headroom
+	DataSize   string // data.img on first deploy, "" = DefaultDataSize; grow later with ResizeData
+
+	// TEAM_051: Kernel params filled from the device .env at boot, e.g.
+	// "forgejo.db_password" -> "POSTGRES_FORGEJO_PASSWORD"
+	KernelSecrets map[string]string
 
 	// Process detection pattern for pgrep/grep
 	// TEAM_029: Use [c]rosvm pattern to avoid grep matching itself
@@ -59,14 +82,31 @@ type VMConfig struct {
 	// Hooks for service-specific logic (optional)
 	PreBuildHook  func(*VMConfig) error
 	PostBuildHook func(*VMConfig) error
+
+	// TEAM_047: TAPGuestIP as configured. AssignGuestIP overwrites TAPGuestIP
+	// with the resolved address, so later calls must not take a lease for a pin.
+	pinnedIP    string
+	pinCaptured bool
 }
 
-// TestResult represents the outcome of a single test.
-type TestResult struct {
-	Name    string
-	Passed  bool
-	Message string
+// RestartMode selects when the supervisor restarts a VM that exited on its own
+type RestartMode string
+
+const (
+	RestartAlways    RestartMode = "always"     // Default: any exit that wasn't a requested stop
+	RestartOnFailure RestartMode = "on-failure" // Not after a clean guest poweroff (exit 0)
+	RestartNever     RestartMode = "never"
+)
+
+// RestartPolicy is enforced by internal/supervisor, not by the CLI.
+// More than MaxRetries exits within Window seconds is a crash loop: the
+// supervisor stops retrying until the next 'sovereign start'.
+type RestartPolicy struct {
+	Mode       RestartMode // "" = RestartAlways
+	MaxRetries int         // 0 = 5
+	Window     int         // Seconds, 0 = 600; also how long a run must last to reset backoff
 }
 
 // TestFunc is a custom test function that services can provide.
-type TestFunc func(cfg *VMConfig) TestResult
+// TEAM_044: Receives the operation context so slow probes can be cancelled
+type TestFunc func(ctx context.Context, cfg *VMConfig) TestResult
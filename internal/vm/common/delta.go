@@ -0,0 +1,148 @@
+// Delta deploy - skip files the device already has
+// TEAM_060: DeployVM pushed rootfs.img and the 35MB kernel every time, each
+// through /data/local/tmp, which takes minutes over USB. Files are now
+// compared by sha256 first, and --verify re-hashes them after the move.
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/rootfs"
+)
+
+// VerifyDeploy re-hashes every pushed file on the device once it is in place
+// TEAM_060: Package-level flag for --verify
+var VerifyDeploy bool
+
+// deployStats tallies what a deploy pushed and skipped
+type deployStats struct {
+	pushed, skipped, verified int
+	pushedBytes, savedBytes   int64
+	sums                      map[string]string // Remote path -> local sha256
+}
+
+// remoteSHA256 hashes a device file, "" if it doesn't exist. Streams rather
+// than using RunShellCommand: hashing a 4G data.img can outlast its 30s cap.
+func remoteSHA256(ctx context.Context, path string) (string, error) {
+	var out strings.Builder
+	cmd := fmt.Sprintf("[ -f %s ] && sha256sum %s 2>/dev/null", device.Quote(path), device.Quote(path))
+	if err := device.StreamShellCommand(ctx, cmd, &out); err != nil && ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	fields := strings.Fields(out.String())
+	if len(fields) == 0 {
+		return "", nil
+	}
+	if len(fields[0]) != 64 {
+		return "", fmt.Errorf("unexpected sha256sum output for %s: %q", path, out.String())
+	}
+	return fields[0], nil
+}
+
+// planPush adds a step sending local to remote, unless the device already
+// has it: at remote (nothing to do), or at base - the running release's copy -
+// which is then hard-linked. what names the file in progress output.
+// TEAM_062: base lets a new release reuse unchanged files
+// TEAM_063: Decides from read-only hashing; the plan does the pushing
+func (st *deployStats) planPush(ctx context.Context, p *Plan, what, local, remote, base string) error {
+	info, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+	sum, err := hashFile(local)
+	if err != nil {
+		return err
+	}
+	st.record(remote, sum)
+	if base == "" {
+		base = remote
+	}
+	have, err := remoteSHA256(ctx, base)
+	if err != nil {
+		return err
+	}
+	if have != sum {
+		st.pushed++
+		st.pushedBytes += info.Size()
+		p.Push(fmt.Sprintf("Pushing %s (%s)...", what, rootfs.FormatSize(info.Size())), local, remote, info.Size(),
+			func(ctx context.Context) error { return st.transfer(ctx, local, remote, sum) })
+		return nil
+	}
+
+	st.skipped++
+	st.savedBytes += info.Size()
+	unchanged := fmt.Sprintf("  ✓ %s unchanged (%s), skipped %s", what, sum[:12], rootfs.FormatSize(info.Size()))
+	if base == remote {
+		p.Skip(unchanged)
+		return nil
+	}
+	p.Checked(StepLink, unchanged, device.Quote("ln", base, remote)+" 2>/dev/null || "+device.Quote("cp", base, remote))
+	return nil
+}
+
+// planReplace always sends local to remote. For data.img, whose device copy
+// has diverged from the build output by design, so comparing is wasted hashing.
+func (st *deployStats) planReplace(p *Plan, desc, local, remote string) error {
+	info, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+	st.pushed++
+	st.pushedBytes += info.Size()
+	p.Push(fmt.Sprintf("%s (%s)...", desc, rootfs.FormatSize(info.Size())), local, remote, info.Size(),
+		func(ctx context.Context) error {
+			sum := ""
+			if VerifyDeploy {
+				var err error
+				if sum, err = hashFile(local); err != nil {
+					return err
+				}
+			}
+			return st.transfer(ctx, local, remote, sum)
+		})
+	return nil
+}
+
+// transfer pushes one file and, with --verify, checks the device copy
+// against sum once device.PushFile has moved it into place
+func (st *deployStats) transfer(ctx context.Context, local, remote, sum string) error {
+	if err := device.PushFile(ctx, local, remote); err != nil {
+		return err
+	}
+	if !VerifyDeploy {
+		return nil
+	}
+	got, err := remoteSHA256(ctx, remote)
+	if err != nil {
+		return err
+	}
+	if got == "" {
+		return fmt.Errorf("verify failed for %s: cannot hash it on the device (missing, or no sha256sum)", remote)
+	}
+	if got != sum {
+		return fmt.Errorf("verify failed for %s: device has %s, expected %s", remote, got[:12], sum[:12])
+	}
+	st.verified++
+	fmt.Printf("  ✓ Verified %s (%s)\n", remote, sum[:12])
+	return nil
+}
+
+func (st *deployStats) record(remote, sum string) {
+	if st.sums == nil {
+		st.sums = map[string]string{}
+	}
+	st.sums[remote] = sum
+}
+
+// report prints the deploy's transfer summary
+func (st *deployStats) report() {
+	fmt.Printf("Transferred %d file(s), %s; skipped %d unchanged, %s saved\n",
+		st.pushed, rootfs.FormatSize(st.pushedBytes), st.skipped, rootfs.FormatSize(st.savedBytes))
+	if VerifyDeploy {
+		fmt.Printf("Verified %d pushed file(s) on the device\n", st.verified)
+	}
+}
@@ -3,15 +3,20 @@
 package common
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
+
+	"github.com/anthropics/sovereign/internal/device"
 )
 
 // CheckDependencies verifies all dependencies are available before starting a VM.
 // Returns an error if any dependency is unavailable.
 // TEAM_029: Called by StartVM to fail fast
-func CheckDependencies(cfg *VMConfig) error {
+func CheckDependencies(ctx context.Context, cfg *VMConfig) error {
 	if len(cfg.Dependencies) == 0 {
 		return nil
 	}
@@ -19,7 +24,7 @@ func CheckDependencies(cfg *VMConfig) error {
 	fmt.Printf("Checking dependencies for %s...\n", cfg.DisplayName)
 
 	for _, dep := range cfg.Dependencies {
-		if err := checkDependency(dep); err != nil {
+		if err := checkDependency(ctx, dep); err != nil {
 			return fmt.Errorf("dependency %s unavailable: %w", dep.Name, err)
 		}
 	}
@@ -30,16 +35,17 @@ func CheckDependencies(cfg *VMConfig) error {
 
 // checkDependency verifies a single service dependency is available.
 // TEAM_029: First tries TAP IP (for local VM-to-VM), then Tailscale hostnames.
-func checkDependency(dep ServiceDependency) error {
+func checkDependency(ctx context.Context, dep ServiceDependency) error {
 	fmt.Printf("  Checking %s (%s:%d)... ", dep.Description, dep.TailscaleHost, dep.Port)
 
 	// First try TAP IP if available (for VM-to-VM on same device)
-	// TEAM_036: Must run nc on device via adb since TAP network is only accessible there
-	if dep.TAPIP != "" {
-		cmd := exec.Command("adb", "shell", "su", "-c",
-			fmt.Sprintf("nc -z -w 2 %s %d", dep.TAPIP, dep.Port))
-		if err := cmd.Run(); err == nil {
-			fmt.Printf("✓ (TAP: %s)\n", dep.TAPIP)
+	// TEAM_036: Must run nc on device since TAP network is only accessible there
+	// TEAM_042: Goes through the device transport instead of calling adb directly
+	// TEAM_047: Address comes from IPAM when the dependency doesn't pin one
+	if tapIP := ResolveDependencyIP(dep); tapIP != "" {
+		out, _ := device.RunShellCommand(ctx, device.Quote("nc", "-z", "-w", "2", tapIP, strconv.Itoa(dep.Port))+" && echo OK")
+		if out == "OK" {
+			fmt.Printf("✓ (TAP: %s)\n", tapIP)
 			return nil
 		}
 	}
@@ -52,12 +58,12 @@ func checkDependency(dep ServiceDependency) error {
 	}
 
 	for _, hostname := range hostnames {
-		tsIP, connected := CheckTailscaleConnected(hostname)
+		tsIP, connected := CheckTailscaleConnected(ctx, hostname)
 		if !connected {
 			continue
 		}
 
-		cmd := exec.Command("nc", "-z", "-w", "3", hostname, fmt.Sprintf("%d", dep.Port))
+		cmd := exec.CommandContext(ctx, "nc", "-z", "-w", "3", hostname, fmt.Sprintf("%d", dep.Port))
 		if err := cmd.Run(); err != nil {
 			continue
 		}
@@ -74,8 +80,8 @@ func checkDependency(dep ServiceDependency) error {
 // GetDependencyInfo returns connection info for a dependency.
 // Useful for passing to init scripts or configuration.
 // TEAM_029: Used to get PostgreSQL connection details for Forgejo
-func GetDependencyInfo(dep ServiceDependency) (*DependencyInfo, error) {
-	tsIP, connected := CheckTailscaleConnected(dep.TailscaleHost)
+func GetDependencyInfo(ctx context.Context, dep ServiceDependency) (*DependencyInfo, error) {
+	tsIP, connected := CheckTailscaleConnected(ctx, dep.TailscaleHost)
 	if !connected {
 		return nil, fmt.Errorf("%s not connected to Tailscale", dep.TailscaleHost)
 	}
@@ -110,27 +116,48 @@ func (d *DependencyInfo) ConnectionString(user, password, dbname string) string
 
 // PostgreSQLDependency is a pre-configured dependency for PostgreSQL.
 // TEAM_029: Common dependency used by Forgejo and future services
-// Note: For VM-to-VM on same device, use TAP IP (192.168.100.2)
+// TEAM_047: TAP IP is resolved from the sql config/lease (ResolveDependencyIP)
 var PostgreSQLDependency = ServiceDependency{
 	Name:          "sql",
 	TailscaleHost: "sovereign-sql",
-	TAPIP:         "192.168.100.2", // SQL VM TAP IP for local VM-to-VM
 	Port:          5432,
 	Description:   "PostgreSQL database",
 }
 
+// ErrNoDevicePsql is returned by DevicePsql when the device has no psql
+var ErrNoDevicePsql = errors.New("psql not available on device")
+
+// DevicePsql runs psql on the device against host:port as user and returns
+// its combined output. The password reaches psql through a root-only
+// PGPASSFILE written over stdin, never through a command line.
+// TEAM_066: Replaces PGPASSWORD=%s in formatted shell strings
+func DevicePsql(ctx context.Context, host string, port int, user, password string, args ...string) (string, error) {
+	if out, _ := device.RunShellCommand(ctx, "command -v psql"); out == "" {
+		return "", ErrNoDevicePsql
+	}
+	esc := strings.NewReplacer(`\`, `\\`, ":", `\:`)
+	passfile, cleanup, err := device.WriteSecret(ctx, fmt.Sprintf("%s:%d:*:%s:%s\n", esc.Replace(host), port, esc.Replace(user), esc.Replace(password)))
+	if err != nil {
+		return "", fmt.Errorf("passing the database password: %w", err)
+	}
+	defer cleanup()
+
+	argv := append([]string{"psql", "-h", host, "-p", strconv.Itoa(port), "-U", user}, args...)
+	return device.RunShellCommand(ctx, "PGPASSFILE="+device.Quote(passfile)+" PGCONNECT_TIMEOUT=3 "+device.Quote(argv...)+" 2>&1")
+}
+
 // CheckPostgreSQLAvailable is a convenience function to check if PostgreSQL is ready.
-func CheckPostgreSQLAvailable() error {
+func CheckPostgreSQLAvailable(ctx context.Context) error {
 	fmt.Println("Checking PostgreSQL availability...")
 
-	tsIP, connected := CheckTailscaleConnected("sovereign-sql")
+	tsIP, connected := CheckTailscaleConnected(ctx, "sovereign-sql")
 	if !connected {
 		return fmt.Errorf("sovereign-sql not found on Tailscale\n" +
 			"  Start PostgreSQL first: sovereign start --sql")
 	}
 
 	// Check port
-	cmd := exec.Command("nc", "-z", "-w", "3", "sovereign-sql", "5432")
+	cmd := exec.CommandContext(ctx, "nc", "-z", "-w", "3", "sovereign-sql", "5432")
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("sovereign-sql is on Tailscale (%s) but PostgreSQL port 5432 not responding\n"+
 			"  Check status: sovereign test --sql", tsIP)
@@ -142,8 +169,8 @@ func CheckPostgreSQLAvailable() error {
 
 // GetPostgreSQLConnectionInfo returns PostgreSQL connection details.
 // Reads credentials from secrets if available.
-func GetPostgreSQLConnectionInfo() (host string, port int, user string, password string, err error) {
-	tsIP, connected := CheckTailscaleConnected("sovereign-sql")
+func GetPostgreSQLConnectionInfo(ctx context.Context) (host string, port int, user string, password string, err error) {
+	tsIP, connected := CheckTailscaleConnected(ctx, "sovereign-sql")
 	if !connected {
 		return "", 0, "", "", fmt.Errorf("sovereign-sql not connected to Tailscale")
 	}
@@ -4,17 +4,25 @@
 package common
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"path"
 	"sync"
+	"time"
 
 	"github.com/anthropics/sovereign/internal/device"
 )
 
-var (
-	// bootScriptDeployed tracks if boot script has been deployed this session
-	bootScriptDeployed bool
-	bootScriptMu       sync.Mutex
+// bootScriptMu serializes boot script deployment
+var bootScriptMu sync.Mutex
+
+// Boot script locations
+const (
+	bootScriptLocal  = "host/sovereign_start.sh"
+	bootScriptDevice = "/data/adb/service.d/sovereign_start.sh"
 )
 
 // FreshDataDeploy forces wiping data.img and re-registering Tailscale
@@ -23,9 +31,16 @@ var FreshDataDeploy bool
 
 // DeployVM deploys a VM to the Android device.
 // TEAM_029: Extracted from sql/sql.go Deploy() and forge/forge.go Deploy()
-func DeployVM(cfg *VMConfig) error {
+func DeployVM(ctx context.Context, cfg *VMConfig) error {
 	fmt.Printf("=== Deploying %s VM ===\n", cfg.DisplayName)
 
+	// TEAM_065: One mutating operation per VM and device at a time
+	unlock, err := lockVM(ctx, cfg, "deploy")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	fmt.Println("Tailscale: Using persistent machine identity (no cleanup needed)")
 
 	// Verify required files exist locally
@@ -49,95 +64,145 @@ func DeployVM(cfg *VMConfig) error {
 			"  2. Get auth key from https://login.tailscale.com/admin/settings/keys\n" +
 			"  3. Fill in TAILSCALE_AUTHKEY in .env")
 	}
+	// TEAM_051: Fail before pushing anything rather than fall back to the shell daemon
+	if _, err := os.Stat(SupervisorLocalPath); err != nil {
+		return errSupervisorNotBuilt
+	}
+
+	// TEAM_063: Everything below is planned from read-only checks, then
+	// printed (--dry-run) or executed
+	p := &Plan{Title: fmt.Sprintf("Deploy %s VM", cfg.DisplayName)}
 
 	// Create device directories
-	fmt.Println("Creating directories on device...")
-	device.MkdirP(cfg.DevicePath)
-	if !device.DirExists(cfg.DevicePath) {
-		return fmt.Errorf("failed to create directories on device")
-	}
+	p.Checked(StepShell, "Creating directories on device...", device.Quote("mkdir", "-p", cfg.DevicePath))
+
+	// TEAM_060: Every push below skips files the device already has
+	var st deployStats
+
+	// TEAM_062: rootfs, kernel, start script and build manifest go into a new
+	// release; the running one stays untouched until it is verified
+	rel := newRelease(ctx, cfg)
+	rel.planStage(p)
 
 	// Push rootfs
-	fmt.Println("Pushing rootfs.img (this may take a while)...")
-	if err := device.PushFile(
+	if err := st.planPush(ctx, p, "rootfs.img",
 		fmt.Sprintf("%s/rootfs.img", cfg.LocalPath),
-		fmt.Sprintf("%s/rootfs.img", cfg.DevicePath)); err != nil {
+		rel.path("rootfs.img"), rel.base["rootfs.img"]); err != nil {
 		return err
 	}
 
+	// TEAM_056: Provenance of the images just pushed, read back by status
+	if err := planBuildManifest(ctx, p, cfg, &st, rel); err != nil {
+		fmt.Printf("  ⚠ Build manifest not deployed: %v\n", err)
+	}
+
 	// Push data disk - BUT PRESERVE IF EXISTS (contains Tailscale state!)
 	// TEAM_034: Only push data.img if it doesn't exist on device OR --fresh-data flag
 	// This preserves Tailscale machine identity across redeploys
+	dataImgLocal := fmt.Sprintf("%s/data.img", cfg.LocalPath)
 	dataImgDevice := fmt.Sprintf("%s/data.img", cfg.DevicePath)
 	if FreshDataDeploy {
 		// User explicitly wants a fresh start - clean up old Tailscale registrations
 		fmt.Println("--fresh-data: Cleaning up old Tailscale registrations...")
-		if err := RemoveTailscaleRegistrations(cfg.TailscaleHost); err != nil {
+		if err := planTailscaleCleanup(ctx, p, cfg.TailscaleHost); err != nil {
 			fmt.Printf("  ⚠ Warning: %v\n", err)
 		}
-		fmt.Println("Pushing fresh data.img (new Tailscale identity)...")
-		if err := device.PushFile(
-			fmt.Sprintf("%s/data.img", cfg.LocalPath),
-			dataImgDevice); err != nil {
+		if err := st.planReplace(p, "Pushing fresh data.img (new Tailscale identity)", dataImgLocal, dataImgDevice); err != nil {
 			return err
 		}
-	} else if device.FileExists(dataImgDevice) {
-		fmt.Println("Preserving existing data.img (contains Tailscale identity)")
+	} else if device.FileExists(ctx, dataImgDevice) {
+		p.Skip("Preserving existing data.img (contains Tailscale identity)")
 	} else {
-		fmt.Println("Pushing data.img (first deploy)...")
-		if err := device.PushFile(
-			fmt.Sprintf("%s/data.img", cfg.LocalPath),
-			dataImgDevice); err != nil {
+		if err := st.planReplace(p, "Pushing data.img (first deploy)", dataImgLocal, dataImgDevice); err != nil {
 			return err
 		}
 	}
 
 	// Push kernel
 	if cfg.SharedKernel && cfg.KernelSource != "" {
-		fmt.Println("Pushing kernel (shared from sql VM)...")
-		if err := device.PushFile(cfg.KernelSource, fmt.Sprintf("%s/Image", cfg.DevicePath)); err != nil {
+		if err := st.planPush(ctx, p, "kernel (shared from sql VM)", cfg.KernelSource, rel.path("Image"), rel.base["Image"]); err != nil {
 			return err
 		}
 	} else {
-		fmt.Println("Pushing guest kernel (this may take a while - 35MB)...")
-		if err := device.PushFile(
+		if err := st.planPush(ctx, p, "guest kernel",
 			fmt.Sprintf("%s/Image", cfg.LocalPath),
-			fmt.Sprintf("%s/Image", cfg.DevicePath)); err != nil {
+			rel.path("Image"), rel.base["Image"]); err != nil {
 			return err
 		}
 	}
 
 	// Push .env
-	if _, err := os.Stat(envPath); err == nil {
-		fmt.Println("Pushing .env...")
-		if err := device.PushFile(envPath, "/data/sovereign/.env"); err != nil {
-			return err
-		}
+	if err := st.planPush(ctx, p, ".env", envPath, "/data/sovereign/.env", ""); err != nil {
+		return err
 	}
 
 	// Push and chmod start script
-	fmt.Println("Creating start script...")
 	startScriptLocal := fmt.Sprintf("%s/start.sh", cfg.LocalPath)
-	startScriptDevice := fmt.Sprintf("%s/start.sh", cfg.DevicePath)
+	startScriptDevice := rel.path("start.sh")
 
 	if _, err := os.Stat(startScriptLocal); os.IsNotExist(err) {
 		return fmt.Errorf("start script not found: %s", startScriptLocal)
 	}
 
-	if err := device.PushFile(startScriptLocal, startScriptDevice); err != nil {
+	if err := st.planPush(ctx, p, "start.sh", startScriptLocal, startScriptDevice, rel.base["start.sh"]); err != nil {
 		return err
 	}
+	p.Checked(StepShell, "", device.Quote("chmod", "+x", startScriptDevice))
+
+	p.Do(StepShell, fmt.Sprintf("Verifying release %s...", rel.id), false, func(ctx context.Context) error {
+		return rel.verify(ctx, &st)
+	})
+	rel.planCommit(p)
+	planPrune(ctx, p, cfg, rel.id)
+
+	// TEAM_047: Reserve the guest IP now so boot-time daemon mode knows it
+	p.Do(StepLocal, fmt.Sprintf("Reserving %s's guest IP lease...", cfg.Name), false, func(ctx context.Context) error {
+		ip, err := AssignGuestIP(cfg)
+		if err != nil {
+			return fmt.Errorf("assigning guest IP: %w", err)
+		}
+		fmt.Printf("Guest IP: %s\n", ip)
+		return nil
+	})
+	p.Push("", "network.env", NetworkEnvDevice, 0, func(ctx context.Context) error {
+		if err := PushNetworkEnv(ctx); err != nil {
+			return fmt.Errorf("pushing network.env: %w", err)
+		}
+		return nil
+	})
 
-	if _, err := device.RunShellCommand(fmt.Sprintf("chmod +x %s", startScriptDevice)); err != nil {
-		return fmt.Errorf("failed to chmod start script: %w", err)
+	// TEAM_037: Deploy boot script to /data/adb/service.d/
+	// TEAM_064: Only when it or the supervisor changed since the last deploy to this device
+	if !bootScriptCurrent(ctx) {
+		p.Add(Step{Kind: StepPush, Local: bootScriptLocal, Remote: bootScriptDevice + " and /data/sovereign/ (+ supervisor)",
+			run: func(ctx context.Context) error {
+				if err := DeployBootScript(ctx); err != nil {
+					return fmt.Errorf("boot script deployment failed: %w", err)
+				}
+				return nil
+			}})
+	} else {
+		p.Skip("  ✓ Boot script and supervisor unchanged")
 	}
 
-	// TEAM_037: Deploy boot script to /data/adb/service.d/ (once per session)
-	if err := DeployBootScript(); err != nil {
-		fmt.Printf("  ⚠ Warning: boot script deployment failed: %v\n", err)
+	ran, err := runPlan(ctx, p)
+	if err != nil {
+		rel.abandon()
+		return err
+	}
+	if !ran {
+		return nil
 	}
+	st.report()
+	recordVM(cfg, func(r *VMRecord) {
+		r.Release, r.DeployedAt = rel.id, time.Now().UTC()
+		r.Build = ""
+		if m, err := LocalBuild(cfg); err == nil {
+			r.Build = m.InputsKey
+		}
+	})
 
-	fmt.Printf("\n✓ %s VM deployed\n", cfg.DisplayName)
+	fmt.Printf("\n✓ %s VM deployed (release %s)\n", cfg.DisplayName, rel.id)
 	fmt.Printf("\nNext: sovereign start --%s\n", cfg.Name)
 	return nil
 }
@@ -145,44 +210,88 @@ func DeployVM(cfg *VMConfig) error {
 // DeployBootScript deploys the sovereign_start.sh boot script to /data/adb/service.d/
 // TEAM_037: This script runs at boot via KernelSU and keeps VMs alive as its children,
 // preventing Android init from killing them as orphaned processes.
-func DeployBootScript() error {
+func DeployBootScript(ctx context.Context) error {
 	bootScriptMu.Lock()
 	defer bootScriptMu.Unlock()
 
-	// Only deploy once per session
-	if bootScriptDeployed {
+	// TEAM_064: deploy --all deploys each VM; the first one installs it
+	if bootScriptCurrent(ctx) {
 		return nil
 	}
+	key, err := bootScriptKey()
+	if err != nil {
+		return err
+	}
 
-	localScript := "host/sovereign_start.sh"
+	localScript := bootScriptLocal
 	if _, err := os.Stat(localScript); os.IsNotExist(err) {
 		return fmt.Errorf("boot script not found: %s", localScript)
 	}
 
 	// Create /data/adb/service.d/ if it doesn't exist
-	serviceDir := "/data/adb/service.d"
-	device.RunShellCommand(fmt.Sprintf("mkdir -p %s", serviceDir))
+	device.MkdirP(ctx, path.Dir(bootScriptDevice))
 
 	// Push boot script
-	destScript := serviceDir + "/sovereign_start.sh"
+	destScript := bootScriptDevice
 	fmt.Println("Deploying boot script to " + destScript + "...")
-	if err := device.PushFile(localScript, destScript); err != nil {
+	if err := device.PushFile(ctx, localScript, destScript); err != nil {
 		return fmt.Errorf("failed to push boot script: %w", err)
 	}
 
 	// Make executable
-	if _, err := device.RunShellCommand(fmt.Sprintf("chmod +x %s", destScript)); err != nil {
+	if _, err := device.Exec(ctx, "chmod", "+x", destScript); err != nil {
 		return fmt.Errorf("failed to chmod boot script: %w", err)
 	}
 
 	// Also copy to /data/sovereign/ for CLI access
-	device.RunShellCommand("mkdir -p /data/sovereign")
-	if err := device.PushFile(localScript, "/data/sovereign/sovereign_start.sh"); err != nil {
+	device.RunShellCommand(ctx, "mkdir -p /data/sovereign")
+	if err := device.PushFile(ctx, localScript, "/data/sovereign/sovereign_start.sh"); err != nil {
 		return fmt.Errorf("failed to push boot script to /data/sovereign: %w", err)
 	}
-	device.RunShellCommand("chmod +x /data/sovereign/sovereign_start.sh")
+	device.RunShellCommand(ctx, "chmod +x /data/sovereign/sovereign_start.sh")
+
+	// TEAM_051: The boot script execs the supervisor when it is installed
+	if err := deploySupervisor(ctx); err != nil {
+		return fmt.Errorf("failed to deploy supervisor: %w", err)
+	}
+	fmt.Println("✓ Supervisor deployed to " + SupervisorDevicePath)
 
-	bootScriptDeployed = true
+	recordDevice(func(d *DeviceState) { d.BootScript = key })
 	fmt.Println("✓ Boot script deployed (VMs will auto-start at boot)")
 	return nil
 }
+
+// bootScriptKey digests the boot script and the supervisor binary, which
+// DeployBootScript installs together
+// TEAM_064: Recorded per device in the state store
+func bootScriptKey() (string, error) {
+	h := sha256.New()
+	sum, err := hashFile(bootScriptLocal)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "%s\x00", sum)
+	if sum, err = hashFile(SupervisorLocalPath); err != nil {
+		if os.IsNotExist(err) {
+			return "", errSupervisorNotBuilt
+		}
+		return "", err
+	}
+	fmt.Fprintf(h, "%s\x00", sum)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// bootScriptCurrent reports whether the selected device has the boot script
+// and supervisor this host would deploy, going by the state store
+func bootScriptCurrent(ctx context.Context) bool {
+	key, err := bootScriptKey()
+	if err != nil {
+		return false
+	}
+	s, err := LoadState()
+	if err != nil {
+		return false
+	}
+	d := s.Device()
+	return d != nil && d.BootScript == key && device.FileExists(ctx, bootScriptDevice)
+}
@@ -1,284 +1,314 @@
 // Diagnose command - comprehensive debugging for VMs
 // TEAM_041: Created for better troubleshooting
+// TEAM_045: Collects findings into a Diagnosis instead of printing
 package common
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/report"
+	"github.com/anthropics/sovereign/internal/supervisor"
 )
 
 // DiagnoseVM runs comprehensive diagnostics for a VM
-func DiagnoseVM(cfg *VMConfig) error {
-	fmt.Printf("=== Diagnosing %s VM ===\n\n", cfg.DisplayName)
+func DiagnoseVM(ctx context.Context, cfg *VMConfig) (*Diagnosis, error) {
+	d := &Diagnosis{
+		VM:       cfg.Name,
+		Title:    fmt.Sprintf("Diagnosing %s VM", cfg.DisplayName),
+		footer:   "Diagnosis Complete",
+		numbered: true,
+	}
 
 	// 1. Process Status
-	fmt.Println("## 1. Process Status")
-	pid := device.GetProcessPID(cfg.ProcessPattern)
-	if pid != "" {
-		fmt.Printf("   ✓ VM process running (PID: %s)\n", pid)
+	s := d.section("Process Status")
+	pid := processPID(ctx, cfg)
+	if pid != "" && device.FileExists(ctx, pausedMarker(cfg)) {
+		// TEAM_050: Network checks below will fail while the guest is suspended
+		s.add(report.StatusWarn, "VM process PAUSED (PID: %s) - resume before trusting connectivity checks", pid)
+	} else if pid != "" {
+		s.add(report.StatusOK, "VM process running (PID: %s)", pid)
 		// Get process details
-		out, _ := device.RunShellCommand(fmt.Sprintf("ps -p %s -o pid,ppid,etime,args 2>/dev/null | tail -1", pid))
+		out, _ := device.RunShellCommand(ctx, device.Quote("ps", "-p", pid, "-o", "pid,ppid,etime,args")+" 2>/dev/null | tail -1")
 		if out != "" {
-			fmt.Printf("   Details: %s\n", out)
+			s.add(report.StatusInfo, "Details: %s", out)
 		}
 	} else {
-		fmt.Printf("   ✗ VM process NOT running\n")
-		fmt.Printf("   Pattern used: %s\n", cfg.ProcessPattern)
+		s.add(report.StatusFail, "VM process NOT running")
+		s.add(report.StatusInfo, "Pattern used: %s", cfg.ProcessPattern)
 	}
+	addSupervisorStatus(ctx, s, cfg)
+
+	addProvenance(ctx, d.section("Build Provenance"), cfg)
 
 	// 2. TAP Interface
-	fmt.Println("\n## 2. TAP Interface")
-	tapOut, _ := device.RunShellCommand(fmt.Sprintf("ip link show %s 2>/dev/null", cfg.TAPInterface))
+	s = d.section("TAP Interface")
+	tapOut, _ := device.RunShellCommand(ctx, device.Quote("ip", "link", "show", cfg.TAPInterface)+" 2>/dev/null")
 	if tapOut != "" {
 		if strings.Contains(tapOut, "UP") && strings.Contains(tapOut, "LOWER_UP") {
-			fmt.Printf("   ✓ TAP %s is UP\n", cfg.TAPInterface)
+			s.add(report.StatusOK, "TAP %s is UP", cfg.TAPInterface)
 		} else if strings.Contains(tapOut, "NO-CARRIER") {
-			fmt.Printf("   ⚠ TAP %s has NO-CARRIER (VM may not be connected)\n", cfg.TAPInterface)
+			s.add(report.StatusWarn, "TAP %s has NO-CARRIER (VM may not be connected)", cfg.TAPInterface)
 		} else {
-			fmt.Printf("   ⚠ TAP %s exists but state unclear\n", cfg.TAPInterface)
+			s.add(report.StatusWarn, "TAP %s exists but state unclear", cfg.TAPInterface)
 		}
 		// Show TAP details
-		lines := strings.Split(tapOut, "\n")
-		for _, line := range lines {
+		for _, line := range strings.Split(tapOut, "\n") {
 			if strings.TrimSpace(line) != "" {
-				fmt.Printf("   %s\n", strings.TrimSpace(line))
+				s.add(report.StatusInfo, "%s", strings.TrimSpace(line))
 			}
 		}
 	} else {
-		fmt.Printf("   ✗ TAP %s does NOT exist\n", cfg.TAPInterface)
+		s.add(report.StatusFail, "TAP %s does NOT exist", cfg.TAPInterface)
 	}
 
 	// 3. Bridge Status
-	fmt.Println("\n## 3. Bridge Network")
-	bridgeOut, _ := device.RunShellCommand("ip link show vm_bridge 2>/dev/null")
+	s = d.section("Bridge Network")
+	bridgeOut, _ := device.RunShellCommand(ctx, "ip link show vm_bridge 2>/dev/null")
 	if bridgeOut != "" {
-		fmt.Printf("   ✓ Bridge vm_bridge exists\n")
+		s.add(report.StatusOK, "Bridge vm_bridge exists")
 	} else {
-		fmt.Printf("   ✗ Bridge vm_bridge does NOT exist\n")
+		s.add(report.StatusFail, "Bridge vm_bridge does NOT exist")
 	}
 	// Show bridge members
-	brctl, _ := device.RunShellCommand("cat /sys/class/net/vm_bridge/brif/*/ifindex 2>/dev/null | wc -l")
+	brctl, _ := device.RunShellCommand(ctx, "cat /sys/class/net/vm_bridge/brif/*/ifindex 2>/dev/null | wc -l")
 	if brctl != "" && brctl != "0" {
-		fmt.Printf("   Bridge has %s attached interfaces\n", strings.TrimSpace(brctl))
+		s.add(report.StatusInfo, "Bridge has %s attached interfaces", strings.TrimSpace(brctl))
 	}
 
 	// 4. Port Connectivity (TAP)
-	fmt.Println("\n## 4. Port Connectivity (TAP)")
+	s = d.section("Port Connectivity (TAP)")
 	for _, port := range cfg.ServicePorts {
 		// Test via nc from host
-		testCmd := fmt.Sprintf("timeout 2 nc -zv %s %d 2>&1", cfg.TAPGuestIP, port)
-		out, _ := device.RunShellCommand(testCmd)
+		testCmd := device.Quote("timeout", "2", "nc", "-zv", cfg.TAPGuestIP, strconv.Itoa(port)) + " 2>&1"
+		out, _ := device.RunShellCommand(ctx, testCmd)
 		if strings.Contains(out, "succeeded") || strings.Contains(out, "open") {
-			fmt.Printf("   ✓ Port %d on %s: OPEN\n", port, cfg.TAPGuestIP)
+			s.add(report.StatusOK, "Port %d on %s: OPEN", port, cfg.TAPGuestIP)
 		} else {
-			fmt.Printf("   ✗ Port %d on %s: CLOSED/UNREACHABLE\n", port, cfg.TAPGuestIP)
+			s.add(report.StatusFail, "Port %d on %s: CLOSED/UNREACHABLE", port, cfg.TAPGuestIP)
 		}
 	}
 
 	// 5. Tailscale Status
-	fmt.Println("\n## 5. Tailscale Status")
-	tsOut, err := exec.Command("tailscale", "status").Output()
+	s = d.section("Tailscale Status")
+	tsOut, err := exec.CommandContext(ctx, "tailscale", "status").Output()
 	if err != nil {
-		fmt.Printf("   ✗ Cannot get tailscale status: %v\n", err)
+		s.add(report.StatusFail, "Cannot get tailscale status: %v", err)
 	} else {
-		lines := strings.Split(string(tsOut), "\n")
 		found := false
-		for _, line := range lines {
+		for _, line := range strings.Split(string(tsOut), "\n") {
 			if strings.Contains(line, cfg.TailscaleHost) {
 				found = true
 				parts := strings.Fields(line)
 				if len(parts) >= 2 {
 					ip := parts[0]
 					hostname := parts[1]
-					online := !strings.Contains(line, "offline")
-					if online {
-						fmt.Printf("   ✓ %s (%s) - ONLINE\n", hostname, ip)
+					if !strings.Contains(line, "offline") {
+						s.add(report.StatusOK, "%s (%s) - ONLINE", hostname, ip)
 					} else {
-						fmt.Printf("   ⚠ %s (%s) - OFFLINE\n", hostname, ip)
+						s.add(report.StatusWarn, "%s (%s) - OFFLINE", hostname, ip)
 					}
 				}
 			}
 		}
 		if !found {
-			fmt.Printf("   ✗ No Tailscale entry matching '%s'\n", cfg.TailscaleHost)
+			s.add(report.StatusFail, "No Tailscale entry matching '%s'", cfg.TailscaleHost)
 		}
 	}
 
 	// 6. HTTPS Connectivity (if Tailscale)
 	if cfg.TailscaleHost != "" {
-		fmt.Println("\n## 6. HTTPS Connectivity")
-		fqdn := GetTailscaleFQDN(cfg)
+		s = d.section("HTTPS Connectivity")
+		fqdn := GetTailscaleFQDN(ctx, cfg)
 		if fqdn == "" {
-			fmt.Printf("   ✗ Cannot determine Tailscale FQDN\n")
+			s.add(report.StatusFail, "Cannot determine Tailscale FQDN")
 		} else {
 			url := fmt.Sprintf("https://%s", fqdn)
-			fmt.Printf("   Testing: %s\n", url)
+			s.add(report.StatusInfo, "Testing: %s", url)
 
 			// Do actual HTTP request with verbose output
 			start := time.Now()
-			cmd := exec.Command("curl", "-sk", "-o", "/dev/null", "-w",
+			cmd := exec.CommandContext(ctx, "curl", "-sk", "-o", "/dev/null", "-w",
 				"HTTP %{http_code}, Time: %{time_total}s, IP: %{remote_ip}",
 				"--connect-timeout", "10", url)
 			output, err := cmd.CombinedOutput()
 			elapsed := time.Since(start)
 
 			if err != nil {
-				fmt.Printf("   ✗ Request failed: %v\n", err)
-				fmt.Printf("   Output: %s\n", strings.TrimSpace(string(output)))
+				s.add(report.StatusFail, "Request failed: %v", err)
+				s.add(report.StatusInfo, "Output: %s", strings.TrimSpace(string(output)))
 			} else {
 				result := strings.TrimSpace(string(output))
 				if strings.Contains(result, "HTTP 200") {
-					fmt.Printf("   ✓ %s (%.2fs)\n", result, elapsed.Seconds())
+					s.add(report.StatusOK, "%s (%.2fs)", result, elapsed.Seconds())
 				} else {
-					fmt.Printf("   ⚠ %s (%.2fs)\n", result, elapsed.Seconds())
+					s.add(report.StatusWarn, "%s (%.2fs)", result, elapsed.Seconds())
 				}
 			}
 
 			// Also test /api endpoint for Vaultwarden
 			if cfg.Name == "vault" {
 				apiUrl := fmt.Sprintf("https://%s/api/config", fqdn)
-				cmd = exec.Command("curl", "-sk", "-o", "/dev/null", "-w", "%{http_code}",
+				cmd = exec.CommandContext(ctx, "curl", "-sk", "-o", "/dev/null", "-w", "%{http_code}",
 					"--connect-timeout", "5", apiUrl)
 				output, _ = cmd.Output()
 				httpCode := strings.TrimSpace(string(output))
 				if httpCode == "200" {
-					fmt.Printf("   ✓ API endpoint: HTTP %s\n", httpCode)
+					s.add(report.StatusOK, "API endpoint: HTTP %s", httpCode)
 				} else {
-					fmt.Printf("   ⚠ API endpoint: HTTP %s\n", httpCode)
+					s.add(report.StatusWarn, "API endpoint: HTTP %s", httpCode)
 				}
 			}
 		}
 	}
 
 	// 7. Console Log (last 10 lines)
-	fmt.Println("\n## 7. Recent Console Output")
+	s = d.section("Recent Console Output")
 	consoleLog := fmt.Sprintf("%s/console.log", cfg.DevicePath)
-	logOut, _ := device.RunShellCommand(fmt.Sprintf("tail -10 %s 2>/dev/null", consoleLog))
+	logOut, _ := device.RunShellCommand(ctx, device.Quote("tail", "-10", consoleLog)+" 2>/dev/null")
 	if logOut != "" {
-		lines := strings.Split(logOut, "\n")
-		for _, line := range lines {
-			if strings.TrimSpace(line) != "" {
-				// Truncate long lines
-				if len(line) > 100 {
-					line = line[:100] + "..."
-				}
-				fmt.Printf("   %s\n", line)
-			}
-		}
+		addLogLines(s, logOut)
 	} else {
-		fmt.Printf("   (no console.log found)\n")
+		s.add(report.StatusInfo, "(no console.log found)")
 	}
 
 	// 8. Error Detection
-	fmt.Println("\n## 8. Error Detection")
-	errOut, _ := device.RunShellCommand(fmt.Sprintf("grep -iE '(error|fatal|failed|panic)' %s 2>/dev/null | tail -5", consoleLog))
+	s = d.section("Error Detection")
+	errOut, _ := device.RunShellCommand(ctx, device.Quote("grep", "-iE", "(error|fatal|failed|panic)", consoleLog)+" 2>/dev/null | tail -5")
 	if errOut != "" && strings.TrimSpace(errOut) != "" {
-		fmt.Printf("   ⚠ Errors found in console.log:\n")
-		lines := strings.Split(errOut, "\n")
-		for _, line := range lines {
-			if strings.TrimSpace(line) != "" {
-				if len(line) > 100 {
-					line = line[:100] + "..."
-				}
-				fmt.Printf("   %s\n", line)
-			}
-		}
+		s.add(report.StatusWarn, "Errors found in console.log:")
+		addLogLines(s, errOut)
 	} else {
-		fmt.Printf("   ✓ No obvious errors in console.log\n")
+		s.add(report.StatusOK, "No obvious errors in console.log")
 	}
 
 	// 9. Recommendations
-	fmt.Println("\n## 9. Recommendations")
+	s = d.section("Recommendations")
 	if pid == "" {
-		fmt.Printf("   → Run: sovereign start --%s\n", cfg.Name)
+		s.add(report.StatusInfo, "→ Run: sovereign start --%s", cfg.Name)
 	}
 	if tapOut == "" || strings.Contains(tapOut, "NO-CARRIER") {
-		fmt.Printf("   → TAP issue: Try sovereign stop --%s && sovereign start --%s\n", cfg.Name, cfg.Name)
+		s.add(report.StatusInfo, "→ TAP issue: Try sovereign stop --%s && sovereign start --%s", cfg.Name, cfg.Name)
 	}
 	if errOut != "" && strings.Contains(errOut, "password authentication failed") {
-		fmt.Printf("   → Database password mismatch: Restart SQL VM first, then restart this VM\n")
+		s.add(report.StatusInfo, "→ Database password mismatch: Restart SQL VM first, then restart this VM")
 	}
 
-	fmt.Println()
-	fmt.Println("=== Diagnosis Complete ===")
-	return nil
+	return d, nil
+}
+
+// addLogLines adds non-empty log lines to s, truncating long ones
+func addLogLines(s *DiagnosticSection, out string) {
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) != "" {
+			if len(line) > 100 {
+				line = line[:100] + "..."
+			}
+			s.add(report.StatusInfo, "%s", line)
+		}
+	}
+}
+
+// addSupervisorStatus adds the supervisor's restart history for cfg and, after
+// an unexpected exit, its post-mortem
+// TEAM_052: Shared by diagnose and status
+func addSupervisorStatus(ctx context.Context, s *DiagnosticSection, cfg *VMConfig) {
+	st, ok := supervisedStatus(ctx, cfg)
+	if !ok {
+		return
+	}
+	s.add(report.StatusInfo, "Supervisor: %s, %d restart(s)", st.State, st.Restarts)
+	if st.LastExit != "" {
+		s.add(report.StatusInfo, "Last exit: %s at %s", st.LastExit, st.LastExitAt.Format(time.RFC3339))
+	}
+	if st.CrashLoop {
+		s.add(report.StatusFail, "Crash loop - supervisor gave up restarting; run 'sovereign start --%s' after fixing", cfg.Name)
+	}
+	if st.State == supervisor.StateFailed || st.State == supervisor.StateBackoff {
+		for _, line := range st.ConsoleTail {
+			s.add(report.StatusInfo, "console: %s", line)
+		}
+	}
 }
 
 // DiagnoseAll runs diagnostics on all VMs and infrastructure
-func DiagnoseAll() error {
-	fmt.Println("=== Sovereign Vault System Diagnosis ===")
-	fmt.Println()
+func DiagnoseAll(ctx context.Context) (*Diagnosis, error) {
+	d := &Diagnosis{
+		VM:     "all",
+		Title:  "Sovereign Vault System Diagnosis",
+		footer: "Use 'sovereign diagnose --<vm>' for detailed VM diagnosis",
+	}
 
 	// 1. Host connectivity
-	fmt.Println("## Host System")
-	if device.IsConnected() {
-		fmt.Println("   ✓ ADB connected to device")
+	s := d.section("Host System")
+	if device.IsConnected(ctx) {
+		s.add(report.StatusOK, "ADB connected to device")
 	} else {
-		fmt.Println("   ✗ ADB NOT connected")
-		return fmt.Errorf("no device connected")
+		s.add(report.StatusFail, "ADB NOT connected")
+		return d, fmt.Errorf("no device connected")
 	}
 
 	// 2. crosvm availability
-	crosvmOut, _ := device.RunShellCommand("ls -la /apex/com.android.virt/bin/crosvm 2>/dev/null")
+	crosvmOut, _ := device.RunShellCommand(ctx, "ls -la /apex/com.android.virt/bin/crosvm 2>/dev/null")
 	if crosvmOut != "" {
-		fmt.Println("   ✓ crosvm binary found")
+		s.add(report.StatusOK, "crosvm binary found")
 	} else {
-		fmt.Println("   ✗ crosvm binary NOT found - AVF may not be enabled")
+		s.add(report.StatusFail, "crosvm binary NOT found - AVF may not be enabled")
 	}
 
 	// 3. Daemon status
-	daemonOut, _ := device.RunShellCommand("pgrep -f sovereign_start.sh 2>/dev/null | wc -l")
+	daemonOut, _ := device.RunShellCommand(ctx, "pgrep -f sovereign_start.sh 2>/dev/null | wc -l")
 	daemonCount := strings.TrimSpace(daemonOut)
 	if daemonCount != "0" && daemonCount != "" {
-		fmt.Printf("   ✓ Daemon processes running: %s\n", daemonCount)
+		s.add(report.StatusOK, "Daemon processes running: %s", daemonCount)
 	} else {
-		fmt.Println("   ⚠ No daemon processes running")
+		s.add(report.StatusWarn, "No daemon processes running")
 	}
 
 	// 4. Bridge network
-	bridgeOut, _ := device.RunShellCommand("ip addr show vm_bridge 2>/dev/null")
-	if strings.Contains(bridgeOut, "192.168.100.1") {
-		fmt.Println("   ✓ Bridge network configured (192.168.100.1)")
+	bridgeOut, _ := device.RunShellCommand(ctx, device.Quote("ip", "addr", "show", BridgeName)+" 2>/dev/null")
+	subnet, _ := BridgeSubnet()
+	if gw, err := BridgeIP(subnet); err == nil && strings.Contains(bridgeOut, gw) {
+		s.add(report.StatusOK, "Bridge network configured (%s)", gw)
 	} else {
-		fmt.Println("   ⚠ Bridge network not configured")
+		s.add(report.StatusWarn, "Bridge network not configured")
 	}
 
 	// 5. Running VMs
-	fmt.Println("\n## Running VMs")
-	vmOut, _ := device.RunShellCommand("ps -ef | grep '[c]rosvm' | wc -l")
-	vmCount := strings.TrimSpace(vmOut)
-	fmt.Printf("   crosvm processes: %s\n", vmCount)
+	s = d.section("Running VMs")
+	vmOut, _ := device.RunShellCommand(ctx, "ps -ef | grep '[c]rosvm' | wc -l")
+	s.add(report.StatusInfo, "crosvm processes: %s", strings.TrimSpace(vmOut))
 
 	// List each VM
 	for _, vmName := range []string{"sql", "forge", "vault"} {
 		pattern := fmt.Sprintf("[c]rosvm.*vm/%s/", vmName)
-		pid := device.GetProcessPID(pattern)
-		if pid != "" {
-			fmt.Printf("   ✓ %s: running (PID %s)\n", vmName, pid)
+		pid := device.GetProcessPID(ctx, pattern)
+		if pid != "" && isPaused(ctx, vmName) {
+			s.add(report.StatusWarn, "%s: paused (PID %s)", vmName, pid)
+		} else if pid != "" {
+			s.add(report.StatusOK, "%s: running (PID %s)", vmName, pid)
 		} else {
-			fmt.Printf("   ✗ %s: not running\n", vmName)
+			s.add(report.StatusFail, "%s: not running", vmName)
 		}
 	}
 
 	// 6. Tailscale status
-	fmt.Println("\n## Tailscale Connections")
-	tsOut, err := exec.Command("tailscale", "status").Output()
+	s = d.section("Tailscale Connections")
+	tsOut, err := exec.CommandContext(ctx, "tailscale", "status").Output()
 	if err != nil {
-		fmt.Printf("   ✗ Cannot get tailscale status: %v\n", err)
+		s.add(report.StatusFail, "Cannot get tailscale status: %v", err)
 	} else {
-		lines := strings.Split(string(tsOut), "\n")
-		for _, line := range lines {
+		for _, line := range strings.Split(string(tsOut), "\n") {
 			if strings.Contains(line, "sovereign-") {
-				fmt.Printf("   %s\n", strings.TrimSpace(line))
+				s.add(report.StatusInfo, "%s", strings.TrimSpace(line))
 			}
 		}
 	}
 
-	fmt.Println()
-	fmt.Println("=== Use 'sovereign diagnose --<vm>' for detailed VM diagnosis ===")
-	return nil
+	return d, nil
 }
@@ -3,322 +3,306 @@
 package common
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/report"
 )
 
-// FixResult represents the result of a fix attempt
-type FixResult struct {
-	Issue   string
-	Fixed   bool
-	Message string
-}
-
 // FixVM attempts to automatically detect and fix common issues for a VM
-func FixVM(cfg *VMConfig) error {
-	fmt.Printf("=== Auto-Fix for %s VM ===\n\n", cfg.DisplayName)
-
-	var results []FixResult
+// TEAM_045: Returns a FixReport instead of printing
+func FixVM(ctx context.Context, cfg *VMConfig) (*FixReport, error) {
+	r := &FixReport{VM: cfg.Name, DisplayName: cfg.DisplayName}
+
+	// TEAM_063: Each check repairs as it goes, so there is no plan to print;
+	// diagnose runs the same checks without changing anything
+	if DryRun {
+		return r, fmt.Errorf("fix --%s has no dry run - 'sovereign diagnose --%s' shows what it would check", cfg.Name, cfg.Name)
+	}
 
 	// 1. Check and fix device connectivity
-	fmt.Println("## 1. Checking device connectivity...")
-	if !device.IsConnected() {
-		fmt.Println("   ✗ Device not connected - cannot auto-fix")
-		return fmt.Errorf("device not connected")
+	if !device.IsConnected(ctx) {
+		r.add("Checking device connectivity", FixResult{Issue: "device", Status: report.StatusFail, Message: "Device not connected - cannot auto-fix"})
+		return r, fmt.Errorf("device not connected")
 	}
-	fmt.Println("   ✓ Device connected")
+	r.add("Checking device connectivity", FixResult{Issue: "device", Status: report.StatusOK, Message: "Device connected"})
 
-	// 2. Check and fix bridge network
-	fmt.Println("\n## 2. Checking bridge network...")
-	result := fixBridge()
-	results = append(results, result)
-	if result.Fixed {
-		fmt.Printf("   ✓ Fixed: %s\n", result.Message)
-	} else if result.Message != "" {
-		fmt.Printf("   %s\n", result.Message)
+	// TEAM_065: One mutating operation per VM and device at a time
+	unlock, err := lockVM(ctx, cfg, "fix")
+	if err != nil {
+		return r, err
 	}
+	defer unlock()
+
+	// 2. Check and fix bridge network
+	r.add("Checking bridge network", fixBridge(ctx))
 
 	// 3. Check and fix process killers
-	fmt.Println("\n## 3. Checking Android process killers...")
-	result = fixProcessKillers()
-	results = append(results, result)
-	if result.Fixed {
-		fmt.Printf("   ✓ Fixed: %s\n", result.Message)
-	} else if result.Message != "" {
-		fmt.Printf("   %s\n", result.Message)
-	}
+	r.add("Checking Android process killers", fixProcessKillers(ctx))
 
 	// 4. Check and fix VM process
-	fmt.Println("\n## 4. Checking VM process...")
-	result = fixVMProcess(cfg)
-	results = append(results, result)
-	if result.Fixed {
-		fmt.Printf("   ✓ Fixed: %s\n", result.Message)
-	} else if result.Message != "" {
-		fmt.Printf("   %s\n", result.Message)
-	}
+	r.add("Checking VM process", fixVMProcess(ctx, cfg))
 
 	// 5. Check and fix TAP interface
-	fmt.Println("\n## 5. Checking TAP interface...")
-	result = fixTAP(cfg)
-	results = append(results, result)
-	if result.Fixed {
-		fmt.Printf("   ✓ Fixed: %s\n", result.Message)
-	} else if result.Message != "" {
-		fmt.Printf("   %s\n", result.Message)
-	}
+	r.add("Checking TAP interface", fixTAP(ctx, cfg))
 
 	// 6. Check and fix stale state files
-	fmt.Println("\n## 6. Checking for stale state...")
-	result = fixStaleState(cfg)
-	results = append(results, result)
-	if result.Fixed {
-		fmt.Printf("   ✓ Fixed: %s\n", result.Message)
-	} else if result.Message != "" {
-		fmt.Printf("   %s\n", result.Message)
-	}
+	r.add("Checking for stale state", fixStaleState(ctx, cfg))
 
 	// 7. For Vault/Forge - check SQL dependency
 	if len(cfg.Dependencies) > 0 {
-		fmt.Println("\n## 7. Checking dependencies...")
-		result = fixDependencies(cfg)
-		results = append(results, result)
-		if result.Fixed {
-			fmt.Printf("   ✓ Fixed: %s\n", result.Message)
-		} else if result.Message != "" {
-			fmt.Printf("   %s\n", result.Message)
-		}
+		r.add("Checking dependencies", fixDependencies(ctx, cfg))
 	}
 
 	// 8. Check Tailscale connectivity (for Vault/Forge)
 	if cfg.TailscaleHost != "" {
-		fmt.Println("\n## 8. Checking Tailscale...")
-		result = fixTailscale(cfg)
-		results = append(results, result)
-		if result.Fixed {
-			fmt.Printf("   ✓ Fixed: %s\n", result.Message)
-		} else if result.Message != "" {
-			fmt.Printf("   %s\n", result.Message)
-		}
-	}
-
-	// Summary
-	fmt.Println("\n=== Fix Summary ===")
-	fixedCount := 0
-	for _, r := range results {
-		if r.Fixed {
-			fixedCount++
-		}
-	}
-
-	if fixedCount > 0 {
-		fmt.Printf("Fixed %d issue(s)\n", fixedCount)
-	} else {
-		fmt.Println("No issues needed fixing")
+		r.add("Checking Tailscale", fixTailscale(ctx, cfg))
 	}
 
 	// Verify with test
-	fmt.Println("\n## Running verification tests...")
-	time.Sleep(2 * time.Second) // Give time for fixes to take effect
+	if err := sleepCtx(ctx, 2*time.Second); err != nil { // Give time for fixes to take effect
+		return r, err
+	}
 
 	// Quick connectivity check
-	pid := device.GetProcessPID(cfg.ProcessPattern)
+	const verify = "Running verification tests"
+	pid := processPID(ctx, cfg)
 	if pid != "" {
-		fmt.Printf("   ✓ VM process running (PID: %s)\n", pid)
+		r.add(verify, FixResult{Issue: "verify_process", Verify: true, Status: report.StatusOK, Message: fmt.Sprintf("VM process running (PID: %s)", pid)})
 	} else {
-		fmt.Printf("   ⚠ VM process not running - may need 'sovereign start --%s'\n", cfg.Name)
+		r.add(verify, FixResult{Issue: "verify_process", Verify: true, Status: report.StatusWarn, Message: fmt.Sprintf("VM process not running - may need 'sovereign start --%s'", cfg.Name)})
 	}
 
 	if cfg.TailscaleHost != "" {
-		fqdn := GetTailscaleFQDN(cfg)
+		fqdn := GetTailscaleFQDN(ctx, cfg)
 		if fqdn != "" {
-			cmd := exec.Command("curl", "-sk", "-o", "/dev/null", "-w", "%{http_code}",
+			cmd := exec.CommandContext(ctx, "curl", "-sk", "-o", "/dev/null", "-w", "%{http_code}",
 				"--connect-timeout", "5", fmt.Sprintf("https://%s", fqdn))
 			output, _ := cmd.Output()
 			httpCode := strings.TrimSpace(string(output))
 			if httpCode == "200" {
-				fmt.Printf("   ✓ HTTPS connectivity: OK (%s)\n", fqdn)
+				r.add(verify, FixResult{Issue: "verify_https", Verify: true, Status: report.StatusOK, Message: fmt.Sprintf("HTTPS connectivity: OK (%s)", fqdn)})
 			} else {
-				fmt.Printf("   ⚠ HTTPS connectivity: HTTP %s\n", httpCode)
+				r.add(verify, FixResult{Issue: "verify_https", Verify: true, Status: report.StatusWarn, Message: fmt.Sprintf("HTTPS connectivity: HTTP %s", httpCode)})
 			}
 		}
 	}
 
-	fmt.Println("\n=== Auto-Fix Complete ===")
-	return nil
+	return r, nil
 }
 
 // fixBridge ensures the VM bridge network is properly configured
-func fixBridge() FixResult {
-	bridgeOut, _ := device.RunShellCommand("ip addr show vm_bridge 2>/dev/null")
+// TEAM_047: Bridge name and address come from IPAM instead of literals
+func fixBridge(ctx context.Context) FixResult {
+	p := &Plan{}
+	r := planBridge(ctx, p)
+	if err := p.Execute(ctx); err != nil {
+		return FixResult{Issue: "bridge", Status: report.StatusFail, Message: err.Error()}
+	}
+	return r
+}
+
+// planBridge adds whatever the bridge needs and returns the result to report
+// once the plan has run
+// TEAM_063: Split from fixBridge for fix --all --dry-run
+func planBridge(ctx context.Context, p *Plan) FixResult {
+	bridgeCIDR, err := BridgeCIDR(DefaultSubnet)
+	if err != nil {
+		return FixResult{Issue: "bridge", Status: report.StatusFail, Message: err.Error()}
+	}
+	bridgeOut, _ := device.RunShellCommand(ctx, device.Quote("ip", "addr", "show", BridgeName)+" 2>/dev/null")
 
 	if bridgeOut == "" {
 		// Bridge doesn't exist - create it
-		device.RunShellCommand("ip link add vm_bridge type bridge")
-		device.RunShellCommand("ip addr add 192.168.100.1/24 dev vm_bridge")
-		device.RunShellCommand("ip link set vm_bridge up")
-		return FixResult{Issue: "bridge", Fixed: true, Message: "Created vm_bridge with 192.168.100.1/24"}
+		p.Shell("", device.Quote("ip", "link", "add", BridgeName, "type", "bridge"))
+		p.Shell("", device.Quote("ip", "addr", "add", bridgeCIDR, "dev", BridgeName))
+		p.Shell("", device.Quote("ip", "link", "set", BridgeName, "up"))
+		return FixResult{Issue: "bridge", Status: report.StatusOK, Fixed: true, Message: fmt.Sprintf("Created %s with %s", BridgeName, bridgeCIDR)}
 	}
 
-	if !strings.Contains(bridgeOut, "192.168.100.1") {
+	if !strings.Contains(bridgeOut, "inet "+bridgeCIDR) {
 		// Bridge exists but wrong IP
-		device.RunShellCommand("ip addr add 192.168.100.1/24 dev vm_bridge 2>/dev/null")
-		return FixResult{Issue: "bridge", Fixed: true, Message: "Added IP 192.168.100.1/24 to vm_bridge"}
+		p.Cleanup(StepShell, device.Quote("ip", "addr", "add", bridgeCIDR, "dev", BridgeName)+" 2>/dev/null")
+		return FixResult{Issue: "bridge", Status: report.StatusOK, Fixed: true, Message: fmt.Sprintf("Added IP %s to %s", bridgeCIDR, BridgeName)}
 	}
 
 	if !strings.Contains(bridgeOut, "UP") {
-		device.RunShellCommand("ip link set vm_bridge up")
-		return FixResult{Issue: "bridge", Fixed: true, Message: "Brought vm_bridge UP"}
+		p.Shell("", device.Quote("ip", "link", "set", BridgeName, "up"))
+		return FixResult{Issue: "bridge", Status: report.StatusOK, Fixed: true, Message: fmt.Sprintf("Brought %s UP", BridgeName)}
 	}
 
-	return FixResult{Issue: "bridge", Fixed: false, Message: "✓ Bridge OK"}
+	return FixResult{Issue: "bridge", Status: report.StatusOK, Message: "Bridge OK"}
 }
 
 // fixProcessKillers disables Android's phantom process killer
-func fixProcessKillers() FixResult {
+func fixProcessKillers(ctx context.Context) FixResult {
+	p := &Plan{}
+	r := planProcessKillers(ctx, p)
+	if err := p.Execute(ctx); err != nil {
+		return FixResult{Issue: "process_killers", Status: report.StatusFail, Message: err.Error()}
+	}
+	return r
+}
+
+// planProcessKillers adds disabling the phantom process killer if needed
+func planProcessKillers(ctx context.Context, p *Plan) FixResult {
 	// Check current setting
-	out, _ := device.RunShellCommand("device_config get activity_manager max_phantom_processes 2>/dev/null")
+	out, _ := device.RunShellCommand(ctx, "device_config get activity_manager max_phantom_processes 2>/dev/null")
 
 	if strings.TrimSpace(out) != "2147483647" {
-		device.RunShellCommand("device_config set_sync_disabled_for_tests persistent")
-		device.RunShellCommand("device_config put activity_manager max_phantom_processes 2147483647")
-		device.RunShellCommand("settings put global settings_enable_monitor_phantom_procs false")
-		return FixResult{Issue: "process_killers", Fixed: true, Message: "Disabled phantom process killer"}
+		p.Cleanup(StepShell, "device_config set_sync_disabled_for_tests persistent") // Missing before Android 10
+		p.Shell("", "device_config put activity_manager max_phantom_processes 2147483647")
+		p.Shell("", "settings put global settings_enable_monitor_phantom_procs false")
+		return FixResult{Issue: "process_killers", Status: report.StatusOK, Fixed: true, Message: "Disabled phantom process killer"}
 	}
 
-	return FixResult{Issue: "process_killers", Fixed: false, Message: "✓ Process killers already disabled"}
+	return FixResult{Issue: "process_killers", Status: report.StatusOK, Message: "Process killers already disabled"}
 }
 
 // fixVMProcess checks if VM is running and restarts if dead
-func fixVMProcess(cfg *VMConfig) FixResult {
-	pid := device.GetProcessPID(cfg.ProcessPattern)
+func fixVMProcess(ctx context.Context, cfg *VMConfig) FixResult {
+	pid := processPID(ctx, cfg)
 
 	if pid != "" {
-		return FixResult{Issue: "vm_process", Fixed: false, Message: fmt.Sprintf("✓ VM running (PID: %s)", pid)}
+		return FixResult{Issue: "vm_process", Status: report.StatusOK, Message: fmt.Sprintf("VM running (PID: %s)", pid)}
+	}
+
+	// TEAM_052: Restarting a crash-looping VM just loops again - report why it died
+	if st, ok := supervisedStatus(ctx, cfg); ok && st.CrashLoop {
+		return FixResult{
+			Issue:   "vm_process",
+			Status:  report.StatusWarn,
+			Message: fmt.Sprintf("VM is crash-looping (last exit: %s) - see 'sovereign diagnose --%s', then 'sovereign start --%s'", st.LastExit, cfg.Name, cfg.Name),
+		}
 	}
 
 	// VM not running - check if there's a console.log with errors
 	consoleLog := fmt.Sprintf("%s/console.log", cfg.DevicePath)
-	errOut, _ := device.RunShellCommand(fmt.Sprintf("grep -iE '(FATAL|panic|Killed)' %s 2>/dev/null | tail -1", consoleLog))
+	errOut, _ := device.RunShellCommand(ctx, device.Quote("grep", "-iE", "(FATAL|panic|Killed)", consoleLog)+" 2>/dev/null | tail -1")
 
 	if strings.Contains(errOut, "password authentication failed") {
 		return FixResult{
 			Issue:   "vm_process",
-			Fixed:   false,
-			Message: "⚠ VM died with password auth error - restart SQL first, then this VM",
+			Status:  report.StatusWarn,
+			Message: "VM died with password auth error - restart SQL first, then this VM",
 		}
 	}
 
 	// Try to start the VM via daemon
 	daemonScript := "/data/sovereign/sovereign_start.sh"
-	exists, _ := device.RunShellCommand(fmt.Sprintf("[ -f %s ] && echo yes", daemonScript))
-	if strings.TrimSpace(exists) != "yes" {
+	if !device.FileExists(ctx, daemonScript) {
 		return FixResult{
 			Issue:   "vm_process",
-			Fixed:   false,
-			Message: "⚠ Daemon script not deployed - run 'sovereign deploy' first",
+			Status:  report.StatusWarn,
+			Message: "Daemon script not deployed - run 'sovereign deploy' first",
 		}
 	}
 
 	// Clean stale state and start
-	device.RunShellCommand(fmt.Sprintf("rm -f %s/vm.sock %s/vm.pid %s/console.log",
-		cfg.DevicePath, cfg.DevicePath, cfg.DevicePath))
+	device.Exec(ctx, "rm", "-f", cfg.DevicePath+"/vm.sock", cfg.DevicePath+"/vm.pid", cfg.DevicePath+"/console.log")
 
-	startCmd := fmt.Sprintf("%s start %s", daemonScript, cfg.Name)
-	device.RunShellCommand(startCmd)
+	device.Exec(ctx, daemonScript, "start", cfg.Name)
 
 	// Wait for startup
-	time.Sleep(5 * time.Second)
+	if err := sleepCtx(ctx, 5*time.Second); err != nil {
+		return FixResult{Issue: "vm_process", Status: report.StatusWarn, Message: fmt.Sprintf("Interrupted while waiting for VM: %v", err)}
+	}
 
 	// Verify
-	newPid := device.GetProcessPID(cfg.ProcessPattern)
+	newPid := processPID(ctx, cfg)
 	if newPid != "" {
-		return FixResult{Issue: "vm_process", Fixed: true, Message: fmt.Sprintf("Started VM (PID: %s)", newPid)}
+		return FixResult{Issue: "vm_process", Status: report.StatusOK, Fixed: true, Message: fmt.Sprintf("Started VM (PID: %s)", newPid)}
 	}
 
-	return FixResult{Issue: "vm_process", Fixed: false, Message: "⚠ Failed to start VM - check 'sovereign diagnose'"}
+	return FixResult{Issue: "vm_process", Status: report.StatusWarn, Message: "Failed to start VM - check 'sovereign diagnose'"}
 }
 
 // fixTAP ensures TAP interface is properly configured
-func fixTAP(cfg *VMConfig) FixResult {
-	tapOut, _ := device.RunShellCommand(fmt.Sprintf("ip link show %s 2>/dev/null", cfg.TAPInterface))
+func fixTAP(ctx context.Context, cfg *VMConfig) FixResult {
+	tapOut, _ := device.RunShellCommand(ctx, device.Quote("ip", "link", "show", cfg.TAPInterface)+" 2>/dev/null")
 
 	if tapOut == "" {
 		// TAP doesn't exist - will be created when VM starts
-		return FixResult{Issue: "tap", Fixed: false, Message: "TAP will be created when VM starts"}
+		return FixResult{Issue: "tap", Status: report.StatusInfo, Message: "TAP will be created when VM starts"}
 	}
 
 	if strings.Contains(tapOut, "NO-CARRIER") {
 		// TAP has no carrier - VM probably died, TAP needs recreation
 		// This will be fixed when VM restarts
-		return FixResult{Issue: "tap", Fixed: false, Message: "⚠ TAP shows NO-CARRIER - VM not connected"}
+		return FixResult{Issue: "tap", Status: report.StatusWarn, Message: "TAP shows NO-CARRIER - VM not connected"}
 	}
 
 	if !strings.Contains(tapOut, "UP") {
-		device.RunShellCommand(fmt.Sprintf("ip link set %s up", cfg.TAPInterface))
-		return FixResult{Issue: "tap", Fixed: true, Message: fmt.Sprintf("Brought %s UP", cfg.TAPInterface)}
+		device.Exec(ctx, "ip", "link", "set", cfg.TAPInterface, "up")
+		return FixResult{Issue: "tap", Status: report.StatusOK, Fixed: true, Message: fmt.Sprintf("Brought %s UP", cfg.TAPInterface)}
 	}
 
 	// Check if attached to bridge
 	if !strings.Contains(tapOut, "master vm_bridge") {
-		device.RunShellCommand(fmt.Sprintf("ip link set %s master vm_bridge", cfg.TAPInterface))
-		return FixResult{Issue: "tap", Fixed: true, Message: fmt.Sprintf("Attached %s to vm_bridge", cfg.TAPInterface)}
+		device.Exec(ctx, "ip", "link", "set", cfg.TAPInterface, "master", BridgeName)
+		return FixResult{Issue: "tap", Status: report.StatusOK, Fixed: true, Message: fmt.Sprintf("Attached %s to vm_bridge", cfg.TAPInterface)}
 	}
 
-	return FixResult{Issue: "tap", Fixed: false, Message: "✓ TAP OK"}
+	return FixResult{Issue: "tap", Status: report.StatusOK, Message: "TAP OK"}
 }
 
 // fixStaleState cleans up stale socket/pid files
-func fixStaleState(cfg *VMConfig) FixResult {
-	pid := device.GetProcessPID(cfg.ProcessPattern)
+func fixStaleState(ctx context.Context, cfg *VMConfig) FixResult {
+	pid := processPID(ctx, cfg)
 
 	// If VM is running, don't clean state
 	if pid != "" {
-		return FixResult{Issue: "stale_state", Fixed: false, Message: "✓ VM running, state OK"}
+		return FixResult{Issue: "stale_state", Status: report.StatusOK, Message: "VM running, state OK"}
 	}
 
 	// VM not running - check for stale files
-	sockExists, _ := device.RunShellCommand(fmt.Sprintf("[ -f %s/vm.sock ] && echo yes", cfg.DevicePath))
-	pidExists, _ := device.RunShellCommand(fmt.Sprintf("[ -f %s/vm.pid ] && echo yes", cfg.DevicePath))
-
-	if strings.TrimSpace(sockExists) == "yes" || strings.TrimSpace(pidExists) == "yes" {
-		device.RunShellCommand(fmt.Sprintf("rm -f %s/vm.sock %s/vm.pid", cfg.DevicePath, cfg.DevicePath))
-		return FixResult{Issue: "stale_state", Fixed: true, Message: "Removed stale socket/pid files"}
+	sock, pidFile := cfg.DevicePath+"/vm.sock", cfg.DevicePath+"/vm.pid"
+	if device.FileExists(ctx, sock) || device.FileExists(ctx, pidFile) {
+		device.Exec(ctx, "rm", "-f", sock, pidFile)
+		return FixResult{Issue: "stale_state", Status: report.StatusOK, Fixed: true, Message: "Removed stale socket/pid files"}
 	}
 
-	return FixResult{Issue: "stale_state", Fixed: false, Message: "✓ No stale state"}
+	return FixResult{Issue: "stale_state", Status: report.StatusOK, Message: "No stale state"}
 }
 
 // fixDependencies checks if required services are running
-func fixDependencies(cfg *VMConfig) FixResult {
+func fixDependencies(ctx context.Context, cfg *VMConfig) FixResult {
 	for _, dep := range cfg.Dependencies {
 		// Check if dependency is reachable via TAP IP
-		testCmd := fmt.Sprintf("timeout 2 nc -z %s %d 2>/dev/null && echo OK || echo FAIL",
-			dep.TAPIP, dep.Port)
-		out, _ := device.RunShellCommand(testCmd)
+		tapIP := ResolveDependencyIP(dep)
+		if tapIP == "" {
+			return FixResult{
+				Issue:   "dependencies",
+				Status:  report.StatusWarn,
+				Message: fmt.Sprintf("%s has no TAP address yet - start %s first", dep.Name, dep.Name),
+			}
+		}
+		testCmd := device.Quote("timeout", "2", "nc", "-z", tapIP, strconv.Itoa(dep.Port)) + " 2>/dev/null && echo OK || echo FAIL"
+		out, _ := device.RunShellCommand(ctx, testCmd)
 
 		if strings.TrimSpace(out) != "OK" {
 			return FixResult{
-				Issue: "dependencies",
-				Fixed: false,
-				Message: fmt.Sprintf("⚠ %s not reachable at %s:%d - start SQL VM first",
-					dep.Name, dep.TAPIP, dep.Port),
+				Issue:  "dependencies",
+				Status: report.StatusWarn,
+				Message: fmt.Sprintf("%s not reachable at %s:%d - start %s first",
+					dep.Name, tapIP, dep.Port, dep.Name),
 			}
 		}
 	}
 
-	return FixResult{Issue: "dependencies", Fixed: false, Message: "✓ Dependencies OK"}
+	return FixResult{Issue: "dependencies", Status: report.StatusOK, Message: "Dependencies OK"}
 }
 
 // fixTailscale checks Tailscale connectivity
-func fixTailscale(cfg *VMConfig) FixResult {
-	tsOut, err := exec.Command("tailscale", "status").Output()
+func fixTailscale(ctx context.Context, cfg *VMConfig) FixResult {
+	tsOut, err := exec.CommandContext(ctx, "tailscale", "status").Output()
 	if err != nil {
-		return FixResult{Issue: "tailscale", Fixed: false, Message: "⚠ Cannot get tailscale status on host"}
+		return FixResult{Issue: "tailscale", Status: report.StatusWarn, Message: "Cannot get tailscale status on host"}
 	}
 
 	lines := strings.Split(string(tsOut), "\n")
@@ -327,59 +311,66 @@ func fixTailscale(cfg *VMConfig) FixResult {
 			if strings.Contains(line, "offline") {
 				return FixResult{
 					Issue:   "tailscale",
-					Fixed:   false,
-					Message: "⚠ VM registered but offline - restart VM",
+					Status:  report.StatusWarn,
+					Message: "VM registered but offline - restart VM",
 				}
 			}
-			return FixResult{Issue: "tailscale", Fixed: false, Message: "✓ Tailscale connected"}
+			return FixResult{Issue: "tailscale", Status: report.StatusOK, Message: "Tailscale connected"}
 		}
 	}
 
 	return FixResult{
 		Issue:   "tailscale",
-		Fixed:   false,
-		Message: "⚠ VM not registered with Tailscale - check auth key in .env",
+		Status:  report.StatusWarn,
+		Message: "VM not registered with Tailscale - check auth key in .env",
 	}
 }
 
 // FixAll attempts to fix common infrastructure issues
-func FixAll() error {
-	fmt.Println("=== Auto-Fix All Infrastructure ===")
-	fmt.Println()
+// TEAM_045: Returns a FixReport; infrastructure steps are reported as fixed actions
+func FixAll(ctx context.Context) (*FixReport, error) {
+	r := &FixReport{VM: "all", DisplayName: "All Infrastructure"}
 
-	if !device.IsConnected() {
-		return fmt.Errorf("device not connected")
+	if !device.IsConnected(ctx) {
+		return r, fmt.Errorf("device not connected")
 	}
 
+	// TEAM_063: Planned first so --dry-run lists every command and rule
+	p := &Plan{Title: "Fix infrastructure"}
+
 	// Fix bridge
-	fmt.Println("## Fixing bridge network...")
-	fixBridge()
+	bridge := planBridge(ctx, p)
 
 	// Fix process killers
-	fmt.Println("## Disabling process killers...")
-	fixProcessKillers()
+	killers := planProcessKillers(ctx, p)
 
 	// Enable IP forwarding
-	fmt.Println("## Enabling IP forwarding...")
-	device.RunShellCommand("echo 1 > /proc/sys/net/ipv4/ip_forward")
+	p.Shell("", "echo 1 > /proc/sys/net/ipv4/ip_forward")
 
 	// Fix routing
-	fmt.Println("## Fixing routing...")
-	device.RunShellCommand("ip rule del from all lookup main pref 1 2>/dev/null; ip rule add from all lookup main pref 1")
+	p.Cleanup(StepShell, "ip rule del from all lookup main pref 1 2>/dev/null")
+	p.Shell("", "ip rule add from all lookup main pref 1")
 
 	// Fix NAT
-	fmt.Println("## Setting up NAT...")
-	device.RunShellCommand("iptables -t nat -D POSTROUTING -s 192.168.100.0/24 -o wlan0 -j MASQUERADE 2>/dev/null")
-	device.RunShellCommand("iptables -t nat -A POSTROUTING -s 192.168.100.0/24 -o wlan0 -j MASQUERADE")
+	p.Cleanup(StepIptables, device.Quote("iptables", "-t", "nat", "-D", "POSTROUTING", "-s", DefaultSubnet, "-o", "wlan0", "-j", "MASQUERADE")+" 2>/dev/null")
+	p.Add(Step{Kind: StepIptables, Cmd: device.Quote("iptables", "-t", "nat", "-A", "POSTROUTING", "-s", DefaultSubnet, "-o", "wlan0", "-j", "MASQUERADE")})
 
 	// Fix forwarding rules
-	fmt.Println("## Setting up forwarding...")
-	device.RunShellCommand("iptables -D FORWARD -i vm_bridge -o wlan0 -j ACCEPT 2>/dev/null")
-	device.RunShellCommand("iptables -I FORWARD 1 -i vm_bridge -o wlan0 -j ACCEPT")
-	device.RunShellCommand("iptables -D FORWARD -i wlan0 -o vm_bridge -m state --state RELATED,ESTABLISHED -j ACCEPT 2>/dev/null")
-	device.RunShellCommand("iptables -I FORWARD 2 -i wlan0 -o vm_bridge -m state --state RELATED,ESTABLISHED -j ACCEPT")
-
-	fmt.Println("\n=== Infrastructure Fix Complete ===")
-	fmt.Println("Run 'sovereign fix --sql' then 'sovereign fix --vault' to fix individual VMs")
-	return nil
+	p.Cleanup(StepIptables, device.Quote("iptables", "-D", "FORWARD", "-i", BridgeName, "-o", "wlan0", "-j", "ACCEPT")+" 2>/dev/null")
+	p.Add(Step{Kind: StepIptables, Cmd: device.Quote("iptables", "-I", "FORWARD", "1", "-i", BridgeName, "-o", "wlan0", "-j", "ACCEPT")})
+	p.Cleanup(StepIptables, device.Quote("iptables", "-D", "FORWARD", "-i", "wlan0", "-o", BridgeName, "-m", "state", "--state", "RELATED,ESTABLISHED", "-j", "ACCEPT")+" 2>/dev/null")
+	p.Add(Step{Kind: StepIptables, Cmd: device.Quote("iptables", "-I", "FORWARD", "2", "-i", "wlan0", "-o", BridgeName, "-m", "state", "--state", "RELATED,ESTABLISHED", "-j", "ACCEPT")})
+
+	if ran, err := runPlan(ctx, p); !ran || err != nil {
+		return r, err
+	}
+
+	r.add("Fixing bridge network", bridge)
+	r.add("Disabling process killers", killers)
+	r.add("Enabling IP forwarding", FixResult{Issue: "ip_forward", Status: report.StatusOK, Message: "net.ipv4.ip_forward=1"})
+	r.add("Fixing routing", FixResult{Issue: "routing", Status: report.StatusOK, Message: "main table at pref 1"})
+	r.add("Setting up NAT", FixResult{Issue: "nat", Status: report.StatusOK, Message: fmt.Sprintf("MASQUERADE %s via wlan0", DefaultSubnet)})
+	r.add("Setting up forwarding", FixResult{Issue: "forwarding", Status: report.StatusOK, Message: BridgeName + " <-> wlan0"})
+
+	return r, nil
 }
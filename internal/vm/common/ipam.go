@@ -0,0 +1,522 @@
+// IP address management for the shared VM bridge
+// TEAM_047: Guest IPs used to be hand-assigned in comments and the bridge
+// address duplicated across Go and shell. Everything now derives from the
+// subnet, and dynamic assignments are persisted as leases.
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/sovereign/internal/device"
+)
+
+// Bridge defaults - every VM shares one L2 bridge on the device
+const (
+	BridgeName    = "vm_bridge"
+	DefaultSubnet = "192.168.100.0/24"
+)
+
+// LeaseFile overrides where dynamic guest IP assignments are persisted.
+// Empty (the default) keeps one table per device next to the state file,
+// ipam/<device>.json, since each phone has its own bridge.
+var LeaseFile string
+
+// legacyLeaseFile is where leases were kept, relative to sovereign-vault/,
+// before they moved next to the state file; read once to keep addresses
+const legacyLeaseFile = ".ipam.json"
+
+// NetworkEnvDevice is sourced by sovereign_start.sh for bridge and guest IPs
+const NetworkEnvDevice = "/data/sovereign/network.env"
+
+// Lease is one guest IP assignment
+type Lease struct {
+	VM       string    `json:"vm"`
+	IP       string    `json:"ip"`
+	Static   bool      `json:"static"` // Pinned by VMConfig.TAPGuestIP
+	Assigned time.Time `json:"assigned"`
+}
+
+// Leases is the persisted lease table for one subnet
+type Leases struct {
+	Subnet string           `json:"subnet"`
+	Leases map[string]Lease `json:"leases"` // by VM name
+}
+
+var (
+	// configs holds every VMConfig registered through vm.Register
+	configs   = map[string]*VMConfig{}
+	configsMu sync.RWMutex
+
+	// leaseMu serializes lease file updates within this process; lockLeases
+	// adds a flock for other processes
+	leaseMu sync.Mutex
+)
+
+// RegisterConfig records a VM's config for collision detection and
+// dependency IP resolution. Called by vm.Register.
+func RegisterConfig(cfg *VMConfig) {
+	configsMu.Lock()
+	defer configsMu.Unlock()
+	cfg.pin()
+	configs[cfg.Name] = cfg
+}
+
+// pin returns the configured guest IP ("" for a lease), capturing it on first use
+func (cfg *VMConfig) pin() string {
+	if !cfg.pinCaptured {
+		cfg.pinnedIP = cfg.TAPGuestIP
+		cfg.pinCaptured = true
+	}
+	return cfg.pinnedIP
+}
+
+// RegisteredConfigs returns all registered configs sorted by name
+func RegisteredConfigs() []*VMConfig {
+	configsMu.RLock()
+	defer configsMu.RUnlock()
+	out := make([]*VMConfig, 0, len(configs))
+	for _, cfg := range configs {
+		out = append(out, cfg)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// subnetOf returns the VM's subnet, defaulting to DefaultSubnet
+func subnetOf(cfg *VMConfig) string {
+	if cfg.TAPSubnet != "" {
+		return cfg.TAPSubnet
+	}
+	return DefaultSubnet
+}
+
+// BridgeIP returns the gateway address (first host) of subnet
+func BridgeIP(subnet string) (string, error) {
+	_, n, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return "", fmt.Errorf("invalid subnet %q: %w", subnet, err)
+	}
+	ip := n.IP.To4()
+	if ip == nil {
+		return "", fmt.Errorf("subnet %s is not IPv4", subnet)
+	}
+	gw := make(net.IP, 4)
+	copy(gw, ip)
+	gw[3]++
+	return gw.String(), nil
+}
+
+// BridgeCIDR returns the gateway with prefix length, e.g. "192.168.100.1/24"
+func BridgeCIDR(subnet string) (string, error) {
+	gw, err := BridgeIP(subnet)
+	if err != nil {
+		return "", err
+	}
+	_, n, _ := net.ParseCIDR(subnet)
+	ones, _ := n.Mask.Size()
+	return fmt.Sprintf("%s/%d", gw, ones), nil
+}
+
+// BridgeSubnet returns the subnet of the shared bridge: the registered VMs'
+// TAPSubnet, or DefaultSubnet. One bridge carries one subnet, so VMs that
+// disagree are an error.
+func BridgeSubnet() (string, error) {
+	subnet, owner := "", ""
+	for _, cfg := range RegisteredConfigs() {
+		if cfg.TAPSubnet == "" {
+			continue
+		}
+		if subnet != "" && cfg.TAPSubnet != subnet {
+			return "", fmt.Errorf("%s uses subnet %s but %s uses %s - all VMs share %s", owner, subnet, cfg.Name, cfg.TAPSubnet, BridgeName)
+		}
+		subnet, owner = cfg.TAPSubnet, cfg.Name
+	}
+	if subnet == "" {
+		subnet = DefaultSubnet
+	}
+	return subnet, nil
+}
+
+// DefaultBridgeIP is BridgeIP(DefaultSubnet)
+func DefaultBridgeIP() string {
+	gw, _ := BridgeIP(DefaultSubnet)
+	return gw
+}
+
+// CheckIPCollisions reports configs that pin the same guest IP or TAP
+// interface, use the gateway as guest IP, or sit outside their subnet
+func CheckIPCollisions(cfgs []*VMConfig) error {
+	ips := map[string]string{}
+	taps := map[string]string{}
+	for _, cfg := range cfgs {
+		if other, ok := taps[cfg.TAPInterface]; ok && cfg.TAPInterface != "" {
+			return fmt.Errorf("%s and %s both use TAP interface %s", other, cfg.Name, cfg.TAPInterface)
+		}
+		taps[cfg.TAPInterface] = cfg.Name
+
+		ip := cfg.pin()
+		if ip == "" {
+			continue // assigned dynamically
+		}
+		if err := checkInSubnet(cfg, ip); err != nil {
+			return err
+		}
+		if other, ok := ips[ip]; ok {
+			return fmt.Errorf("%s and %s both use guest IP %s", other, cfg.Name, ip)
+		}
+		ips[ip] = cfg.Name
+	}
+	return nil
+}
+
+// checkInSubnet validates ip as a guest address for cfg
+func checkInSubnet(cfg *VMConfig, ip string) error {
+	subnet := subnetOf(cfg)
+	_, n, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return fmt.Errorf("%s: invalid subnet %q: %w", cfg.Name, subnet, err)
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil || !n.Contains(parsed) {
+		return fmt.Errorf("%s: guest IP %s is outside %s", cfg.Name, ip, subnet)
+	}
+	gw, _ := BridgeIP(subnet)
+	if ip == gw {
+		return fmt.Errorf("%s: guest IP %s is the bridge gateway", cfg.Name, ip)
+	}
+	p4 := parsed.To4()
+	if p4 != nil && p4.Equal(broadcast(n)) {
+		return fmt.Errorf("%s: guest IP %s is the broadcast address", cfg.Name, ip)
+	}
+	return nil
+}
+
+func broadcast(n *net.IPNet) net.IP {
+	ip := n.IP.To4()
+	b := make(net.IP, 4)
+	for i := range b {
+		b[i] = ip[i] | ^n.Mask[i]
+	}
+	return b
+}
+
+// leasePath resolves LeaseFile for the selected device
+func leasePath() (string, error) {
+	if LeaseFile != "" {
+		return LeaseFile, nil
+	}
+	state, err := statePath()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(filepath.Dir(state), "ipam")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, safeFileName(device.TargetID())+".json"), nil
+}
+
+// lockLeases takes the lease table's lock for a read-modify-write. Two CLI
+// runs (start --forge and start --vault hold different VM locks) would
+// otherwise both pick the same free address.
+// TEAM_047: Flock like UpdateState; leaseMu covers goroutines of one run
+func lockLeases() (path string, unlock func(), err error) {
+	leaseMu.Lock()
+	path, err = leasePath()
+	if err != nil {
+		leaseMu.Unlock()
+		return "", nil, err
+	}
+	lock, err := lockFile(path+".lock", stateLockTimeout)
+	if err != nil {
+		leaseMu.Unlock()
+		return "", nil, err
+	}
+	return path, func() {
+		unlockFile(lock)
+		leaseMu.Unlock()
+	}, nil
+}
+
+// readLeases reads the lease table at path. A missing file falls back to
+// the legacy working-directory table, then to an empty one.
+func readLeases(path string) (*Leases, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && LeaseFile == "" {
+		data, err = os.ReadFile(legacyLeaseFile)
+	}
+	if os.IsNotExist(err) {
+		return &Leases{Leases: map[string]Lease{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var l Leases
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("corrupt lease file %s: %w", path, err)
+	}
+	if l.Leases == nil {
+		l.Leases = map[string]Lease{}
+	}
+	return &l, nil
+}
+
+// loadLeases reads the lease table for subnet, returning an empty table if
+// there is none yet. Caller holds lockLeases.
+func loadLeases(path, subnet string) (*Leases, error) {
+	l, err := readLeases(path)
+	if err != nil {
+		return nil, err
+	}
+	if l.Subnet == "" {
+		l.Subnet = subnet
+	}
+	if l.Subnet != subnet {
+		return nil, fmt.Errorf("lease file %s is for %s, not %s - remove it to re-assign addresses", path, l.Subnet, subnet)
+	}
+	return l, nil
+}
+
+// save writes the lease table atomically. Caller holds lockLeases.
+func (l *Leases) save(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// holder returns the VM holding ip, if any
+func (l *Leases) holder(ip string) (string, bool) {
+	for name, lease := range l.Leases {
+		if lease.IP == ip {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// LeasedIP returns the persisted lease for a VM, if any
+func LeasedIP(name string) string {
+	path, unlock, err := lockLeases()
+	if err != nil {
+		return ""
+	}
+	defer unlock()
+	l, err := readLeases(path)
+	if err != nil {
+		return ""
+	}
+	return l.Leases[name].IP
+}
+
+// AssignGuestIP ensures cfg has a guest IP. A pinned TAPGuestIP is recorded
+// as a static lease; otherwise the existing lease is reused or the lowest
+// free address in the subnet is allocated. Also fills TAPHostIP/TAPSubnet.
+func AssignGuestIP(cfg *VMConfig) (string, error) {
+	subnet := subnetOf(cfg)
+	gw, err := BridgeIP(subnet)
+	if err != nil {
+		return "", err
+	}
+	if cfg.TAPHostIP != "" && cfg.TAPHostIP != gw {
+		return "", fmt.Errorf("%s: TAPHostIP %s is not the bridge gateway %s", cfg.Name, cfg.TAPHostIP, gw)
+	}
+
+	path, unlock, err := lockLeases()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	leases, err := loadLeases(path, subnet)
+	if err != nil {
+		return "", err
+	}
+
+	// Addresses pinned by other registered configs are never handed out
+	pinned := map[string]string{}
+	for _, other := range RegisteredConfigs() {
+		if other.Name != cfg.Name && other.pin() != "" {
+			pinned[other.pin()] = other.Name
+		}
+	}
+
+	ip := cfg.pin()
+	static := ip != ""
+	switch {
+	case static:
+		if err := checkInSubnet(cfg, ip); err != nil {
+			return "", err
+		}
+		if other, ok := pinned[ip]; ok {
+			return "", fmt.Errorf("%s: guest IP %s is pinned by %s", cfg.Name, ip, other)
+		}
+		if other, ok := leases.holder(ip); ok && other != cfg.Name {
+			return "", fmt.Errorf("%s: guest IP %s is leased to %s (edit %s to release it)", cfg.Name, ip, other, path)
+		}
+	case leases.Leases[cfg.Name].IP != "":
+		ip = leases.Leases[cfg.Name].IP
+		if other, ok := pinned[ip]; ok {
+			return "", fmt.Errorf("%s: leased IP %s is now pinned by %s - remove the lease from %s", cfg.Name, ip, other, path)
+		}
+	default:
+		ip, err = nextFree(cfg, leases, pinned)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	prev := leases.Leases[cfg.Name]
+	if prev.IP != ip || prev.Static != static {
+		leases.Leases[cfg.Name] = Lease{VM: cfg.Name, IP: ip, Static: static, Assigned: time.Now().UTC()}
+		if err := leases.save(path); err != nil {
+			return "", fmt.Errorf("saving lease: %w", err)
+		}
+	}
+
+	cfg.TAPGuestIP = ip
+	cfg.TAPHostIP = gw
+	cfg.TAPSubnet = subnet
+	return ip, nil
+}
+
+// nextFree returns the lowest host address not used by the gateway, a lease or a pin
+func nextFree(cfg *VMConfig, leases *Leases, pinned map[string]string) (string, error) {
+	_, n, _ := net.ParseCIDR(subnetOf(cfg))
+	bcast := broadcast(n)
+	ip := n.IP.To4()
+	cur := make(net.IP, 4)
+	copy(cur, ip)
+	cur[3] += 2 // skip network address and gateway
+	for n.Contains(cur) && !cur.Equal(bcast) {
+		s := cur.String()
+		_, leased := leases.holder(s)
+		_, isPinned := pinned[s]
+		if !leased && !isPinned {
+			return s, nil
+		}
+		cur = nextIP(cur)
+	}
+	return "", fmt.Errorf("%s: no free addresses left in %s", cfg.Name, n)
+}
+
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+// ReleaseGuestIP drops a VM's dynamic lease (static pins are kept in config)
+func ReleaseGuestIP(name string) error {
+	path, unlock, err := lockLeases()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	l, err := readLeases(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := l.Leases[name]; !ok {
+		return nil
+	}
+	delete(l.Leases, name)
+	return l.save(path)
+}
+
+// ResolveDependencyIP returns the TAP address of a dependency: explicit
+// TAPIP, else the registered config's pinned IP, else its lease
+func ResolveDependencyIP(dep ServiceDependency) string {
+	if dep.TAPIP != "" {
+		return dep.TAPIP
+	}
+	configsMu.RLock()
+	cfg, ok := configs[dep.Name]
+	configsMu.RUnlock()
+	if ok && cfg.TAPGuestIP != "" {
+		return cfg.TAPGuestIP
+	}
+	return LeasedIP(dep.Name)
+}
+
+// KernelNetParams returns the kernel cmdline parameters init.sh uses to
+// configure the guest interface, e.g. "sovereign.ip=192.168.100.2/24 sovereign.gw=192.168.100.1"
+func KernelNetParams(cfg *VMConfig) (string, error) {
+	_, n, err := net.ParseCIDR(subnetOf(cfg))
+	if err != nil {
+		return "", err
+	}
+	ones, _ := n.Mask.Size()
+	gw, _ := BridgeIP(subnetOf(cfg))
+	return fmt.Sprintf("sovereign.ip=%s/%d sovereign.gw=%s", cfg.TAPGuestIP, ones, gw), nil
+}
+
+// PushNetworkEnv writes network.env on the device so sovereign_start.sh
+// (including boot-time daemon mode) uses the same addresses as the CLI.
+// Only VMs with a known IP are listed; IP_<name> holds each guest address.
+func PushNetworkEnv(ctx context.Context) error {
+	subnet, err := BridgeSubnet()
+	if err != nil {
+		return err
+	}
+	bridge, err := BridgeCIDR(subnet)
+	if err != nil {
+		return err
+	}
+	gw, _ := BridgeIP(subnet)
+	var b strings.Builder
+	b.WriteString("# Generated by sovereign - do not edit (leases are kept on the host)\n")
+	fmt.Fprintf(&b, "BRIDGE_NAME=%s\n", BridgeName)
+	fmt.Fprintf(&b, "BRIDGE_CIDR=%s\n", bridge)
+	fmt.Fprintf(&b, "BRIDGE_IP=%s\n", gw)
+	fmt.Fprintf(&b, "VM_SUBNET=%s\n", subnet)
+	for _, cfg := range RegisteredConfigs() {
+		ip := cfg.TAPGuestIP
+		if ip == "" {
+			ip = LeasedIP(cfg.Name)
+		}
+		if ip == "" {
+			continue
+		}
+		_, n, err := net.ParseCIDR(subnetOf(cfg))
+		if err != nil {
+			continue
+		}
+		ones, _ := n.Mask.Size()
+		key := strings.ReplaceAll(cfg.Name, "-", "_")
+		fmt.Fprintf(&b, "IP_%s=%s\n", key, ip)
+		fmt.Fprintf(&b, "CIDR_%s=%s/%d\n", key, ip, ones)
+	}
+
+	tmp, err := os.CreateTemp("", "network-*.env")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+	return device.PushFile(ctx, tmp.Name(), NetworkEnvDevice)
+}
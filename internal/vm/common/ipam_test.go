@@ -0,0 +1,167 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/sovereign/internal/device"
+)
+
+// withLeases points LeaseFile at a temp file and registers cfgs for the test
+func withLeases(t *testing.T, cfgs ...*VMConfig) {
+	t.Helper()
+	old := LeaseFile
+	LeaseFile = filepath.Join(t.TempDir(), ".ipam.json")
+	for _, cfg := range cfgs {
+		RegisterConfig(cfg)
+	}
+	t.Cleanup(func() {
+		LeaseFile = old
+		configsMu.Lock()
+		for _, cfg := range cfgs {
+			delete(configs, cfg.Name)
+		}
+		configsMu.Unlock()
+	})
+}
+
+func lease(t *testing.T, name string) Lease {
+	t.Helper()
+	l, err := loadLeases(LeaseFile, DefaultSubnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l.Leases[name]
+}
+
+// TEAM_047: AssignGuestIP writes the lease into TAPGuestIP; deploy then start
+// in one process used to re-record it as a static pin
+func TestAssignGuestIPKeepsLeaseDynamic(t *testing.T) {
+	pinned := &VMConfig{Name: "ipam-pinned", TAPInterface: "vm_p", TAPGuestIP: "192.168.100.2"}
+	dynamic := &VMConfig{Name: "ipam-dynamic", TAPInterface: "vm_d"}
+	other := &VMConfig{Name: "ipam-other", TAPInterface: "vm_o"}
+	withLeases(t, pinned, dynamic, other)
+
+	for i := 0; i < 2; i++ {
+		ip, err := AssignGuestIP(dynamic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ip != "192.168.100.3" {
+			t.Fatalf("call %d: got %s, want the lowest free address 192.168.100.3", i+1, ip)
+		}
+		if l := lease(t, dynamic.Name); l.Static {
+			t.Fatalf("call %d: lease %+v recorded as static", i+1, l)
+		}
+	}
+	if ip, err := AssignGuestIP(pinned); err != nil || ip != "192.168.100.2" || !lease(t, pinned.Name).Static {
+		t.Fatalf("pinned: got %s, %v, lease %+v", ip, err, lease(t, pinned.Name))
+	}
+
+	// A second dynamic VM must not mistake the first one's lease for a pin
+	if ip, err := AssignGuestIP(other); err != nil || ip != "192.168.100.4" {
+		t.Fatalf("other: got %s, %v, want 192.168.100.4", ip, err)
+	}
+}
+
+func TestBridgeSubnet(t *testing.T) {
+	a := &VMConfig{Name: "subnet-a", TAPInterface: "vm_a", TAPSubnet: "10.0.5.0/24"}
+	withLeases(t, a)
+	if subnet, err := BridgeSubnet(); err != nil || subnet != "10.0.5.0/24" {
+		t.Fatalf("got %s, %v, want the VMs' subnet", subnet, err)
+	}
+
+	b := &VMConfig{Name: "subnet-b", TAPInterface: "vm_b", TAPSubnet: "10.0.6.0/24"}
+	RegisterConfig(b)
+	defer func() {
+		configsMu.Lock()
+		delete(configs, b.Name)
+		configsMu.Unlock()
+	}()
+	if _, err := BridgeSubnet(); err == nil {
+		t.Fatal("two subnets on one bridge accepted")
+	}
+}
+
+// Run by TestAssignGuestIPAcrossProcesses in a child process
+func TestAssignGuestIPChild(t *testing.T) {
+	name := os.Getenv("SOVEREIGN_IPAM_CHILD")
+	if name == "" {
+		t.Skip("helper for TestAssignGuestIPAcrossProcesses")
+	}
+	LeaseFile = os.Getenv("SOVEREIGN_IPAM_FILE")
+	ip, err := AssignGuestIP(&VMConfig{Name: name, TAPInterface: "vm_" + name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Printf("IP=%s\n", ip)
+}
+
+// Separate CLI runs (start --forge, start --vault) share the lease file
+func TestAssignGuestIPAcrossProcesses(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "leases.json")
+	const n = 8
+	ips := make([]string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestAssignGuestIPChild$")
+			cmd.Env = append(os.Environ(), fmt.Sprintf("SOVEREIGN_IPAM_CHILD=vm%d", i), "SOVEREIGN_IPAM_FILE="+file)
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Errorf("child %d: %v\n%s", i, err, out)
+				return
+			}
+			for _, line := range strings.Split(string(out), "\n") {
+				if ip, ok := strings.CutPrefix(line, "IP="); ok {
+					ips[i] = ip
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[string]int{}
+	for i, ip := range ips {
+		if j, dup := seen[ip]; dup {
+			t.Errorf("vm%d and vm%d both got %s", j, i, ip)
+		}
+		seen[ip] = i
+	}
+	old := LeaseFile
+	LeaseFile = file
+	defer func() { LeaseFile = old }()
+	l, err := loadLeases(file, DefaultSubnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Leases) != n {
+		t.Errorf("%d leases saved, want %d (a write was lost)", len(l.Leases), n)
+	}
+}
+
+func TestLeasesPerDevice(t *testing.T) {
+	withHostState(t)
+	LeaseFile = "" // Default location, under the temp state dir
+	old := device.CurrentTransport()
+	defer device.SetTransport(old)
+
+	device.SetTransport(device.NewFakeTransport())
+	if ip, err := AssignGuestIP(&VMConfig{Name: "phone-a-vm", TAPInterface: "vm_a"}); err != nil || ip != "192.168.100.2" {
+		t.Fatalf("first device: %s, %v", ip, err)
+	}
+	device.SetTransport(device.NewSSHTransport("root@pixel:8022"))
+	if ip, err := AssignGuestIP(&VMConfig{Name: "phone-b-vm", TAPInterface: "vm_b"}); err != nil || ip != "192.168.100.2" {
+		t.Fatalf("second device: %s, %v - leases leaked between devices", ip, err)
+	}
+	if LeasedIP("phone-a-vm") != "" {
+		t.Error("first device's lease visible on the second")
+	}
+}
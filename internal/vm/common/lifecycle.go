@@ -4,13 +4,13 @@
 package common
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/supervisor"
 )
 
 // StopVM stops a running VM and cleans up networking.
@@ -19,100 +19,163 @@ import (
 // TEAM_037: Also kills watchdog daemon to fully clean up
 // CleanVM removes Tailscale registrations for a VM.
 // TEAM_039: Added for Tailscale cleanup via CLI
-func CleanVM(cfg *VMConfig) error {
+func CleanVM(ctx context.Context, cfg *VMConfig) error {
 	fmt.Printf("=== Cleaning %s Tailscale registrations ===\n", cfg.DisplayName)
-	return RemoveTailscaleRegistrations(cfg.TailscaleHost)
+
+	// TEAM_065: One mutating operation per VM and device at a time
+	unlock, err := lockVM(ctx, cfg, "clean")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return RemoveTailscaleRegistrations(ctx, cfg.TailscaleHost)
 }
 
-func StopVM(cfg *VMConfig) error {
+// TEAM_049: Shutdown goes through ShutdownVM (power button first) and reports the path taken
+func StopVM(ctx context.Context, cfg *VMConfig) error {
 	fmt.Printf("=== Stopping %s VM ===\n", cfg.DisplayName)
 
-	// Get PID with timeout protection (already in RunShellCommand)
-	pid := device.GetProcessPID(cfg.ProcessPattern)
+	// TEAM_065: One mutating operation per VM and device at a time
+	unlock, err := lockVM(ctx, cfg, "stop")
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	if pid != "" {
-		fmt.Printf("Stopping VM (PID: %s)...\n", pid)
-		// Try graceful kill first
-		if err := device.KillProcess(pid); err != nil {
-			// Force kill if graceful fails
-			device.RunShellCommand(fmt.Sprintf("kill -9 %s 2>/dev/null", pid))
-		}
-		// Brief wait for process to die
-		time.Sleep(500 * time.Millisecond)
-		// Verify it's dead, force kill if not
-		if device.GetProcessPID(cfg.ProcessPattern) != "" {
-			fmt.Println("Process still alive, force killing...")
-			device.RunShellCommand(fmt.Sprintf("kill -9 %s 2>/dev/null", pid))
-		}
+	p := &Plan{Title: fmt.Sprintf("Stop %s VM", cfg.DisplayName)}
+	stop := planStop(ctx, p, cfg)
+	if ran, err := runPlan(ctx, p); !ran || err != nil {
+		return err
+	}
+	if err := stop.report(cfg); err != nil {
+		return err
+	}
+	recordVM(cfg, func(r *VMRecord) { r.StoppedAt = time.Now().UTC() }) // TEAM_064
+	return nil
+}
+
+// stopOutcome is filled in when a stop plan's shutdown step runs
+type stopOutcome struct {
+	result *StopResult
+	err    error
+}
+
+// report prints how the VM stopped, returning the shutdown error
+func (o *stopOutcome) report(cfg *VMConfig) error {
+	if o.err != nil {
+		return fmt.Errorf("stopping %s: %w", cfg.DisplayName, o.err)
+	}
+	if o.result == nil {
+		return nil
+	}
+	if o.result.Method.Clean() {
+		fmt.Printf("✓ VM stopped (%s)\n", o.result)
 	} else {
-		fmt.Println("VM not running")
+		fmt.Printf("⚠ VM stopped (%s)\n", o.result)
+	}
+	return nil
+}
+
+// planStop adds shutting cfg down and cleaning up after it. A failed
+// shutdown doesn't stop the cleanup; it is reported by the outcome.
+// TEAM_063: Split from StopVM so remove plans include it
+func planStop(ctx context.Context, p *Plan, cfg *VMConfig) *stopOutcome {
+	o := &stopOutcome{}
+	desc := "VM not running, nothing to shut down"
+	if pid := processPID(ctx, cfg); pid != "" {
+		desc = fmt.Sprintf("Shutting down crosvm PID %s (power button, then crosvm stop, SIGTERM, SIGKILL)...", pid)
 	}
+	p.Do(StepShell, desc, false, func(ctx context.Context) error {
+		o.result, o.err = ShutdownVM(ctx, cfg)
+		return nil
+	})
 
 	// TEAM_037: Kill watchdog daemon for this VM if running
 	// The watchdog is a background sovereign_start.sh process monitoring this VM
 	daemonPattern := fmt.Sprintf("[s]overeign_start.sh.*%s", cfg.Name)
-	daemonPid, _ := device.RunShellCommand(fmt.Sprintf("pgrep -f '%s' 2>/dev/null | head -1", daemonPattern))
-	if daemonPid != "" {
-		daemonPid = strings.TrimSpace(daemonPid)
-		if daemonPid != "" {
-			fmt.Printf("Stopping watchdog daemon (PID: %s)...\n", daemonPid)
-			device.RunShellCommand(fmt.Sprintf("kill %s 2>/dev/null", daemonPid))
-		}
+	daemonPid, _ := device.RunShellCommand(ctx, device.Quote("pgrep", "-f", daemonPattern)+" 2>/dev/null | head -1")
+	if daemonPid = strings.TrimSpace(daemonPid); daemonPid != "" {
+		p.Add(Step{Kind: StepShell, Desc: fmt.Sprintf("Stopping watchdog daemon (PID: %s)...", daemonPid),
+			Cmd: device.Quote("kill", daemonPid) + " 2>/dev/null", Optional: true})
 	}
 
-	fmt.Println("Cleaning up networking...")
-	cleanupNetworking(cfg)
-
-	device.RunShellCommand(fmt.Sprintf("rm -f %s/vm.pid 2>/dev/null", cfg.DevicePath))
-
-	fmt.Println("✓ VM stopped")
-	return nil
+	planNetworkCleanup(p, cfg)
+	p.Cleanup(StepShell, device.Quote("rm", "-f", cfg.DevicePath+"/vm.pid", pausedMarker(cfg))+" 2>/dev/null")
+	return o
 }
 
 // cleanupNetworking removes TAP interface and iptables rules.
 // TEAM_029: Extracted from sql/lifecycle.go and forge/lifecycle.go
+func cleanupNetworking(ctx context.Context, cfg *VMConfig) {
+	p := &Plan{}
+	planNetworkCleanup(p, cfg)
+	p.Execute(ctx)
+}
+
+// planNetworkCleanup adds removing cfg's TAP interface and iptables rules
 // TEAM_029: Each command has 2>/dev/null and runs independently to avoid blocking
-func cleanupNetworking(cfg *VMConfig) {
+// TEAM_063: Split from cleanupNetworking so plans list every rule
+func planNetworkCleanup(p *Plan, cfg *VMConfig) {
 	// Delete TAP interface - ignore errors (may not exist)
-	device.RunShellCommandQuick(fmt.Sprintf("ip link del %s 2>/dev/null || true", cfg.TAPInterface))
+	p.Add(Step{Kind: StepShell, Desc: "Cleaning up networking...", Optional: true,
+		Cmd: device.Quote("ip", "link", "del", cfg.TAPInterface) + " 2>/dev/null || true"})
 
-	if cfg.TAPSubnet != "" {
-		// Remove iptables rules - ignore errors (may not exist)
-		device.RunShellCommandQuick(fmt.Sprintf("iptables -t nat -D POSTROUTING -s %s -o wlan0 -j MASQUERADE 2>/dev/null || true", cfg.TAPSubnet))
-		device.RunShellCommandQuick(fmt.Sprintf("iptables -D FORWARD -i %s -o wlan0 -j ACCEPT 2>/dev/null || true", cfg.TAPInterface))
-		device.RunShellCommandQuick(fmt.Sprintf("iptables -D FORWARD -i wlan0 -o %s -m state --state RELATED,ESTABLISHED -j ACCEPT 2>/dev/null || true", cfg.TAPInterface))
-	}
+	// Remove iptables rules - ignore errors (may not exist)
+	// TEAM_047: Subnet defaults via IPAM, so this always runs
+	subnet := subnetOf(cfg)
+	p.Cleanup(StepIptables, device.Quote("iptables", "-t", "nat", "-D", "POSTROUTING", "-s", subnet, "-o", "wlan0", "-j", "MASQUERADE")+" 2>/dev/null || true")
+	p.Cleanup(StepIptables, device.Quote("iptables", "-D", "FORWARD", "-i", cfg.TAPInterface, "-o", "wlan0", "-j", "ACCEPT")+" 2>/dev/null || true")
+	p.Cleanup(StepIptables, device.Quote("iptables", "-D", "FORWARD", "-i", "wlan0", "-o", cfg.TAPInterface, "-m", "state", "--state", "RELATED,ESTABLISHED", "-j", "ACCEPT")+" 2>/dev/null || true")
 
 	// SQL-specific cleanup (policy routing rules)
 	if cfg.Name == "sql" {
-		device.RunShellCommandQuick("ip rule del from all lookup main pref 1 2>/dev/null || true")
-		device.RunShellCommandQuick(fmt.Sprintf("ip rule del from %s lookup wlan0 2>/dev/null || true", cfg.TAPSubnet))
-		device.RunShellCommandQuick(fmt.Sprintf("ip rule del from %s lookup main 2>/dev/null || true", cfg.TAPSubnet))
+		p.Cleanup(StepShell, "ip rule del from all lookup main pref 1 2>/dev/null || true")
+		p.Cleanup(StepShell, device.Quote("ip", "rule", "del", "from", subnet, "lookup", "wlan0")+" 2>/dev/null || true")
+		p.Cleanup(StepShell, device.Quote("ip", "rule", "del", "from", subnet, "lookup", "main")+" 2>/dev/null || true")
 	}
 }
 
 // RemoveVM removes a VM from the device (stop + cleanup + delete files).
 // TEAM_029: Extracted from sql/lifecycle.go Remove() and forge/lifecycle.go Remove()
-func RemoveVM(cfg *VMConfig) error {
+func RemoveVM(ctx context.Context, cfg *VMConfig) error {
 	fmt.Printf("=== Removing %s VM from device ===\n", cfg.DisplayName)
 
-	StopVM(cfg)
+	// TEAM_065: One mutating operation per VM and device at a time
+	unlock, err := lockVM(ctx, cfg, "remove")
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	fmt.Println("Removing Tailscale registration...")
-	RemoveTailscaleRegistrations(cfg.TailscaleHost)
+	p := &Plan{Title: fmt.Sprintf("Remove %s VM", cfg.DisplayName)}
+	stop := planStop(ctx, p, cfg)
+
+	if err := planTailscaleCleanup(ctx, p, cfg.TailscaleHost); err != nil {
+		fmt.Printf("  ⚠ %v\n", err)
+	}
 
 	if cfg.Name == "sql" {
-		fmt.Println("Ensuring all networking rules are removed...")
-		device.RunShellCommand(fmt.Sprintf("ip rule del from %s lookup wlan0 2>/dev/null", cfg.TAPSubnet))
-		device.RunShellCommand(fmt.Sprintf("ip rule del from %s lookup main 2>/dev/null", cfg.TAPSubnet))
+		p.Add(Step{Kind: StepShell, Desc: "Ensuring all networking rules are removed...", Optional: true,
+			Cmd: device.Quote("ip", "rule", "del", "from", subnetOf(cfg), "lookup", "wlan0") + " 2>/dev/null"})
+		p.Cleanup(StepShell, device.Quote("ip", "rule", "del", "from", subnetOf(cfg), "lookup", "main")+" 2>/dev/null")
 	}
 
-	fmt.Println("Removing VM files from device...")
-	device.RemoveDir(cfg.DevicePath)
+	// TEAM_047: Free the guest IP so the next VM can reuse it
+	p.Do(StepLocal, fmt.Sprintf("Releasing %s's guest IP lease...", cfg.Name), true, func(ctx context.Context) error {
+		if err := ReleaseGuestIP(cfg.Name); err != nil {
+			return fmt.Errorf("could not release IP lease: %w", err)
+		}
+		return nil
+	})
+
+	p.Checked(StepShell, "Removing VM files from device...",
+		device.Quote("rm", "-rf", cfg.DevicePath)+" && [ ! -d "+device.Quote(cfg.DevicePath)+" ]")
 
-	if device.DirExists(cfg.DevicePath) {
-		return fmt.Errorf("failed to remove %s", cfg.DevicePath)
+	if ran, err := runPlan(ctx, p); !ran || err != nil {
+		return err
 	}
+	stop.report(cfg) // RemoveVM carries on past a failed shutdown
+	forgetVM(cfg)    // TEAM_064
 
 	fmt.Printf("✓ %s VM removed from device\n", cfg.DisplayName)
 	fmt.Printf("\nTo redeploy: sovereign deploy --%s\n", cfg.Name)
@@ -122,18 +185,36 @@ func RemoveVM(cfg *VMConfig) error {
 // StartVM starts a VM and streams boot logs until ready.
 // TEAM_029: Extracted from sql/lifecycle.go Start() and forge/lifecycle.go Start()
 // TEAM_037: Uses daemon script to keep parent alive, preventing Android init from killing VMs
-func StartVM(cfg *VMConfig) error {
+func StartVM(ctx context.Context, cfg *VMConfig) error {
 	fmt.Printf("=== Starting %s VM ===\n", cfg.DisplayName)
 
+	// TEAM_065: One mutating operation per VM and device at a time
+	unlock, err := lockVM(ctx, cfg, "start")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// TEAM_029: Check dependencies first (fail-fast)
 	if len(cfg.Dependencies) > 0 {
-		if err := CheckDependencies(cfg); err != nil {
+		if err := CheckDependencies(ctx, cfg); err != nil {
 			return err
 		}
 	}
 
-	runningPid := device.GetProcessPID(cfg.ProcessPattern)
+	// TEAM_053: An invalid probe would otherwise only surface after boot
+	if _, err := ReadinessProbes(cfg); err != nil {
+		return err
+	}
+
+	runningPid := processPID(ctx, cfg)
 	if runningPid != "" {
+		// TEAM_050: A paused VM needs resume, not a restart
+		if device.FileExists(ctx, pausedMarker(cfg)) {
+			fmt.Printf("⚠ VM is paused (PID: %s)\n", runningPid)
+			fmt.Printf("Run 'sovereign resume --%s' to continue it\n", cfg.Name)
+			return nil
+		}
 		fmt.Printf("⚠ VM already running (PID: %s)\n", runningPid)
 		fmt.Printf("Run 'sovereign stop --%s' first to restart\n", cfg.Name)
 		return nil
@@ -145,75 +226,127 @@ func StartVM(cfg *VMConfig) error {
 	daemonScript := "/data/sovereign/sovereign_start.sh"
 	legacyScript := fmt.Sprintf("%s/start.sh", cfg.DevicePath)
 
-	if !device.FileExists(daemonScript) && !device.FileExists(legacyScript) {
+	hasDaemon := device.FileExists(ctx, daemonScript)
+	if !hasDaemon && !device.FileExists(ctx, legacyScript) {
 		return fmt.Errorf("no start script found - run 'sovereign deploy --%s' first", cfg.Name)
 	}
 
-	consoleLog := fmt.Sprintf("%s/console.log", cfg.DevicePath)
+	// TEAM_063: Planned so --dry-run shows the lease, pushes and start
+	// command without booting anything
+	p := &Plan{Title: fmt.Sprintf("Start %s VM", cfg.DisplayName)}
+
+	// TEAM_047: Resolve the guest IP before the daemon reads network.env
+	p.Do(StepLocal, fmt.Sprintf("Reserving %s's guest IP lease...", cfg.Name), false, func(ctx context.Context) error {
+		ip, err := AssignGuestIP(cfg)
+		if err != nil {
+			return fmt.Errorf("assigning guest IP: %w", err)
+		}
+		fmt.Printf("Guest IP: %s (gateway %s)\n", ip, cfg.TAPHostIP)
+		return nil
+	})
+	p.Push("", "network.env", NetworkEnvDevice, 0, func(ctx context.Context) error {
+		if err := PushNetworkEnv(ctx); err != nil {
+			return fmt.Errorf("pushing network.env: %w", err)
+		}
+		return nil
+	})
 
 	// TEAM_041: Clean up any stale state before starting
-	// Remove old console.log, socket, and pid files
-	device.RunShellCommand(fmt.Sprintf("rm -f %s %s/vm.sock %s/vm.pid", consoleLog, cfg.DevicePath, cfg.DevicePath))
+	// Remove old console.log, socket, pid and paused marker files
+	consoleLog := fmt.Sprintf("%s/console.log", cfg.DevicePath)
+	p.Cleanup(StepShell, device.Quote("rm", "-f", consoleLog, cfg.DevicePath+"/vm.sock", cfg.DevicePath+"/vm.pid", pausedMarker(cfg)))
+
+	startCmd := device.Quote(legacyScript)
+	switch {
+	case device.FileExists(ctx, SupervisorDevicePath):
+		startCmd = device.Quote(SupervisorDevicePath, "ctl", supervisor.OpStart, cfg.Name)
+	case hasDaemon:
+		startCmd = device.Quote(daemonScript, "start", cfg.Name)
+	}
+	p.Add(Step{Kind: StepShell, Cmd: startCmd, run: func(ctx context.Context) error {
+		return bootVM(ctx, cfg, daemonScript, legacyScript)
+	}})
+
+	_, err = runPlan(ctx, p)
+	return err
+}
+
+// bootVM starts cfg through the supervisor, the daemon script or the legacy
+// start.sh, in that order of preference, and waits for it to be ready
+func bootVM(ctx context.Context, cfg *VMConfig, daemonScript, legacyScript string) error {
+	// TEAM_051: Prefer the native supervisor - it restarts the VM if it crashes
+	if err := ensureSupervisor(ctx); err == nil {
+		if err := PushSupervisorConfig(ctx); err != nil {
+			return fmt.Errorf("pushing supervisor config: %w", err)
+		}
+		fmt.Println("Starting VM via supervisor...")
+		if _, err := supervisorCall(ctx, 30*time.Second, supervisor.OpStart, cfg.Name); err != nil {
+			return fmt.Errorf("supervisor start failed: %w", err)
+		}
+		fmt.Println("\n--- Boot Sequence ---")
+		return waitBooted(ctx, cfg)
+	}
 
 	// TEAM_037: Use daemon script with "start <vm>" to start a single VM
 	// The daemon script stays alive in background, keeping crosvm as its child
 	// This prevents Android init from killing crosvm as an orphaned process
-	if device.FileExists(daemonScript) {
+	if device.FileExists(ctx, daemonScript) {
 		fmt.Println("Starting VM via daemon (prevents Android killing)...")
 
 		// TEAM_041: Clear old daemon log before starting
 		daemonLog := fmt.Sprintf("/data/sovereign/daemon_%s.log", cfg.Name)
-		device.RunShellCommand(fmt.Sprintf("rm -f %s", daemonLog))
+		device.Exec(ctx, "rm", "-f", daemonLog)
 
-		// TEAM_041: Run daemon in a completely detached process
-		// Use SysProcAttr to create a new process group so it survives when Go exits
-		startCmd := fmt.Sprintf("%s start %s", daemonScript, cfg.Name)
-		cmd := exec.Command("adb", "shell", "su", "-c", startCmd)
-
-		// Detach the process completely - new process group, new session
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Setpgid: true, // Create new process group
-			Pgid:    0,    // Use the new process's PID as PGID
-		}
-
-		// Start the command
-		if err := cmd.Start(); err != nil {
+		// TEAM_041: Run daemon in a completely detached process so it survives when Go exits
+		// TEAM_042: Detaching is handled by the device transport
+		startCmd := device.Quote(daemonScript, "start", cfg.Name)
+		if err := device.StartDetached(startCmd); err != nil {
 			return fmt.Errorf("daemon start failed: %w", err)
 		}
 
-		fmt.Printf("Started adb process (PID: %d)\n", cmd.Process.Pid)
-
-		// Release the process - we don't want to wait for it or kill it on exit
-		if err := cmd.Process.Release(); err != nil {
-			fmt.Printf("Warning: could not release process: %v\n", err)
-		}
-
 		// Give the daemon time to set up networking and start crosvm
 		fmt.Println("Waiting for daemon to start VM...")
-		time.Sleep(10 * time.Second)
+		if err := sleepCtx(ctx, 10*time.Second); err != nil {
+			return err
+		}
 	} else {
 		// Fallback to legacy approach (will still be killed after ~90s)
 		fmt.Println("⚠ Using legacy start.sh (VMs may be killed after ~90s)")
 		fmt.Println("  Run 'sovereign deploy' to install the daemon script for stability")
-		cmd := exec.Command("adb", "shell", "su", "-c", legacyScript)
-		if err := cmd.Run(); err != nil {
+		if _, err := device.Exec(ctx, legacyScript); err != nil {
 			return fmt.Errorf("start script failed: %w", err)
 		}
 	}
 
 	fmt.Println("\n--- Boot Sequence ---")
-	return StreamBootLogs(cfg)
+	return waitBooted(ctx, cfg)
 }
 
-// StreamBootLogs streams console.log and waits for the ready marker.
+// waitBooted streams the boot and records the start once the VM is ready
+// TEAM_064: For the host state store
+func waitBooted(ctx context.Context, cfg *VMConfig) error {
+	if err := StreamBootLogs(ctx, cfg); err != nil {
+		return err
+	}
+	recordVM(cfg, func(r *VMRecord) { r.StartedAt = time.Now().UTC() })
+	return nil
+}
+
+// StreamBootLogs streams console.log and waits for the readiness probes.
 // TEAM_029: Extracted from sql/lifecycle.go streamBootAndWaitForPostgres()
 // and forge/lifecycle.go streamBootAndWaitForForgejo()
 // TEAM_041: Added startup grace period - daemon script takes time to set up networking
-func StreamBootLogs(cfg *VMConfig) error {
+// TEAM_044: StartTimeout is applied on top of ctx, so the caller can cancel or shorten the wait
+// TEAM_053: Readiness comes from ReadinessProbes; ReadyMarker is only a hint.
+// FATAL lines no longer fail the boot (PostgreSQL logs FATAL for client errors)
+// but are reported if the VM dies or times out.
+func StreamBootLogs(ctx context.Context, cfg *VMConfig) error {
 	timeout := cfg.StartTimeout
 	if timeout == 0 {
 		timeout = 90
 	}
+	bootCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
 
 	var lastLineCount int
 	startTime := time.Now()
@@ -225,14 +358,23 @@ func StreamBootLogs(cfg *VMConfig) error {
 	const startupGracePeriod = 15 * time.Second
 	processEverSeen := false
 
+	const probeInterval = 2 * time.Second
+	var lastProbe time.Time
+	var probeErr error
+	var lastFatal string
+	markerSeen := false
+
 	for {
 		elapsed := time.Since(startTime)
-		if elapsed > time.Duration(timeout)*time.Second {
-			return fmt.Errorf("timeout waiting for %s (%.0fs) - check 'adb shell cat %s'",
-				cfg.DisplayName, elapsed.Seconds(), consoleLog)
+		if ctx.Err() != nil {
+			return fmt.Errorf("waiting for %s: %w", cfg.DisplayName, ctx.Err())
+		}
+		if bootCtx.Err() != nil {
+			return fmt.Errorf("timeout waiting for %s (%.0fs)%s - check 'adb shell cat %s'",
+				cfg.DisplayName, elapsed.Seconds(), bootFailureDetail(probeErr, lastFatal), consoleLog)
 		}
 
-		out, _ := device.RunShellCommand(fmt.Sprintf("cat %s 2>/dev/null | tail -n +%d", consoleLog, lastLineCount+1))
+		out, _ := device.RunShellCommand(bootCtx, fmt.Sprintf("cat %s 2>/dev/null | tail -n +%d", device.Quote(consoleLog), lastLineCount+1))
 		if out != "" {
 			lines := strings.Split(out, "\n")
 			for _, line := range lines {
@@ -240,21 +382,14 @@ func StreamBootLogs(cfg *VMConfig) error {
 					fmt.Println(line)
 					lastLineCount++
 
-					if cfg.ReadyMarker != "" && strings.Contains(line, cfg.ReadyMarker) {
-						time.Sleep(2 * time.Second)
-						fmt.Printf("\n✓ %s VM started\n", cfg.DisplayName)
-						fmt.Printf("\nNext: sovereign test --%s\n", cfg.Name)
-						return nil
+					if cfg.ReadyMarker != "" && !markerSeen && strings.Contains(line, cfg.ReadyMarker) {
+						markerSeen = true
+						fmt.Println("  (ready marker seen - waiting for readiness probes)")
 					}
-
-					if strings.Contains(line, "INIT COMPLETE") {
-						time.Sleep(2 * time.Second)
-						fmt.Printf("\n✓ %s VM started\n", cfg.DisplayName)
-						fmt.Printf("\nNext: sovereign test --%s\n", cfg.Name)
-						return nil
+					if strings.Contains(line, "FATAL") {
+						lastFatal = strings.TrimSpace(line)
 					}
-
-					if strings.Contains(line, "Kernel panic") || strings.Contains(line, "FATAL") {
+					if strings.Contains(line, "Kernel panic") {
 						return fmt.Errorf("VM boot failed - see output above")
 					}
 				}
@@ -262,15 +397,59 @@ func StreamBootLogs(cfg *VMConfig) error {
 		}
 
 		// TEAM_041: Check if process is running, but respect grace period
-		pid := device.GetProcessPID(cfg.ProcessPattern)
+		pid := device.GetProcessPID(bootCtx, cfg.ProcessPattern)
+		if bootCtx.Err() != nil {
+			continue // Timeout or cancellation - reported at the top of the loop
+		}
 		if pid != "" {
 			processEverSeen = true
 		} else if processEverSeen || elapsed > startupGracePeriod {
 			// Only declare death if we saw the process before and it's gone,
 			// or if grace period passed and process never appeared
-			return fmt.Errorf("VM process died during boot - check console.log")
+			return fmt.Errorf("VM process died during boot%s - check console.log", bootFailureDetail(nil, lastFatal))
+		}
+
+		if pid != "" && time.Since(lastProbe) >= probeInterval {
+			lastProbe = time.Now()
+			var degraded []string
+			if degraded, probeErr = CheckReadiness(bootCtx, cfg); probeErr == nil {
+				fmt.Printf("\n✓ %s VM started (readiness probes passed)\n", cfg.DisplayName)
+				for _, d := range degraded {
+					fmt.Printf("⚠ Degraded: %s\n", d)
+				}
+				fmt.Printf("\nNext: sovereign test --%s\n", cfg.Name)
+				return nil
+			}
 		}
 
-		time.Sleep(500 * time.Millisecond)
+		sleepCtx(bootCtx, 500*time.Millisecond)
+	}
+}
+
+// bootFailureDetail summarises why a boot didn't become ready
+func bootFailureDetail(probeErr error, lastFatal string) string {
+	var parts []string
+	if probeErr != nil {
+		parts = append(parts, "last probe: "+probeErr.Error())
+	}
+	if lastFatal != "" {
+		parts = append(parts, "last FATAL: "+lastFatal)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, "; ") + ")"
+}
+
+// sleepCtx sleeps for d or until ctx is done, returning ctx.Err() if cancelled
+// TEAM_044: Replaces time.Sleep so Ctrl-C is not delayed by fixed waits
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
 	}
 }
@@ -0,0 +1,242 @@
+// Operation locks
+// TEAM_065: Two people running `start --sql` and `remove --sql` against the
+// same phone raced through StartVM/RemoveVM. Mutating operations now hold a
+// lock per (device, VM): a flock on the host, for runs on the same machine,
+// and a lock directory on the device, for runs from different machines.
+// Both name their owner so the error says who to ask.
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/anthropics/sovereign/internal/device"
+)
+
+// BreakLock removes the device lock left by a crashed or killed run
+// TEAM_065: Package-level flag for --break-lock
+var BreakLock bool
+
+// DeviceLockDir holds one lock directory per VM on the device
+const DeviceLockDir = "/data/sovereign/locks"
+
+// LockOwner identifies who holds an operation lock
+type LockOwner struct {
+	User  string    `json:"user"` // user@host
+	PID   int       `json:"pid"`
+	Op    string    `json:"op"` // "deploy", "start", ...
+	Since time.Time `json:"since"`
+	Token string    `json:"token"` // Lets the holder release only its own lock
+}
+
+func (o LockOwner) String() string {
+	return fmt.Sprintf("%s (pid %d, %s since %s)", o.User, o.PID, o.Op, o.Since.Local().Format("2006-01-02 15:04:05"))
+}
+
+// heldLock is a lock this process holds; nested operations (rollback
+// restarting the VM) reuse it instead of locking themselves out
+type heldLock struct {
+	refs  int
+	host  *os.File
+	dir   string // On the device
+	token string
+}
+
+var (
+	heldLocks   = map[string]*heldLock{}
+	heldLocksMu sync.Mutex
+)
+
+// plannedOps change the device only by executing a Plan through runPlan, so
+// under --dry-run they are read-only and skip the lock (whose device side is
+// itself a mkdir). Anything else is locked even in a dry run.
+var plannedOps = map[string]bool{
+	"deploy": true, "start": true, "stop": true, "remove": true, "clean": true,
+	"rollback": true, "pause": true, "resume": true, "resize-data": true,
+}
+
+// lockVM takes cfg's operation lock for op on the selected device, returning
+// the function that releases it
+func lockVM(ctx context.Context, cfg *VMConfig, op string) (unlock func(), err error) {
+	if DryRun && plannedOps[op] {
+		return func() {}, nil
+	}
+	id := device.TargetID()
+	key := id + "/" + cfg.Name
+
+	heldLocksMu.Lock()
+	defer heldLocksMu.Unlock()
+	if l, ok := heldLocks[key]; ok {
+		l.refs++
+		return func() { releaseVMLock(key) }, nil
+	}
+
+	owner, err := newLockOwner(op)
+	if err != nil {
+		return nil, err
+	}
+
+	// Host first: two runs on this machine never get as far as the device
+	hostPath, err := hostLockPath(id, cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+	host, err := lockFile(hostPath, 0)
+	if errors.Is(err, errLocked) {
+		return nil, lockedError(cfg, id, readLockOwner(hostPath), "")
+	}
+	if err != nil {
+		return nil, err
+	}
+	host.Truncate(0)
+	json.NewEncoder(host).Encode(owner)
+
+	dir := fmt.Sprintf("%s/%s.lock", DeviceLockDir, cfg.Name)
+	if err := lockDevice(ctx, cfg, id, dir, owner); err != nil {
+		unlockFile(host)
+		return nil, err
+	}
+
+	heldLocks[key] = &heldLock{refs: 1, host: host, dir: dir, token: owner.Token}
+	return func() { releaseVMLock(key) }, nil
+}
+
+// lockDevice creates dir on the device; mkdir is atomic, so one run wins
+func lockDevice(ctx context.Context, cfg *VMConfig, id, dir string, owner LockOwner) error {
+	data, _ := json.Marshal(owner)
+	acquire := fmt.Sprintf("mkdir -p %s && mkdir %s 2>/dev/null && echo %s > %s/owner && echo OK",
+		DeviceLockDir, device.Quote(dir), device.Quote(string(data)), device.Quote(dir))
+
+	out, err := device.RunShellCommand(ctx, acquire)
+	if err != nil {
+		return fmt.Errorf("taking device lock %s: %w", dir, err)
+	}
+	if out == "OK" {
+		return nil
+	}
+
+	holder, _ := device.RunShellCommand(ctx, device.Quote("cat", dir+"/owner")+" 2>/dev/null")
+	var held LockOwner
+	if json.Unmarshal([]byte(holder), &held) != nil {
+		held = LockOwner{} // Half-written by a run killed between mkdir and echo
+	}
+	if !BreakLock {
+		return lockedError(cfg, id, held, dir)
+	}
+
+	fmt.Printf("⚠ Breaking lock on %s held by %s\n", cfg.Name, describeOwner(held))
+	out, err = device.RunShellCommand(ctx, device.Quote("rm", "-rf", dir)+" && "+acquire)
+	if err != nil {
+		return fmt.Errorf("taking device lock %s: %w", dir, err)
+	}
+	if out != "OK" {
+		return fmt.Errorf("%s was locked again while breaking it - another run is active", dir)
+	}
+	return nil
+}
+
+// releaseVMLock drops one reference to key's lock, releasing it with the last
+func releaseVMLock(key string) {
+	heldLocksMu.Lock()
+	defer heldLocksMu.Unlock()
+	l, ok := heldLocks[key]
+	if !ok {
+		return
+	}
+	if l.refs--; l.refs > 0 {
+		return
+	}
+	delete(heldLocks, key)
+	// Only remove the device lock if it is still ours (not broken and retaken).
+	// Runs on Ctrl-C too, so it can't use the cancelled context.
+	device.RunShellCommandQuick(context.Background(), device.Quote("grep", "-q", l.token, l.dir+"/owner")+" 2>/dev/null && "+device.Quote("rm", "-rf", l.dir))
+	unlockFile(l.host)
+}
+
+// lockedError names the holder, and says whether it looks stale
+func lockedError(cfg *VMConfig, id string, held LockOwner, dir string) error {
+	msg := fmt.Sprintf("%s on %s is locked by %s", cfg.Name, id, describeOwner(held))
+	if dir == "" {
+		// Host flocks are released by the kernel, so the holder is alive
+		return fmt.Errorf("%s - wait for it to finish", msg)
+	}
+	if host, _ := os.Hostname(); held.PID > 0 && strings.HasSuffix(held.User, "@"+host) && !processAlive(held.PID) {
+		return fmt.Errorf("%s, which is no longer running - rerun with --break-lock to clear %s", msg, dir)
+	}
+	return fmt.Errorf("%s - wait for it to finish, or rerun with --break-lock if it is stale (%s)", msg, dir)
+}
+
+func describeOwner(o LockOwner) string {
+	if o.User == "" {
+		return "an unknown run"
+	}
+	return o.String()
+}
+
+// newLockOwner describes this run. The token must be unpredictable: release
+// only removes a device lock whose owner file still contains it.
+func newLockOwner(op string) (LockOwner, error) {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return LockOwner{}, fmt.Errorf("generating lock token: %w", err)
+	}
+	return LockOwner{User: name + "@" + host, PID: os.Getpid(), Op: op, Since: time.Now().UTC(), Token: hex.EncodeToString(b)}, nil
+}
+
+// hostLockPath is the flock file for one VM on one device, next to the state file
+func hostLockPath(id, vm string) (string, error) {
+	state, err := statePath()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(filepath.Dir(state), "locks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, safeFileName(id)+"-"+vm+".lock"), nil
+}
+
+// safeFileName turns a device ID (serial, ssh://host:port) into a file name
+func safeFileName(id string) string {
+	safe := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, id)
+	if safe == "" {
+		safe = "default"
+	}
+	return safe
+}
+
+// readLockOwner reads the owner a host lock's holder wrote into it
+func readLockOwner(path string) LockOwner {
+	var o LockOwner
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &o)
+	}
+	return o
+}
+
+// processAlive reports whether pid exists on this host
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
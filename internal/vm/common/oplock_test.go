@@ -0,0 +1,132 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/sovereign/internal/device"
+)
+
+// deviceLock fakes the device side of a lock: mkdir succeeds while the
+// directory is free; held is the owner file of another run
+func deviceLock(held *LockOwner) *device.FakeTransport {
+	fake := device.NewFakeTransport()
+	fake.Handler = func(cmd string) (string, error) {
+		switch {
+		case strings.HasPrefix(cmd, "rm -rf") && strings.Contains(cmd, "&& mkdir -p"):
+			held = nil // --break-lock, then acquire
+			return "OK", nil
+		case strings.HasPrefix(cmd, "mkdir -p "+DeviceLockDir):
+			if held != nil {
+				return "", nil
+			}
+			return "OK", nil
+		case strings.HasPrefix(cmd, "cat "):
+			data, _ := json.Marshal(held)
+			return string(data), nil
+		}
+		return "", nil
+	}
+	return fake
+}
+
+func withLocks(t *testing.T, fake *device.FakeTransport) *VMConfig {
+	t.Helper()
+	withHostState(t)
+	old := device.CurrentTransport()
+	device.SetTransport(fake)
+	t.Cleanup(func() { device.SetTransport(old) })
+	return &VMConfig{Name: "locked", DisplayName: "Locked"}
+}
+
+func TestLockVMHostContention(t *testing.T) {
+	cfg := withLocks(t, deviceLock(nil))
+	path, err := hostLockPath(device.TargetID(), cfg.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := lockFile(path, 0) // Another process on this host
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := lockVM(context.Background(), cfg, "start"); err == nil || !strings.Contains(err.Error(), "wait for it to finish") {
+		t.Fatalf("err = %v, want the host lock to be held", err)
+	}
+	unlockFile(other)
+
+	unlock, err := lockVM(context.Background(), cfg, "start")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+}
+
+func TestLockVMNestedAndRelease(t *testing.T) {
+	fake := deviceLock(nil)
+	cfg := withLocks(t, fake)
+
+	unlock, err := lockVM(context.Background(), cfg, "rollback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rollback restarting the VM takes the same lock again
+	inner, err := lockVM(context.Background(), cfg, "start")
+	if err != nil {
+		t.Fatalf("nested lock: %v", err)
+	}
+	inner()
+	if fake.Ran("grep -q") {
+		t.Fatal("inner unlock released the outer lock")
+	}
+	unlock()
+	if !fake.Ran("&& rm -rf " + DeviceLockDir + "/locked.lock") {
+		t.Errorf("device lock not released: %q", fake.Commands)
+	}
+}
+
+func TestLockVMDeviceHeldAndBreakLock(t *testing.T) {
+	held := &LockOwner{User: "alice@laptop", PID: 1, Op: "deploy", Token: "theirs"}
+	cfg := withLocks(t, deviceLock(held))
+
+	_, err := lockVM(context.Background(), cfg, "start")
+	if err == nil || !strings.Contains(err.Error(), "alice@laptop") || !strings.Contains(err.Error(), "--break-lock") {
+		t.Fatalf("err = %v, want the holder and a --break-lock hint", err)
+	}
+
+	BreakLock = true
+	defer func() { BreakLock = false }()
+	unlock, err := lockVM(context.Background(), cfg, "start")
+	if err != nil {
+		t.Fatalf("--break-lock: %v", err)
+	}
+	unlock()
+}
+
+func TestLockVMDryRun(t *testing.T) {
+	fake := deviceLock(nil)
+	cfg := withLocks(t, fake)
+	DryRun = true
+	defer func() { DryRun = false }()
+
+	unlock, err := lockVM(context.Background(), cfg, "resize-data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if len(fake.Commands) != 0 {
+		t.Errorf("planned op locked during a dry run: %q", fake.Commands)
+	}
+
+	// Not planned: locked even in a dry run
+	unlock, err = lockVM(context.Background(), cfg, "fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if !fake.Ran("mkdir -p " + DeviceLockDir) {
+		t.Error("unplanned op skipped the lock during a dry run")
+	}
+}
@@ -0,0 +1,142 @@
+// Pause/resume via the crosvm control socket
+// TEAM_050: Freeze a VM to free CPU on the phone without losing guest state.
+// crosvm can't report whether it is suspended, so pause leaves a vm.paused
+// marker next to vm.sock that status, tests and stop consult.
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/sovereign/internal/device"
+)
+
+// VMState is the coarse run state of a VM on the device
+type VMState string
+
+const (
+	StateStopped VMState = "stopped"
+	StateRunning VMState = "running"
+	StatePaused  VMState = "paused"
+)
+
+// pausedMarker is written by PauseVM and removed by ResumeVM/StartVM/StopVM
+func pausedMarker(cfg *VMConfig) string {
+	return fmt.Sprintf("%s/vm.paused", cfg.DevicePath)
+}
+
+func controlSocket(cfg *VMConfig) string {
+	return fmt.Sprintf("%s/vm.sock", cfg.DevicePath)
+}
+
+// GetVMState returns whether the VM is stopped, running or paused
+func GetVMState(ctx context.Context, cfg *VMConfig) VMState {
+	if processPID(ctx, cfg) == "" {
+		return StateStopped
+	}
+	if device.FileExists(ctx, pausedMarker(cfg)) {
+		return StatePaused
+	}
+	return StateRunning
+}
+
+// isPaused checks the paused marker of a registered VM by name
+func isPaused(ctx context.Context, name string) bool {
+	for _, cfg := range RegisteredConfigs() {
+		if cfg.Name == name {
+			return device.FileExists(ctx, pausedMarker(cfg))
+		}
+	}
+	return false
+}
+
+// crosvmControl runs a crosvm control command (suspend, resume, ...) against vm.sock
+func crosvmControl(ctx context.Context, cfg *VMConfig, command string) error {
+	sock := controlSocket(cfg)
+	if !device.FileExists(ctx, sock) {
+		return fmt.Errorf("no control socket at %s - restart the VM to enable pause/resume", sock)
+	}
+	out, err := device.RunShellCommand(ctx, crosvmCmd+" "+device.Quote(command, sock)+" 2>&1 && echo OK")
+	if err != nil {
+		return fmt.Errorf("crosvm %s: %w", command, err)
+	}
+	if !strings.HasSuffix(out, "OK") {
+		return fmt.Errorf("crosvm %s failed: %s", command, out)
+	}
+	return nil
+}
+
+// planControl adds sending command to cfg's crosvm control socket
+func planControl(p *Plan, cfg *VMConfig, command string) {
+	p.Add(Step{Kind: StepShell, Cmd: crosvmCmd + " " + device.Quote(command, controlSocket(cfg)), run: func(ctx context.Context) error {
+		return crosvmControl(ctx, cfg, command)
+	}})
+}
+
+// PauseVM suspends the guest's vCPUs; memory stays allocated and the
+// TAP link stays up, so ResumeVM continues exactly where the guest left off.
+func PauseVM(ctx context.Context, cfg *VMConfig) error {
+	fmt.Printf("=== Pausing %s VM ===\n", cfg.DisplayName)
+
+	// TEAM_065: One mutating operation per VM and device at a time
+	unlock, err := lockVM(ctx, cfg, "pause")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	switch GetVMState(ctx, cfg) {
+	case StateStopped:
+		return fmt.Errorf("%s VM is not running", cfg.DisplayName)
+	case StatePaused:
+		fmt.Println("⚠ VM already paused")
+		return nil
+	}
+
+	// TEAM_063: Planned so --dry-run shows the suspend without sending it
+	p := &Plan{Title: fmt.Sprintf("Pause %s VM", cfg.DisplayName)}
+	planControl(p, cfg, "suspend")
+	p.Add(Step{Kind: StepShell, Cmd: device.Quote("touch", pausedMarker(cfg))})
+	if ran, err := runPlan(ctx, p); !ran || err != nil {
+		return err
+	}
+
+	fmt.Println("✓ VM paused")
+	fmt.Printf("\nTo continue: sovereign resume --%s\n", cfg.Name)
+	return nil
+}
+
+// ResumeVM wakes a VM suspended by PauseVM
+func ResumeVM(ctx context.Context, cfg *VMConfig) error {
+	fmt.Printf("=== Resuming %s VM ===\n", cfg.DisplayName)
+
+	// TEAM_065: One mutating operation per VM and device at a time
+	unlock, err := lockVM(ctx, cfg, "resume")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	switch GetVMState(ctx, cfg) {
+	case StateStopped:
+		// Drop a marker left behind by a VM that died while paused
+		if !DryRun {
+			device.Exec(ctx, "rm", "-f", pausedMarker(cfg))
+		}
+		return fmt.Errorf("%s VM is not running - use 'sovereign start --%s'", cfg.DisplayName, cfg.Name)
+	case StateRunning:
+		fmt.Println("⚠ VM is not paused")
+		return nil
+	}
+
+	p := &Plan{Title: fmt.Sprintf("Resume %s VM", cfg.DisplayName)}
+	planControl(p, cfg, "resume")
+	p.Cleanup(StepShell, device.Quote("rm", "-f", pausedMarker(cfg)))
+	if ran, err := runPlan(ctx, p); !ran || err != nil {
+		return err
+	}
+
+	fmt.Println("✓ VM resumed")
+	return nil
+}
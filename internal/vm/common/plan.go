@@ -0,0 +1,187 @@
+// Plans for mutating operations
+// TEAM_063: deploy, remove, stop and fix --all ran dozens of `su -c`
+// commands with no way to see them first. They now build a Plan - every push,
+// shell command, iptables rule and Tailscale deletion - from read-only checks,
+// and then either print it (--dry-run) or execute that same Plan.
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/rootfs"
+)
+
+// DryRun prints the plan of each mutating operation instead of running it
+// TEAM_063: Package-level flag for --dry-run
+var DryRun bool
+
+// StepKind says what a step touches
+type StepKind string
+
+const (
+	StepShell     StepKind = "shell"     // Command on the device
+	StepIptables  StepKind = "iptables"  // Firewall rule on the device
+	StepPush      StepKind = "push"      // File from the host to the device
+	StepLink      StepKind = "link"      // Unchanged file reused on the device
+	StepTailscale StepKind = "tailscale" // Tailscale API call
+	StepLocal     StepKind = "local"     // Host-side state (IP leases)
+	StepSkip      StepKind = "skip"      // Nothing to do; shown so the plan is complete
+)
+
+// Step is one action of a Plan
+type Step struct {
+	Kind StepKind
+	Desc string // Printed as-is when the step runs ("Pushing rootfs.img...")
+	Cmd  string // Device command (shell, iptables, link)
+
+	Local, Remote string // push
+	Size          int64  // push; 0 = generated when the step runs
+
+	// Optional steps are best-effort cleanup: run with the short timeout and
+	// failures ignored. Checked commands must print OK (`{ cmd; } && echo OK`).
+	Optional bool
+	Checked  bool
+
+	run func(ctx context.Context) error // Anything that isn't a plain command
+}
+
+// Plan is an ordered list of steps for one operation
+type Plan struct {
+	Title string
+	Steps []Step
+}
+
+// Add appends a step
+func (p *Plan) Add(s Step) {
+	p.Steps = append(p.Steps, s)
+}
+
+// Shell adds a device command; its error fails the plan
+func (p *Plan) Shell(desc, cmd string) {
+	p.Steps = append(p.Steps, Step{Kind: StepShell, Desc: desc, Cmd: cmd})
+}
+
+// Checked adds a device command that must succeed
+func (p *Plan) Checked(kind StepKind, desc, cmd string) {
+	p.Steps = append(p.Steps, Step{Kind: kind, Desc: desc, Cmd: cmd, Checked: true})
+}
+
+// Cleanup adds a best-effort device command
+func (p *Plan) Cleanup(kind StepKind, cmd string) {
+	p.Steps = append(p.Steps, Step{Kind: kind, Cmd: cmd, Optional: true})
+}
+
+// Push adds a file transfer
+func (p *Plan) Push(desc, local, remote string, size int64, run func(ctx context.Context) error) {
+	if run == nil {
+		run = func(ctx context.Context) error { return device.PushFile(ctx, local, remote) }
+	}
+	p.Steps = append(p.Steps, Step{Kind: StepPush, Desc: desc, Local: local, Remote: remote, Size: size, run: run})
+}
+
+// Do adds a step that runs Go code; desc must say everything it changes
+func (p *Plan) Do(kind StepKind, desc string, optional bool, run func(ctx context.Context) error) {
+	p.Steps = append(p.Steps, Step{Kind: kind, Desc: desc, Optional: optional, run: run})
+}
+
+// Skip records something the operation deliberately leaves alone
+func (p *Plan) Skip(desc string) {
+	p.Steps = append(p.Steps, Step{Kind: StepSkip, Desc: desc})
+}
+
+// PushBytes is the total size of the planned pushes
+func (p *Plan) PushBytes() int64 {
+	var n int64
+	for _, s := range p.Steps {
+		if s.Kind == StepPush {
+			n += s.Size
+		}
+	}
+	return n
+}
+
+// Print writes the plan, one step per line
+func (p *Plan) Print(w io.Writer) {
+	fmt.Fprintf(w, "Plan: %s (%d steps)\n", p.Title, len(p.Steps))
+	for _, s := range p.Steps {
+		var detail string
+		switch {
+		case s.Kind == StepPush:
+			size := "generated"
+			if s.Size > 0 {
+				size = rootfs.FormatSize(s.Size)
+			}
+			detail = fmt.Sprintf("%s → %s (%s)", s.Local, s.Remote, size)
+		case s.Cmd != "":
+			detail = s.Cmd
+		default:
+			detail = strings.TrimSpace(strings.TrimSuffix(s.Desc, "..."))
+		}
+		if s.Optional {
+			detail += "  [best effort]"
+		}
+		fmt.Fprintf(w, "  %-9s %s\n", s.Kind, detail)
+	}
+	if n := p.PushBytes(); n > 0 {
+		fmt.Fprintf(w, "  Total to push: %s\n", rootfs.FormatSize(n))
+	}
+}
+
+// Execute runs the steps in order, stopping at the first required failure
+func (p *Plan) Execute(ctx context.Context) error {
+	for _, s := range p.Steps {
+		if err := s.execute(ctx); err != nil {
+			if s.Optional {
+				fmt.Printf("  ⚠ %v\n", err)
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s Step) execute(ctx context.Context) error {
+	if s.Desc != "" {
+		fmt.Println(s.Desc)
+	}
+	switch {
+	case s.run != nil:
+		return s.run(ctx)
+	case s.Cmd == "":
+		return nil
+	case s.Optional:
+		device.RunShellCommandQuick(ctx, s.Cmd)
+		return nil
+	case s.Checked:
+		out, err := device.RunShellCommand(ctx, fmt.Sprintf("{ %s; } && echo OK", s.Cmd))
+		if err == nil && !strings.HasSuffix(out, "OK") {
+			err = fmt.Errorf("exited non-zero %s", strings.TrimSpace(out))
+		}
+		if err != nil {
+			return fmt.Errorf("%s failed: %w", s.Cmd, err)
+		}
+		return nil
+	default:
+		if _, err := device.RunShellCommand(ctx, s.Cmd); err != nil {
+			return fmt.Errorf("%s failed: %w", s.Cmd, err)
+		}
+		return nil
+	}
+}
+
+// runPlan prints p and stops under --dry-run, otherwise executes it.
+// ran is false for a dry run, so callers skip their success output.
+func runPlan(ctx context.Context, p *Plan) (ran bool, err error) {
+	if DryRun {
+		p.Print(os.Stdout)
+		fmt.Println("\nDry run - nothing was changed.")
+		return false, nil
+	}
+	return true, p.Execute(ctx)
+}
@@ -0,0 +1,120 @@
+package common
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/sovereign/internal/device"
+)
+
+// readOnlyCmds are the device commands a dry run may issue: the checks a
+// plan is built from
+var readOnlyCmds = []string{"[ -e ", "[ -d ", "ps -ef", "pgrep ", "cat ", "stat ", "command -v ", "df ",
+	SupervisorDevicePath + " ctl status"}
+
+// fakeVM answers the checks for a VM in the given state
+func fakeVM(running, paused bool) *device.FakeTransport {
+	fake := device.NewFakeTransport()
+	fake.Handler = func(cmd string) (string, error) {
+		switch {
+		case strings.HasPrefix(cmd, "ps -ef"):
+			if running {
+				return "4242", nil
+			}
+		case strings.HasPrefix(cmd, "[ -e ") && strings.Contains(cmd, "vm.paused"):
+			if paused {
+				return "yes", nil
+			}
+		case strings.HasPrefix(cmd, "[ -e "), strings.HasPrefix(cmd, "[ -d "):
+			return "yes", nil
+		case strings.HasPrefix(cmd, "stat -c"):
+			return "1073741824", nil
+		case strings.HasPrefix(cmd, "command -v"):
+			return "/system/bin/tool", nil
+		case strings.HasPrefix(cmd, "df -k"):
+			return "/dev/block/dm-1 100000000 1000 90000000 1% /data", nil
+		}
+		return "", nil
+	}
+	return fake
+}
+
+// withHostState points the lease and state files at a temp dir
+func withHostState(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	oldLease, oldState := LeaseFile, StateFile
+	LeaseFile, StateFile = filepath.Join(dir, ".ipam.json"), filepath.Join(dir, "state.json")
+	t.Cleanup(func() { LeaseFile, StateFile = oldLease, oldState })
+	return dir
+}
+
+func TestDryRunDoesNotMutate(t *testing.T) {
+	cfg := &VMConfig{Name: "dry", DisplayName: "Dry", DevicePath: "/data/sovereign/vm/dry",
+		ProcessPattern: "crosvm.*dry", TAPInterface: "vm_dry", ServicePorts: []int{8080}, TAPSubnet: DefaultSubnet}
+	tests := []struct {
+		name            string
+		running, paused bool
+		op              func(ctx context.Context) error
+	}{
+		{"start", false, false, func(ctx context.Context) error { return StartVM(ctx, cfg) }},
+		{"stop", true, false, func(ctx context.Context) error { return StopVM(ctx, cfg) }},
+		{"pause", true, false, func(ctx context.Context) error { return PauseVM(ctx, cfg) }},
+		{"resume", true, true, func(ctx context.Context) error { return ResumeVM(ctx, cfg) }},
+		{"resume stopped", false, true, func(ctx context.Context) error { ResumeVM(ctx, cfg); return nil }},
+		{"resize-data", false, false, func(ctx context.Context) error { return ResizeData(ctx, cfg, "2G") }},
+		{"remove", true, false, func(ctx context.Context) error { return RemoveVM(ctx, cfg) }},
+	}
+
+	DryRun = true
+	defer func() { DryRun = false }()
+	old := device.CurrentTransport()
+	defer device.SetTransport(old)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := withHostState(t)
+			fake := fakeVM(tt.running, tt.paused)
+			device.SetTransport(fake)
+
+			if err := tt.op(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			for _, cmd := range fake.Commands {
+				readOnly := false
+				for _, prefix := range readOnlyCmds {
+					readOnly = readOnly || strings.HasPrefix(cmd, prefix)
+				}
+				if !readOnly {
+					t.Errorf("dry run issued %q", cmd)
+				}
+			}
+			if len(fake.Files) != 0 || len(fake.Stdin) != 0 {
+				t.Errorf("dry run pushed files %v", fake.Files)
+			}
+			entries, _ := os.ReadDir(dir)
+			for _, e := range entries {
+				t.Errorf("dry run wrote %s on the host", e.Name())
+			}
+		})
+	}
+}
+
+func TestFixVMRefusesDryRun(t *testing.T) {
+	DryRun = true
+	defer func() { DryRun = false }()
+	fake := fakeVM(true, false)
+	old := device.CurrentTransport()
+	device.SetTransport(fake)
+	defer device.SetTransport(old)
+
+	if _, err := FixVM(context.Background(), &VMConfig{Name: "dry"}); err == nil || !strings.Contains(err.Error(), "diagnose") {
+		t.Fatalf("err = %v, want a pointer to diagnose", err)
+	}
+	if len(fake.Commands) != 0 {
+		t.Errorf("fix issued %q", fake.Commands)
+	}
+}
@@ -0,0 +1,293 @@
+// Readiness probes - decide when a started VM is actually serving
+// TEAM_053: Replaces matching ReadyMarker/"INIT COMPLETE" in console.log.
+// Vaultwarden printed INIT COMPLETE while its HTTPS listener was still
+// failing; the marker is now only a progress hint in StreamBootLogs.
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/secrets"
+)
+
+// ProbeType selects how a ReadinessProbe checks the guest
+type ProbeType string
+
+const (
+	ProbeTCP      ProbeType = "tcp"      // Connect to TAPGuestIP:Port from the device
+	ProbeHTTP     ProbeType = "http"     // GET Path: http over TAP, https over Tailscale (device has no TLS client)
+	ProbePostgres ProbeType = "postgres" // psql SELECT 1: on the device over TAP, else from the host over Tailscale
+	ProbeExec     ProbeType = "exec"     // Command in the guest via `tailscale ssh` (guest needs `tailscale up --ssh`)
+)
+
+// ReadinessProbe is one check that must pass before a VM counts as started
+type ReadinessProbe struct {
+	Type         ProbeType
+	Port         int    // tcp: required; http: scheme default; postgres: 5432
+	Path         string // http: default "/"
+	Scheme       string // http: "http" (default) or "https"
+	ExpectStatus []int  // http: accepted status codes, default [200]
+	User         string // postgres: default "postgres"
+	Command      string // exec: ready when it exits 0
+}
+
+// Normalize fills defaults and validates the probe
+func (p ReadinessProbe) Normalize() (ReadinessProbe, error) {
+	switch p.Type {
+	case ProbeTCP:
+		if p.Port == 0 {
+			return p, fmt.Errorf("tcp probes need a port")
+		}
+	case ProbeHTTP:
+		if p.Scheme == "" {
+			p.Scheme = "http"
+		}
+		if p.Scheme != "http" && p.Scheme != "https" {
+			return p, fmt.Errorf("scheme must be http or https, got %q", p.Scheme)
+		}
+		if p.Port == 0 {
+			p.Port = 80
+			if p.Scheme == "https" {
+				p.Port = 443
+			}
+		}
+		if p.Path == "" {
+			p.Path = "/"
+		}
+		// The path is spliced into a shell printf on the device
+		if !strings.HasPrefix(p.Path, "/") || strings.ContainsAny(p.Path, "'\\% \t\r\n") {
+			return p, fmt.Errorf("path %q must start with / and contain no quotes, %%, backslashes or spaces", p.Path)
+		}
+		if len(p.ExpectStatus) == 0 {
+			p.ExpectStatus = []int{200}
+		}
+	case ProbePostgres:
+		if p.Port == 0 {
+			p.Port = 5432
+		}
+		if p.User == "" {
+			p.User = "postgres"
+		}
+	case ProbeExec:
+		if strings.TrimSpace(p.Command) == "" {
+			return p, fmt.Errorf("exec probes need a command")
+		}
+	default:
+		return p, fmt.Errorf("type must be tcp, http, postgres or exec, got %q", p.Type)
+	}
+	if p.Port < 0 || p.Port > 65535 {
+		return p, fmt.Errorf("port %d out of range", p.Port)
+	}
+	return p, nil
+}
+
+// String describes the probe for progress output
+func (p ReadinessProbe) String() string {
+	switch p.Type {
+	case ProbeHTTP:
+		return fmt.Sprintf("%s GET :%d%s", p.Scheme, p.Port, p.Path)
+	case ProbePostgres:
+		return fmt.Sprintf("postgres SELECT 1 on :%d", p.Port)
+	case ProbeExec:
+		return fmt.Sprintf("exec %q", p.Command)
+	}
+	return fmt.Sprintf("tcp :%d", p.Port)
+}
+
+// viaTAP reports whether the probe runs from the device over the bridge
+func (p ReadinessProbe) viaTAP() bool {
+	return p.Type == ProbeTCP || p.Type == ProbePostgres || (p.Type == ProbeHTTP && p.Scheme == "http")
+}
+
+// ReadinessProbes returns cfg's probes with defaults applied. VMs without
+// probes get a TCP probe on their first service port. An invalid probe is an
+// error: dropping it could leave nothing to wait for.
+func ReadinessProbes(cfg *VMConfig) ([]ReadinessProbe, error) {
+	probes := cfg.ReadinessProbes
+	if len(probes) == 0 && len(cfg.ServicePorts) > 0 {
+		probes = []ReadinessProbe{{Type: ProbeTCP, Port: cfg.ServicePorts[0]}}
+	}
+	out := make([]ReadinessProbe, 0, len(probes))
+	for i, p := range probes {
+		n, err := p.Normalize()
+		if err != nil {
+			return nil, fmt.Errorf("%s: readiness probe #%d: %w", cfg.Name, i+1, err)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// readyPort is the port dependents can poll over TAP: the first TAP probe's
+// port, else the first service port
+func readyPort(cfg *VMConfig) int {
+	probes, _ := ReadinessProbes(cfg)
+	for _, p := range probes {
+		if p.viaTAP() {
+			return p.Port
+		}
+	}
+	if len(cfg.ServicePorts) > 0 {
+		return cfg.ServicePorts[0]
+	}
+	return 0
+}
+
+// Check runs the probe once; nil means ready
+func (p ReadinessProbe) Check(ctx context.Context, cfg *VMConfig) error {
+	ip := cfg.TAPGuestIP
+	if ip == "" {
+		ip = LeasedIP(cfg.Name)
+	}
+	if p.viaTAP() && ip == "" {
+		return fmt.Errorf("no guest IP assigned")
+	}
+
+	switch p.Type {
+	case ProbeTCP:
+		return tcpProbe(ctx, ip, p.Port)
+	case ProbeHTTP:
+		return p.checkHTTP(ctx, cfg, ip)
+	case ProbePostgres:
+		return p.checkPostgres(ctx, cfg, ip)
+	case ProbeExec:
+		return p.checkExec(ctx, cfg)
+	}
+	return fmt.Errorf("unknown probe type %q", p.Type)
+}
+
+// tcpProbe connects from the device, like checkDependency
+func tcpProbe(ctx context.Context, ip string, port int) error {
+	out, _ := device.RunShellCommand(ctx, device.Quote("nc", "-z", "-w", "2", ip, strconv.Itoa(port))+" && echo OPEN")
+	if out != "OPEN" {
+		return fmt.Errorf("%s:%d not accepting connections", ip, port)
+	}
+	return nil
+}
+
+// checkHTTP sends a raw HTTP/1.0 request with nc on the device, or curls the
+// Tailscale FQDN from the host for https
+func (p ReadinessProbe) checkHTTP(ctx context.Context, cfg *VMConfig, ip string) error {
+	var code string
+	if p.Scheme == "http" {
+		req := fmt.Sprintf("GET %s HTTP/1.0\r\nHost: %s\r\n\r\n", p.Path, ip)
+		out, _ := device.RunShellCommand(ctx, device.Quote("printf", "%s", req)+" | "+
+			device.Quote("nc", "-w", "3", ip, strconv.Itoa(p.Port))+" 2>/dev/null | head -1")
+		// "HTTP/1.1 200 OK"
+		if fields := strings.Fields(out); len(fields) >= 2 && strings.HasPrefix(fields[0], "HTTP/") {
+			code = fields[1]
+		}
+	} else {
+		fqdn := GetTailscaleFQDN(ctx, cfg)
+		if fqdn == "" {
+			return fmt.Errorf("cannot determine Tailscale FQDN")
+		}
+		host := fqdn
+		if p.Port != 443 {
+			host = fmt.Sprintf("%s:%d", fqdn, p.Port)
+		}
+		cmd := exec.CommandContext(ctx, "curl", "-sk", "-o", "/dev/null", "-w", "%{http_code}",
+			"--connect-timeout", "5", "--max-time", "10", fmt.Sprintf("https://%s%s", host, p.Path))
+		output, _ := cmd.Output()
+		code = strings.TrimSpace(string(output))
+	}
+	n, _ := strconv.Atoi(code)
+	if !slices.Contains(p.ExpectStatus, n) {
+		if code == "" || code == "000" {
+			return fmt.Errorf("no HTTP response on :%d%s", p.Port, p.Path)
+		}
+		return fmt.Errorf("HTTP %s on :%d%s, want %v", code, p.Port, p.Path, p.ExpectStatus)
+	}
+	return nil
+}
+
+// errDegraded marks a probe that passed on a weaker check than it asks for
+type errDegraded struct{ reason string }
+
+func (e errDegraded) Error() string { return e.reason }
+
+// checkPostgres runs SELECT 1 with psql. Android images rarely ship psql, so
+// the host is tried next over Tailscale; with neither, an open port passes
+// the probe as degraded.
+func (p ReadinessProbe) checkPostgres(ctx context.Context, cfg *VMConfig, ip string) error {
+	password := "sovereign"
+	if creds, err := secrets.LoadSecretsFile(); err == nil {
+		password = creds.DBPassword
+	}
+
+	out, err := DevicePsql(ctx, ip, p.Port, p.User, password, "-tAc", "SELECT 1")
+	if !errors.Is(err, ErrNoDevicePsql) {
+		if out == "1" {
+			return nil
+		}
+		if err != nil && out == "" {
+			return err
+		}
+		return fmt.Errorf("SELECT 1 failed: %s", lastLine(out))
+	}
+
+	if cfg.TailscaleHost != "" {
+		cmd := exec.CommandContext(ctx, "psql", "-h", cfg.TailscaleHost, "-p", strconv.Itoa(p.Port), "-U", p.User, "-tAc", "SELECT 1")
+		cmd.Env = append(os.Environ(), "PGPASSWORD="+password, "PGCONNECT_TIMEOUT=3")
+		output, err := cmd.CombinedOutput()
+		if !errors.Is(err, exec.ErrNotFound) {
+			if err == nil && strings.TrimSpace(string(output)) == "1" {
+				return nil
+			}
+			return fmt.Errorf("SELECT 1 failed: %s", lastLine(string(output)))
+		}
+	}
+	if err := tcpProbe(ctx, ip, p.Port); err != nil {
+		return err
+	}
+	return errDegraded{"port open, SELECT 1 not verified - no psql on the device or host"}
+}
+
+// checkExec runs the command inside the guest over Tailscale SSH
+func (p ReadinessProbe) checkExec(ctx context.Context, cfg *VMConfig) error {
+	host := GetTailscaleFQDN(ctx, cfg)
+	if host == "" {
+		return fmt.Errorf("cannot determine Tailscale FQDN")
+	}
+	cmd := exec.CommandContext(ctx, "tailscale", "ssh", "root@"+host, p.Command)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%q: %v %s", p.Command, err, lastLine(string(output)))
+	}
+	return nil
+}
+
+// lastLine returns the last non-empty line of command output
+func lastLine(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// CheckReadiness runs every probe once and returns the first failure.
+// Probes that only passed a weaker check are returned as warnings.
+func CheckReadiness(ctx context.Context, cfg *VMConfig) (degraded []string, err error) {
+	probes, err := ReadinessProbes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range probes {
+		err := p.Check(ctx, cfg)
+		var d errDegraded
+		if errors.As(err, &d) {
+			degraded = append(degraded, fmt.Sprintf("%s: %s", p, d.reason))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+	}
+	return degraded, nil
+}
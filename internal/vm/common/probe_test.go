@@ -0,0 +1,88 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/sovereign/internal/device"
+)
+
+func TestReadinessProbeNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		probe   ReadinessProbe
+		want    ReadinessProbe
+		wantErr string
+	}{
+		{"tcp needs port", ReadinessProbe{Type: ProbeTCP}, ReadinessProbe{}, "need a port"},
+		{"http defaults", ReadinessProbe{Type: ProbeHTTP},
+			ReadinessProbe{Type: ProbeHTTP, Scheme: "http", Port: 80, Path: "/", ExpectStatus: []int{200}}, ""},
+		{"https port", ReadinessProbe{Type: ProbeHTTP, Scheme: "https", Path: "/alive"},
+			ReadinessProbe{Type: ProbeHTTP, Scheme: "https", Port: 443, Path: "/alive", ExpectStatus: []int{200}}, ""},
+		{"http bad scheme", ReadinessProbe{Type: ProbeHTTP, Scheme: "ftp"}, ReadinessProbe{}, "scheme"},
+		{"http path with space", ReadinessProbe{Type: ProbeHTTP, Path: "/a b"}, ReadinessProbe{}, "path"},
+		{"postgres defaults", ReadinessProbe{Type: ProbePostgres},
+			ReadinessProbe{Type: ProbePostgres, Port: 5432, User: "postgres"}, ""},
+		{"exec needs command", ReadinessProbe{Type: ProbeExec, Command: " "}, ReadinessProbe{}, "command"},
+		{"unknown type", ReadinessProbe{Type: "icmp"}, ReadinessProbe{}, "type must be"},
+		{"port range", ReadinessProbe{Type: ProbeTCP, Port: 70000}, ReadinessProbe{}, "out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.probe.Normalize()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want.String() || got.User != tt.want.User || len(got.ExpectStatus) != len(tt.want.ExpectStatus) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TEAM_053: Invalid probes used to be dropped, leaving nothing to wait for
+func TestReadinessProbesRejectsInvalid(t *testing.T) {
+	cfg := &VMConfig{Name: "probe-bad", ReadinessProbes: []ReadinessProbe{{Type: ProbeTCP}}}
+	if _, err := ReadinessProbes(cfg); err == nil {
+		t.Fatal("invalid probe accepted")
+	}
+	if _, err := CheckReadiness(context.Background(), cfg); err == nil {
+		t.Fatal("VM with only invalid probes counted as ready")
+	}
+
+	cfg = &VMConfig{Name: "probe-default", ServicePorts: []int{8080}}
+	probes, err := ReadinessProbes(cfg)
+	if err != nil || len(probes) != 1 || probes[0].Type != ProbeTCP || probes[0].Port != 8080 {
+		t.Fatalf("default probe = %+v, %v", probes, err)
+	}
+}
+
+func TestPostgresProbeWithoutPsqlIsDegraded(t *testing.T) {
+	fake := device.NewFakeTransport().On("nc -z", "OPEN", nil)
+	old := device.CurrentTransport()
+	device.SetTransport(fake)
+	defer device.SetTransport(old)
+
+	cfg := &VMConfig{Name: "probe-pg", TAPGuestIP: "192.168.100.2",
+		ReadinessProbes: []ReadinessProbe{{Type: ProbePostgres}}}
+	degraded, err := CheckReadiness(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(degraded) != 1 || !strings.Contains(degraded[0], "SELECT 1 not verified") {
+		t.Fatalf("degraded = %q, want the psql warning", degraded)
+	}
+
+	// A closed port still fails
+	device.SetTransport(device.NewFakeTransport())
+	if _, err := CheckReadiness(context.Background(), cfg); err == nil {
+		t.Fatal("closed port passed")
+	}
+}
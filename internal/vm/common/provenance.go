@@ -0,0 +1,173 @@
+// Build provenance - what each rootfs was built from and what is on the phone
+// TEAM_056: BuildRootfs writes build-manifest.json next to rootfs.img,
+// DeployVM pushes it to the device, and status/diagnose read it back.
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/docker"
+	"github.com/anthropics/sovereign/internal/report"
+	"github.com/anthropics/sovereign/internal/rootfs"
+)
+
+const buildManifestFile = "build-manifest.json"
+
+// BuildManifest records what a rootfs image was built from
+type BuildManifest struct {
+	VM           string           `json:"vm"`
+	DockerImage  string           `json:"docker_image"`
+	ImageDigest  string           `json:"image_digest"` // docker image ID (sha256:...)
+	BaseImages   []string         `json:"base_images"`  // FROM lines, with registry digests when known
+	Packages     []rootfs.Package `json:"packages"`     // From the apk database in the rootfs
+	KernelSHA256 string           `json:"kernel_sha256"`
+	InitSHA256   string           `json:"init_sh_sha256"`
+	InputsKey    string           `json:"inputs_key"` // Build cache key
+	BuildHost    string           `json:"build_host"`
+	BuiltAt      time.Time        `json:"built_at"`
+}
+
+// Summary is a one-line description for status output
+func (m *BuildManifest) Summary() string {
+	return fmt.Sprintf("built %s on %s, image %s, kernel %s, %d packages",
+		m.BuiltAt.Local().Format("2006-01-02 15:04"), m.BuildHost,
+		shortDigest(m.ImageDigest), shortDigest(m.KernelSHA256), len(m.Packages))
+}
+
+// shortDigest trims "sha256:" and keeps 12 hex digits
+func shortDigest(d string) string {
+	d = strings.TrimPrefix(d, "sha256:")
+	if len(d) > 12 {
+		return d[:12]
+	}
+	if d == "" {
+		return "unknown"
+	}
+	return d
+}
+
+var fromLine = regexp.MustCompile(`(?i)^\s*FROM\s+(?:--platform=\S+\s+)?(\S+)`)
+
+// baseImages returns the Dockerfile's FROM images, resolved to the registry
+// digest docker pulled when it knows one
+func baseImages(dockerfile string) []string {
+	data, err := os.ReadFile(dockerfile)
+	if err != nil {
+		return nil
+	}
+	var images []string
+	for _, line := range strings.Split(string(data), "\n") {
+		m := fromLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if digest := docker.RepoDigest(m[1]); digest != "" {
+			images = append(images, fmt.Sprintf("%s (%s)", m[1], digest))
+		} else {
+			images = append(images, m[1])
+		}
+	}
+	return images
+}
+
+// writeBuildManifest records a fresh build. rootfsTar is the docker export
+// the image was made from.
+func writeBuildManifest(cfg *VMConfig, in BuildInputs, rootfsTar string) error {
+	m := &BuildManifest{
+		VM:           cfg.Name,
+		DockerImage:  cfg.DockerImage,
+		BaseImages:   baseImages(filepath.Join(cfg.LocalPath, "Dockerfile")),
+		KernelSHA256: in.Kernel,
+		InitSHA256:   in.InitScript,
+		InputsKey:    in.Key(),
+		BuiltAt:      time.Now().UTC(),
+	}
+	var err error
+	if m.ImageDigest, err = docker.ImageID(cfg.DockerImage); err != nil {
+		fmt.Printf("  ⚠ %v\n", err)
+	}
+	if m.Packages, err = rootfs.Packages(rootfsTar); err != nil {
+		return fmt.Errorf("listing packages: %w", err)
+	}
+	if m.BuildHost, err = os.Hostname(); err != nil {
+		m.BuildHost = "unknown"
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(cfg.LocalPath, buildManifestFile)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("  ✓ Wrote %s (%d packages)\n", path, len(m.Packages))
+	return nil
+}
+
+// LocalBuild reads the manifest of the last local build
+func LocalBuild(cfg *VMConfig) (*BuildManifest, error) {
+	data, err := os.ReadFile(filepath.Join(cfg.LocalPath, buildManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m BuildManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", buildManifestFile, err)
+	}
+	return &m, nil
+}
+
+// DeployedBuild reads the manifest DeployVM pushed with the running images
+func DeployedBuild(ctx context.Context, cfg *VMConfig) (*BuildManifest, error) {
+	return readDeviceManifest(ctx, fmt.Sprintf("%s/%s", cfg.DevicePath, buildManifestFile))
+}
+
+// readDeviceManifest parses a build manifest on the device
+func readDeviceManifest(ctx context.Context, path string) (*BuildManifest, error) {
+	out, err := device.RunShellCommand(ctx, device.Quote("cat", path)+" 2>/dev/null")
+	if err != nil || out == "" {
+		return nil, fmt.Errorf("no %s on device (deployed before provenance was recorded?)", path)
+	}
+	var m BuildManifest
+	if err := json.Unmarshal([]byte(out), &m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// addProvenance adds what is deployed for cfg, and whether the local build
+// has moved on, for status and diagnose
+func addProvenance(ctx context.Context, s *DiagnosticSection, cfg *VMConfig) {
+	deployed, err := DeployedBuild(ctx, cfg)
+	if err != nil {
+		s.add(report.StatusWarn, "%v", err)
+		return
+	}
+	s.add(report.StatusInfo, "Deployed: %s", deployed.Summary())
+	for _, base := range deployed.BaseImages {
+		s.add(report.StatusInfo, "Base image: %s", base)
+	}
+	if local, err := LocalBuild(cfg); err == nil && local.InputsKey != deployed.InputsKey {
+		s.add(report.StatusWarn, "Local build differs (%s) - run 'sovereign deploy --%s'", local.Summary(), cfg.Name)
+	}
+}
+
+// planBuildManifest adds staging the local manifest in rel. Builds from
+// before provenance existed have none, and the release's manifest link
+// dangles so status doesn't describe the wrong images.
+func planBuildManifest(ctx context.Context, p *Plan, cfg *VMConfig, st *deployStats, rel *release) error {
+	local := filepath.Join(cfg.LocalPath, buildManifestFile)
+	if _, err := os.Stat(local); err != nil {
+		return fmt.Errorf("%s not found - rebuild to record provenance", local)
+	}
+	return st.planPush(ctx, p, buildManifestFile, local, rel.path(buildManifestFile), rel.base[buildManifestFile])
+}
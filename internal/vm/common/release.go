@@ -0,0 +1,296 @@
+// Versioned releases on the device
+// TEAM_062: Deploy used to overwrite rootfs.img, Image and start.sh in place,
+// so a push that died halfway left the VM unbootable with nothing to go back
+// to. Each deploy now fills releases/<id>/ and, once verified, flips the
+// `current` symlink. DevicePath/rootfs.img etc. are symlinks through current,
+// so start.sh, sovereign_start.sh and the supervisor see the same paths.
+// data.img and .env carry state and stay outside the releases.
+package common
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/supervisor"
+)
+
+// KeepReleases is how many releases per VM deploy keeps on the device
+// TEAM_062: Package-level flag for --keep-releases
+var KeepReleases = 3
+
+// releaseFiles make up a release; DevicePath/<f> links to current/<f>
+var releaseFiles = []string{"rootfs.img", "Image", "start.sh", buildManifestFile}
+
+const partialSuffix = ".partial" // A release still being pushed
+
+// Release is one release directory on the device
+type Release struct {
+	ID      string
+	Current bool
+	Build   *BuildManifest // nil for builds from before provenance
+}
+
+// release is a release being staged by DeployVM
+type release struct {
+	cfg  *VMConfig
+	id   string
+	base map[string]string // File -> what the device runs now, for delta pushes
+}
+
+func releasesDir(cfg *VMConfig) string {
+	return cfg.DevicePath + "/releases"
+}
+
+// newRelease picks the next release's ID and finds the running files it can
+// reuse. planStage creates its directory.
+func newRelease(ctx context.Context, cfg *VMConfig) *release {
+	stamp := time.Now().UTC().Format("20060102-150405")
+	id := stamp
+	for n := 2; device.DirExists(ctx, releasesDir(cfg)+"/"+id); n++ {
+		id = fmt.Sprintf("%s-%d", stamp, n) // Two deploys in one second
+	}
+	r := &release{cfg: cfg, id: id, base: map[string]string{}}
+	// Unchanged files are hard-linked from the running release instead of
+	// pushed. Before the first release these are the plain files.
+	for _, f := range releaseFiles {
+		if p, _ := device.RunShellCommand(ctx, device.Quote("readlink", "-f", cfg.DevicePath+"/"+f)+" 2>/dev/null"); p != "" {
+			r.base[f] = p
+		}
+	}
+	return r
+}
+
+// planStage adds creating the release's staging directory
+func (r *release) planStage(p *Plan) {
+	p.Checked(StepShell, "", device.Quote("mkdir", "-p", r.dir()))
+}
+
+func (r *release) dir() string {
+	return releasesDir(r.cfg) + "/" + r.id + partialSuffix
+}
+
+// path returns where file f is staged
+func (r *release) path(f string) string {
+	return r.dir() + "/" + f
+}
+
+// verify checks every staged file against the local sha256 recorded when it
+// was pushed or linked
+func (r *release) verify(ctx context.Context, st *deployStats) error {
+	fmt.Printf("Verifying release %s...\n", r.id)
+	for _, f := range releaseFiles {
+		want, ok := st.sums[r.path(f)]
+		if !ok {
+			continue // No build manifest
+		}
+		got, err := remoteSHA256(ctx, r.path(f))
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return fmt.Errorf("release %s: %s on device does not match the local file - current release left in place", r.id, f)
+		}
+	}
+	fmt.Println("  ✓ All files match")
+	return nil
+}
+
+// planCommit adds renaming the verified release into place and making it
+// current
+func (r *release) planCommit(p *Plan) {
+	final := releasesDir(r.cfg) + "/" + r.id
+	p.Checked(StepShell, fmt.Sprintf("Making release %s current...", r.id), device.Quote("mv", r.dir(), final))
+	for _, cmd := range activateCmds(r.cfg, r.id) {
+		p.Checked(StepShell, "", cmd)
+	}
+}
+
+// abandon removes a release that failed to stage
+func (r *release) abandon() {
+	device.RunShellCommandQuick(context.Background(), device.Quote("rm", "-rf", r.dir()))
+}
+
+// activateCmds point current at releases/<id>. The new symlink is renamed
+// over the old one, so a reader sees one release or the other (toybox
+// without mv -T falls back to rm + mv). The second command replaces the
+// plain files of a pre-release deploy with links and is a no-op afterwards.
+func activateCmds(cfg *VMConfig, id string) []string {
+	cd := device.Quote("cd", cfg.DevicePath)
+	links := make([]string, 0, len(releaseFiles))
+	for _, f := range releaseFiles {
+		links = append(links, device.Quote("ln", "-sfn", "current/"+f, f))
+	}
+	return []string{
+		cd + " && " + device.Quote("ln", "-sfn", "releases/"+id, "current.new") + " && " +
+			"{ mv -fT current.new current 2>/dev/null || { rm -f current && mv current.new current; }; }",
+		cd + " && " + strings.Join(links, " && "),
+	}
+}
+
+// currentRelease returns the ID current points at, "" before the first release
+func currentRelease(ctx context.Context, cfg *VMConfig) string {
+	out, _ := device.RunShellCommand(ctx, device.Quote("readlink", cfg.DevicePath+"/current")+" 2>/dev/null")
+	if out == "" {
+		return ""
+	}
+	return path.Base(out)
+}
+
+// releaseIDs lists finished releases, oldest first (IDs sort by time)
+func releaseIDs(ctx context.Context, cfg *VMConfig) []string {
+	out, _ := device.RunShellCommand(ctx, device.Quote("ls", "-1", releasesDir(cfg))+" 2>/dev/null")
+	var ids []string
+	for _, id := range strings.Fields(out) {
+		if !strings.HasSuffix(id, partialSuffix) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ListReleases returns cfg's releases on the device, oldest first
+func ListReleases(ctx context.Context, cfg *VMConfig) []Release {
+	cur := currentRelease(ctx, cfg)
+	var rels []Release
+	for _, id := range releaseIDs(ctx, cfg) {
+		r := Release{ID: id, Current: id == cur}
+		r.Build, _ = readDeviceManifest(ctx, fmt.Sprintf("%s/%s/%s", releasesDir(cfg), id, buildManifestFile))
+		rels = append(rels, r)
+	}
+	return rels
+}
+
+// planPrune adds removing all but the newest KeepReleases releases once
+// next is current, and staging directories left by failed deploys
+func planPrune(ctx context.Context, p *Plan, cfg *VMConfig, next string) {
+	keep := KeepReleases
+	if keep < 1 {
+		keep = 1
+	}
+	ids := append(releaseIDs(ctx, cfg), next)
+	var old []string
+	for i, id := range ids {
+		if i < len(ids)-keep && id != next {
+			old = append(old, releasesDir(cfg)+"/"+id)
+		}
+	}
+	p.Cleanup(StepShell, "rm -rf "+device.Quote(releasesDir(cfg))+"/*"+partialSuffix) // Glob left unquoted
+	if len(old) > 0 {
+		p.Add(Step{Kind: StepShell, Desc: fmt.Sprintf("  Pruning %d old release(s), keeping %d", len(old), keep),
+			Cmd: device.Quote(append([]string{"rm", "-rf"}, old...)...), Optional: true})
+	}
+}
+
+// RollbackVM points cfg back at release ("" = the one before current) and
+// restarts the VM if it was running. data.img is not rolled back.
+func RollbackVM(ctx context.Context, cfg *VMConfig, release string) error {
+	fmt.Printf("=== Rolling back %s VM ===\n", cfg.DisplayName)
+
+	// TEAM_065: One mutating operation per VM and device at a time
+	unlock, err := lockVM(ctx, cfg, "rollback")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	rels := ListReleases(ctx, cfg)
+	if len(rels) == 0 {
+		return fmt.Errorf("no releases of %s on device - redeploy with 'sovereign deploy --%s' to start keeping them", cfg.Name, cfg.Name)
+	}
+	cur := -1
+	for i, r := range rels {
+		if r.Current {
+			cur = i
+		}
+	}
+
+	var target *Release
+	if release == "" {
+		switch {
+		case cur == -1:
+			target = &rels[len(rels)-1]
+		case cur == 0:
+			return fmt.Errorf("%s is the oldest release on the device - nothing to roll back to", rels[cur].ID)
+		default:
+			target = &rels[cur-1]
+		}
+	} else {
+		for i := range rels {
+			if rels[i].ID == release {
+				target = &rels[i]
+			}
+		}
+		if target == nil {
+			ids := make([]string, len(rels))
+			for i, r := range rels {
+				ids[i] = r.ID
+			}
+			return fmt.Errorf("no release %q on device (have: %s)", release, strings.Join(ids, ", "))
+		}
+	}
+	if target.Current {
+		fmt.Printf("✓ %s is already the current release\n", target.ID)
+		return nil
+	}
+	for _, f := range []string{"rootfs.img", "Image", "start.sh"} {
+		if p := fmt.Sprintf("%s/%s/%s", releasesDir(cfg), target.ID, f); !device.FileExists(ctx, p) {
+			return fmt.Errorf("release %s is incomplete: %s missing", target.ID, p)
+		}
+	}
+
+	running := processPID(ctx, cfg) != ""
+	if st, ok := supervisedStatus(ctx, cfg); ok && st.State != supervisor.StateStopped && st.State != supervisor.StateFailed {
+		running = true
+	}
+	from := "no release"
+	if cur >= 0 {
+		from = rels[cur].ID
+	}
+
+	// TEAM_063: Planned like deploy, so --dry-run shows the switch
+	p := &Plan{Title: fmt.Sprintf("Roll back %s VM to %s", cfg.DisplayName, target.ID)}
+	var stop *stopOutcome
+	if running {
+		stop = planStop(ctx, p, cfg)
+	}
+	for i, cmd := range activateCmds(cfg, target.ID) {
+		desc := ""
+		if i == 0 {
+			desc = fmt.Sprintf("Switching current from %s to %s...", from, target.ID)
+		}
+		p.Checked(StepShell, desc, cmd)
+	}
+	if running {
+		p.Add(Step{Kind: StepShell, Cmd: "sovereign start --" + cfg.Name, run: func(ctx context.Context) error {
+			if err := stop.report(cfg); err != nil {
+				return err
+			}
+			return StartVM(ctx, cfg)
+		}})
+	}
+
+	if ran, err := runPlan(ctx, p); !ran || err != nil {
+		return err
+	}
+	recordVM(cfg, func(r *VMRecord) {
+		r.Release, r.Build = target.ID, ""
+		if target.Build != nil {
+			r.Build = target.Build.InputsKey
+		}
+	})
+	fmt.Printf("✓ %s now on release %s (was %s)\n", cfg.DisplayName, target.ID, from)
+	if target.Build != nil {
+		fmt.Printf("  Build: %s\n", target.Build.Summary())
+	}
+	fmt.Println("  data.img was not rolled back - undo any data migrations by hand")
+	if !running {
+		fmt.Printf("\nNext: sovereign start --%s\n", cfg.Name)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/sovereign/internal/device"
+)
+
+// fakeReleases fakes a stopped VM's device with the given release IDs and
+// current pointing at cur ("" = before the first release)
+func fakeReleases(ids []string, cur string) *device.FakeTransport {
+	fake := device.NewFakeTransport()
+	fake.Handler = func(cmd string) (string, error) {
+		switch {
+		case strings.HasPrefix(cmd, "mkdir -p "+DeviceLockDir), strings.HasSuffix(cmd, "&& echo OK") && strings.HasPrefix(cmd, "{ "):
+			return "OK", nil
+		case strings.HasPrefix(cmd, "ls -1 ") && strings.Contains(cmd, "/releases"):
+			return strings.Join(ids, "\n"), nil
+		case strings.HasPrefix(cmd, "readlink ") && strings.Contains(cmd, "/current"):
+			if cur != "" {
+				return "releases/" + cur, nil
+			}
+		case strings.HasPrefix(cmd, "[ -e "):
+			return "yes", nil
+		}
+		return "", nil
+	}
+	return fake
+}
+
+// switchedTo returns the release the commands pointed current at
+func switchedTo(fake *device.FakeTransport) string {
+	for _, cmd := range fake.Commands {
+		if i := strings.Index(cmd, "ln -sfn releases/"); i >= 0 {
+			return strings.Fields(cmd[i+len("ln -sfn releases/"):])[0]
+		}
+	}
+	return ""
+}
+
+func TestRollbackTarget(t *testing.T) {
+	ids := []string{"20260101-000000", "20260102-000000", "20260103-000000", "20260104-000000.partial"}
+	tests := []struct {
+		name, cur, release string
+		want               string // Release switched to, "" = none
+		wantErr            string
+	}{
+		{"previous", "20260103-000000", "", "20260102-000000", ""},
+		{"specific", "20260103-000000", "20260101-000000", "20260101-000000", ""},
+		{"no current", "", "", "20260103-000000", ""},
+		{"already current", "20260103-000000", "20260103-000000", "", ""},
+		{"oldest", "20260101-000000", "", "", "nothing to roll back to"},
+		{"unknown", "20260103-000000", "20260109-000000", "", "no release"},
+		{"partial", "20260103-000000", "20260104-000000.partial", "", "no release"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withHostState(t)
+			fake := fakeReleases(ids, tt.cur)
+			old := device.CurrentTransport()
+			device.SetTransport(fake)
+			defer device.SetTransport(old)
+
+			cfg := &VMConfig{Name: "rb", DisplayName: "Rb", DevicePath: "/data/sovereign/vm/rb", ProcessPattern: "crosvm.*rb"}
+			err := RollbackVM(context.Background(), cfg, tt.release)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if got := switchedTo(fake); got != tt.want {
+				t.Errorf("switched to %q, want %q", got, tt.want)
+			}
+			if tt.want != "" {
+				s, err := LoadState()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if d := s.Devices[device.TargetID()]; d == nil || d.VMs["rb"] == nil || d.VMs["rb"].Release != tt.want {
+					t.Errorf("state not updated to %s", tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanPrune(t *testing.T) {
+	ids := []string{"r1", "r2", "r3", "r4.partial"}
+	tests := []struct {
+		keep int
+		want []string // Releases removed
+	}{
+		{3, []string{"r1"}},
+		{2, []string{"r1", "r2"}},
+		{0, []string{"r1", "r2", "r3"}}, // At least the new release is kept
+		{10, nil},
+	}
+	old := device.CurrentTransport()
+	defer device.SetTransport(old)
+	oldKeep := KeepReleases
+	defer func() { KeepReleases = oldKeep }()
+
+	cfg := &VMConfig{Name: "rb", DevicePath: "/d"}
+	for _, tt := range tests {
+		device.SetTransport(fakeReleases(ids, "r3"))
+		KeepReleases = tt.keep
+		p := &Plan{}
+		planPrune(context.Background(), p, cfg, "r5")
+
+		var removed []string
+		partials := false
+		for _, s := range p.Steps {
+			switch {
+			case strings.Contains(s.Cmd, "*"+partialSuffix):
+				partials = true
+			case strings.HasPrefix(s.Cmd, "rm -rf "):
+				for _, dir := range strings.Fields(s.Cmd)[2:] {
+					removed = append(removed, strings.TrimPrefix(dir, "/d/releases/"))
+				}
+			}
+		}
+		if strings.Join(removed, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("keep %d: removed %v, want %v", tt.keep, removed, tt.want)
+		}
+		if !partials {
+			t.Errorf("keep %d: staging directories not cleaned up", tt.keep)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Growing a deployed data disk
+// TEAM_058: `sovereign resize-data --<vm> <size>`. data.img holds the
+// Tailscale identity and the service's data, so it is resized in place on
+// the device (e2fsck + truncate + resize2fs), never re-pushed.
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/rootfs"
+	"github.com/anthropics/sovereign/internal/supervisor"
+)
+
+var exitMarker = regexp.MustCompile(`__exit=(\d+)`)
+
+// runDeviceStep streams a long-running device command (no 30s cap) and
+// returns its exit status
+func runDeviceStep(ctx context.Context, cmd string) (int, error) {
+	var out strings.Builder
+	w := &prefixWriter{out: &out}
+	if err := device.StreamShellCommand(ctx, cmd+` 2>&1; echo "__exit=$?"`, w); err != nil && ctx.Err() != nil {
+		return -1, ctx.Err()
+	}
+	m := exitMarker.FindStringSubmatch(out.String())
+	if m == nil {
+		return -1, fmt.Errorf("no exit status from device: %s", lastLine(out.String()))
+	}
+	code, _ := strconv.Atoi(m[1])
+	return code, nil
+}
+
+// prefixWriter echoes device output indented, hiding the exit marker
+type prefixWriter struct {
+	out  *strings.Builder
+	line []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.out.Write(b)
+	for _, c := range b {
+		if c != '\n' {
+			p.line = append(p.line, c)
+			continue
+		}
+		if line := string(p.line); !strings.HasPrefix(line, "__exit=") {
+			fmt.Fprintf(os.Stdout, "    %s\n", line)
+		}
+		p.line = p.line[:0]
+	}
+	return len(b), nil
+}
+
+// ResizeData grows cfg's data.img on the device to size. The VM must be
+// stopped; shrinking is refused.
+func ResizeData(ctx context.Context, cfg *VMConfig, size string) error {
+	fmt.Printf("=== Resizing %s data disk ===\n", cfg.DisplayName)
+
+	want, err := rootfs.ParseSize(size)
+	if err != nil {
+		return err
+	}
+	// TEAM_065: Nobody starts the VM while its disk is being resized
+	unlock, err := lockVM(ctx, cfg, "resize-data")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if pid := processPID(ctx, cfg); pid != "" {
+		return fmt.Errorf("%s VM is running (PID %s) - stop it first: sovereign stop --%s", cfg.Name, pid, cfg.Name)
+	}
+	// A pending supervisor restart would boot the VM mid-resize
+	if st, ok := supervisedStatus(ctx, cfg); ok && st.State != supervisor.StateStopped && st.State != supervisor.StateFailed {
+		return fmt.Errorf("supervisor has %s in state %s - run 'sovereign stop --%s' first", cfg.Name, st.State, cfg.Name)
+	}
+
+	img := fmt.Sprintf("%s/data.img", cfg.DevicePath)
+	if !device.FileExists(ctx, img) {
+		return fmt.Errorf("%s not found on device - run 'sovereign deploy --%s' first", img, cfg.Name)
+	}
+	out, _ := device.Exec(ctx, "stat", "-c", "%s", img)
+	have, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot read size of %s: %q", img, out)
+	}
+	if want <= have {
+		return fmt.Errorf("data.img is already %s - resize-data only grows (shrinking could lose data)", rootfs.FormatSize(have))
+	}
+	fmt.Printf("  %s → %s\n", rootfs.FormatSize(have), rootfs.FormatSize(want))
+
+	for _, tool := range []string{"e2fsck", "resize2fs", "truncate"} {
+		if out, _ := device.RunShellCommand(ctx, "command -v "+device.Quote(tool)); out == "" {
+			return fmt.Errorf("%s not found on device", tool)
+		}
+	}
+	// The image is sparse, but the space has to exist once the service fills it
+	if out, _ := device.RunShellCommand(ctx, "df -k /data | tail -1"); out != "" {
+		if f := strings.Fields(out); len(f) >= 4 {
+			if avail, err := strconv.ParseInt(f[3], 10, 64); err == nil && avail*1024 < want-have {
+				fmt.Printf("  ⚠ Only %s free on /data for %s of growth\n", rootfs.FormatSize(avail*1024), rootfs.FormatSize(want-have))
+			}
+		}
+	}
+
+	// TEAM_063: Planned so --dry-run shows the commands without touching data.img
+	p := &Plan{Title: fmt.Sprintf("Resize %s data.img to %s", cfg.DisplayName, rootfs.FormatSize(want))}
+
+	// resize2fs refuses filesystems that haven't just been checked.
+	// e2fsck: 0 = clean, 1 = errors corrected, anything else = stop here.
+	fsck := device.Quote("e2fsck", "-fy", img)
+	p.Add(Step{Kind: StepShell, Desc: "Checking filesystem...", Cmd: fsck, run: func(ctx context.Context) error {
+		code, err := runDeviceStep(ctx, fsck)
+		if err == nil && code > 1 {
+			err = fmt.Errorf("e2fsck exited %d - data.img needs manual repair, not resized", code)
+		}
+		return err
+	}})
+
+	p.Checked(StepShell, "Growing image file...", device.Quote("truncate", "-s", strconv.FormatInt(want, 10), img))
+
+	grow := device.Quote("resize2fs", img)
+	p.Add(Step{Kind: StepShell, Desc: "Growing filesystem...", Cmd: grow, run: func(ctx context.Context) error {
+		code, err := runDeviceStep(ctx, grow)
+		if err == nil && code != 0 {
+			err = fmt.Errorf("resize2fs exited %d - the file is %s but the filesystem may not be; run e2fsck -f %s on the device",
+				code, rootfs.FormatSize(want), img)
+		}
+		return err
+	}})
+
+	verify := device.Quote("e2fsck", "-fn", img)
+	p.Add(Step{Kind: StepShell, Desc: "Verifying...", Cmd: verify, run: func(ctx context.Context) error {
+		code, err := runDeviceStep(ctx, verify)
+		if err == nil && code != 0 {
+			err = fmt.Errorf("e2fsck found problems after the resize (exit %d)", code)
+		}
+		return err
+	}})
+
+	if ran, err := runPlan(ctx, p); !ran || err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✓ %s data.img is now %s (Tailscale identity and data preserved)\n", cfg.DisplayName, rootfs.FormatSize(want))
+	if cfg.DataSize != size {
+		fmt.Printf("  Set DataSize/data_size = %q so a fresh deploy creates the same size\n", size)
+	}
+	fmt.Printf("\nNext: sovereign start --%s\n", cfg.Name)
+	return nil
+}
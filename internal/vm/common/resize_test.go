@@ -0,0 +1,114 @@
+package common
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/sovereign/internal/device"
+)
+
+// localDevice runs device commands in a local sh, so e2fsck, truncate and
+// resize2fs work on a real image. Locks and process checks are faked.
+func localDevice() *device.FakeTransport {
+	fake := device.NewFakeTransport()
+	fake.Handler = func(cmd string) (string, error) {
+		switch {
+		case strings.Contains(cmd, DeviceLockDir):
+			return "OK", nil
+		case strings.HasPrefix(cmd, "ps -ef"), strings.HasPrefix(cmd, SupervisorDevicePath):
+			return "", nil
+		}
+		out, err := exec.Command("sh", "-c", cmd).CombinedOutput()
+		return string(out), err
+	}
+	return fake
+}
+
+func TestResizeData(t *testing.T) {
+	for _, tool := range []string{"mke2fs", "e2fsck", "resize2fs", "truncate", "debugfs"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not installed", tool)
+		}
+	}
+	withHostState(t)
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(filepath.Join(src, "tailscale"), 0755)
+	os.WriteFile(filepath.Join(src, "tailscale", "tailscaled.state"), []byte("node key"), 0600)
+	img := filepath.Join(dir, "data.img")
+	if out, err := exec.Command("mke2fs", "-q", "-F", "-t", "ext4", "-d", src, img, "32M").CombinedOutput(); err != nil {
+		t.Fatalf("mke2fs: %v\n%s", err, out)
+	}
+
+	old := device.CurrentTransport()
+	device.SetTransport(localDevice())
+	defer device.SetTransport(old)
+
+	cfg := &VMConfig{Name: "grow", DisplayName: "Grow", DevicePath: dir, ProcessPattern: "crosvm.*grow"}
+	if err := ResizeData(context.Background(), cfg, "64M"); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(img); err != nil || info.Size() != 64<<20 {
+		t.Fatalf("data.img size = %v, %v, want 64M", info.Size(), err)
+	}
+	if out, err := exec.Command("e2fsck", "-fn", img).CombinedOutput(); err != nil {
+		t.Fatalf("e2fsck after resize: %v\n%s", err, out)
+	}
+	out, err := exec.Command("debugfs", "-R", "stats", img).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blocks, blockSize int64
+	for _, line := range strings.Split(string(out), "\n") {
+		if f := strings.Fields(line); len(f) == 3 && f[0] == "Block" {
+			switch f[1] {
+			case "count:":
+				blocks, _ = strconv.ParseInt(f[2], 10, 64)
+			case "size:":
+				blockSize, _ = strconv.ParseInt(f[2], 10, 64)
+			}
+		}
+	}
+	if blocks*blockSize != 64<<20 {
+		t.Errorf("filesystem is %d blocks of %d, want 64M", blocks, blockSize)
+	}
+	if out, _ := exec.Command("debugfs", "-R", "cat /tailscale/tailscaled.state", img).Output(); string(out) != "node key" {
+		t.Errorf("tailscale state = %q after resize", out)
+	}
+
+	if err := ResizeData(context.Background(), cfg, "32M"); err == nil || !strings.Contains(err.Error(), "only grows") {
+		t.Errorf("shrink: err = %v, want only grows", err)
+	}
+}
+
+func TestResizeDataRefusesRunningVM(t *testing.T) {
+	withHostState(t)
+	fake := fakeVM(true, false)
+	vm := fake.Handler
+	fake.Handler = func(cmd string) (string, error) {
+		if strings.HasPrefix(cmd, "mkdir -p "+DeviceLockDir) {
+			return "OK", nil
+		}
+		return vm(cmd)
+	}
+	old := device.CurrentTransport()
+	device.SetTransport(fake)
+	defer device.SetTransport(old)
+
+	cfg := &VMConfig{Name: "grow", DisplayName: "Grow", DevicePath: "/data/sovereign/vm/grow", ProcessPattern: "crosvm.*grow"}
+	err := ResizeData(context.Background(), cfg, "2G")
+	if err == nil || !strings.Contains(err.Error(), "stop it first") {
+		t.Fatalf("err = %v, want the VM to be stopped first", err)
+	}
+	for _, cmd := range fake.Commands {
+		if strings.Contains(cmd, "truncate") || strings.Contains(cmd, "resize2fs") || strings.Contains(cmd, "e2fsck") {
+			t.Errorf("ran %q on a running VM", cmd)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Structured results for test, diagnose and fix operations
+// TEAM_045: Operations return these instead of printing; internal/report renders them
+package common
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/anthropics/sovereign/internal/report"
+)
+
+// TestResult represents the outcome of a single test.
+type TestResult struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Skipped bool   `json:"skipped,omitempty"` // Not run (e.g. tailscale CLI missing on host)
+	Message string `json:"message,omitempty"`
+}
+
+// Status maps the result to a report status
+func (r TestResult) Status() report.Status {
+	switch {
+	case r.Skipped:
+		return report.StatusSkip
+	case r.Passed:
+		return report.StatusOK
+	default:
+		return report.StatusFail
+	}
+}
+
+// TestReport is the outcome of RunVMTests
+type TestReport struct {
+	VM          string       `json:"vm"`
+	DisplayName string       `json:"display_name"`
+	Passed      bool         `json:"passed"`
+	Skipped     bool         `json:"skipped,omitempty"` // Not run (VM paused); Passed is false
+	Results     []TestResult `json:"results"`
+
+	tailscaleIP, tailscaleHost string // From the Tailscale test, for the state store
+}
+
+// Failed returns the tests that did not pass
+func (r *TestReport) Failed() []TestResult {
+	var failed []TestResult
+	for _, t := range r.Results {
+		if !t.Passed && !t.Skipped {
+			failed = append(failed, t)
+		}
+	}
+	return failed
+}
+
+// WriteText prints the classic numbered PASS/FAIL list
+func (r *TestReport) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "=== Testing %s VM ===\n", r.DisplayName)
+	for i, t := range r.Results {
+		label := "✗ FAIL"
+		if t.Skipped {
+			label = "? SKIP"
+		} else if t.Passed {
+			label = "✓ PASS"
+		}
+		if t.Message != "" {
+			fmt.Fprintf(w, "%d. %s: %s (%s)\n", i+1, t.Name, label, t.Message)
+		} else {
+			fmt.Fprintf(w, "%d. %s: %s\n", i+1, t.Name, label)
+		}
+	}
+	fmt.Fprintln(w)
+	switch {
+	case r.Skipped:
+		fmt.Fprintln(w, "=== TESTS SKIPPED ===")
+	case r.Passed:
+		fmt.Fprintln(w, "=== ALL TESTS PASSED ===")
+	default:
+		fmt.Fprintf(w, "=== %d TEST(S) FAILED ===\n", len(r.Failed()))
+	}
+	return nil
+}
+
+// JUnit returns one test case per test
+func (r *TestReport) JUnit() report.JUnitSuite {
+	var cases []report.JUnitCase
+	for _, t := range r.Results {
+		cases = append(cases, report.JUnitCaseFor(t.Name, t.Status(), t.Message))
+	}
+	return report.NewJUnitSuite("sovereign.test."+r.VM, cases)
+}
+
+// FixResult represents the result of a fix attempt
+type FixResult struct {
+	Issue   string        `json:"issue"`            // Stable ID: "bridge", "tap", "vm_process"...
+	Check   string        `json:"check"`            // Human title: "Checking bridge network"
+	Status  report.Status `json:"status"`           // ok = nothing left to do, warn = needs a human
+	Fixed   bool          `json:"fixed"`            // An action was taken
+	Verify  bool          `json:"verify,omitempty"` // Post-fix verification, not a fix
+	Message string        `json:"message,omitempty"`
+}
+
+// FixReport is the outcome of FixVM and FixAll
+type FixReport struct {
+	VM          string      `json:"vm"`
+	DisplayName string      `json:"display_name"`
+	FixedCount  int         `json:"fixed_count"`
+	Results     []FixResult `json:"results"`
+}
+
+// add appends a result and keeps FixedCount in sync
+func (r *FixReport) add(check string, result FixResult) {
+	result.Check = check
+	if result.Fixed {
+		r.FixedCount++
+	}
+	r.Results = append(r.Results, result)
+}
+
+// Unresolved returns the checks that still need attention
+func (r *FixReport) Unresolved() []FixResult {
+	var out []FixResult
+	for _, f := range r.Results {
+		if f.Status == report.StatusWarn || f.Status == report.StatusFail {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// WriteText prints the numbered check list, summary and verification
+func (r *FixReport) WriteText(w io.Writer) error {
+	title := r.DisplayName
+	if r.VM != "all" {
+		title += " VM"
+	}
+	fmt.Fprintf(w, "=== Auto-Fix for %s ===\n", title)
+	n := 0
+	verifyHeader := false
+	for _, f := range r.Results {
+		if f.Verify {
+			if !verifyHeader {
+				fmt.Fprintln(w, "\n=== Fix Summary ===")
+				r.writeSummary(w)
+				fmt.Fprintln(w, "\n## Running verification tests...")
+				verifyHeader = true
+			}
+			fmt.Fprintf(w, "   %s %s\n", f.Status.Symbol(), f.Message)
+			continue
+		}
+		n++
+		fmt.Fprintf(w, "\n## %d. %s...\n", n, f.Check)
+		switch {
+		case f.Fixed:
+			fmt.Fprintf(w, "   ✓ Fixed: %s\n", f.Message)
+		case f.Status == report.StatusInfo:
+			fmt.Fprintf(w, "   %s\n", f.Message)
+		case f.Message != "":
+			fmt.Fprintf(w, "   %s %s\n", f.Status.Symbol(), f.Message)
+		}
+	}
+	if !verifyHeader {
+		fmt.Fprintln(w, "\n=== Fix Summary ===")
+		r.writeSummary(w)
+	}
+	fmt.Fprintln(w, "\n=== Auto-Fix Complete ===")
+	return nil
+}
+
+func (r *FixReport) writeSummary(w io.Writer) {
+	if r.FixedCount > 0 {
+		fmt.Fprintf(w, "Fixed %d issue(s)\n", r.FixedCount)
+	} else {
+		fmt.Fprintln(w, "No issues needed fixing")
+	}
+}
+
+// JUnit returns one test case per check; unresolved issues are failures
+func (r *FixReport) JUnit() report.JUnitSuite {
+	var cases []report.JUnitCase
+	for _, f := range r.Results {
+		status := f.Status
+		if status == report.StatusWarn {
+			status = report.StatusFail
+		}
+		cases = append(cases, report.JUnitCaseFor(f.Issue, status, f.Message))
+	}
+	return report.NewJUnitSuite("sovereign.fix."+r.VM, cases)
+}
+
+// DiagnosticItem is one line of a diagnostic section
+type DiagnosticItem struct {
+	Status  report.Status `json:"status"`
+	Message string        `json:"message"`
+}
+
+// DiagnosticSection groups related findings ("Process Status", "TAP Interface")
+type DiagnosticSection struct {
+	Title string           `json:"title"`
+	Items []DiagnosticItem `json:"items"`
+}
+
+// add appends an item to the section
+func (s *DiagnosticSection) add(status report.Status, format string, args ...any) {
+	s.Items = append(s.Items, DiagnosticItem{Status: status, Message: fmt.Sprintf(format, args...)})
+}
+
+// Diagnosis is the outcome of DiagnoseVM and DiagnoseAll
+type Diagnosis struct {
+	VM       string               `json:"vm"`
+	Title    string               `json:"title"`
+	Sections []*DiagnosticSection `json:"sections"`
+
+	footer   string // Closing line for text output
+	numbered bool   // Prefix section titles with 1., 2., ...
+}
+
+// section starts a new section
+func (d *Diagnosis) section(title string) *DiagnosticSection {
+	s := &DiagnosticSection{Title: title}
+	d.Sections = append(d.Sections, s)
+	return s
+}
+
+// WriteText prints sections in the classic "## n. Title" layout
+func (d *Diagnosis) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "=== %s ===\n", d.Title)
+	for i, s := range d.Sections {
+		fmt.Fprintln(w)
+		if d.numbered {
+			fmt.Fprintf(w, "## %d. %s\n", i+1, s.Title)
+		} else {
+			fmt.Fprintf(w, "## %s\n", s.Title)
+		}
+		for _, item := range s.Items {
+			if item.Status == report.StatusInfo {
+				fmt.Fprintf(w, "   %s\n", item.Message)
+			} else {
+				fmt.Fprintf(w, "   %s %s\n", item.Status.Symbol(), item.Message)
+			}
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "=== %s ===\n", d.footer)
+	return nil
+}
+
+// JUnit returns one test case per non-informational item
+func (d *Diagnosis) JUnit() report.JUnitSuite {
+	var cases []report.JUnitCase
+	for _, s := range d.Sections {
+		for _, item := range s.Items {
+			if item.Status == report.StatusInfo {
+				continue
+			}
+			cases = append(cases, report.JUnitCaseFor(s.Title+": "+item.Message, item.Status, item.Message))
+		}
+	}
+	return report.NewJUnitSuite("sovereign.diagnose."+d.VM, cases)
+}
@@ -0,0 +1,156 @@
+// SBOM generation for built VM images
+// TEAM_057: `sovereign sbom --<vm>` - inventory the docker image a rootfs was
+// built from and optionally match it against an offline OSV database
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthropics/sovereign/internal/docker"
+	"github.com/anthropics/sovereign/internal/sbom"
+)
+
+// SBOM options, set from the sbom command's flags
+var (
+	SBOMFormat string // --format: cyclonedx (default) or spdx
+	SBOMOutput string // --out: default <LocalPath>/sbom.<format>.json
+	SBOMVulnDB string // --vuln-db: OSV .json, .zip or directory; empty skips matching
+)
+
+// headlinePrefixes pick the packages worth printing in the summary
+var headlinePrefixes = []string{"postgresql", "forgejo", "vaultwarden", "tailscale", "openssh", "openssl", "musl"}
+
+// GenerateSBOM writes an SBOM for cfg's docker image and returns the
+// vulnerabilities found (none without SBOMVulnDB)
+func GenerateSBOM(ctx context.Context, cfg *VMConfig) ([]sbom.Finding, error) {
+	fmt.Printf("=== SBOM for %s VM ===\n", cfg.DisplayName)
+
+	format, err := sbom.ParseFormat(SBOMFormat)
+	if err != nil {
+		return nil, err
+	}
+	if !docker.IsAvailable() {
+		return nil, fmt.Errorf("docker not found in PATH - install Docker first")
+	}
+	imageID, err := docker.ImageID(cfg.DockerImage)
+	if err != nil {
+		return nil, fmt.Errorf("%w - run 'sovereign build --%s' first", err, cfg.Name)
+	}
+	// TEAM_056: rootfs.img may come from the build cache, built from an
+	// image docker no longer has under this tag
+	if m, err := LocalBuild(cfg); err == nil && m.ImageDigest != "" && m.ImageDigest != imageID {
+		fmt.Printf("  ⚠ %s is %s but rootfs.img was built from %s - rebuild for an exact SBOM\n",
+			cfg.DockerImage, shortDigest(imageID), shortDigest(m.ImageDigest))
+	}
+
+	var db *sbom.VulnDB
+	if SBOMVulnDB != "" {
+		if db, err = sbom.LoadVulnDB(SBOMVulnDB); err != nil {
+			return nil, err
+		}
+		fmt.Printf("  ✓ Loaded %d advisories from %s\n", db.Records, SBOMVulnDB)
+	}
+
+	tarFile, err := os.CreateTemp("", "sovereign-sbom-*.tar")
+	if err != nil {
+		return nil, err
+	}
+	tarFile.Close()
+	defer os.Remove(tarFile.Name())
+	fmt.Println("Exporting image filesystem...")
+	if err := docker.ExportTar(cfg.DockerImage, tarFile.Name()); err != nil {
+		return nil, err
+	}
+
+	inv, err := sbom.FromTar(tarFile.Name(), sbom.CopySources(filepath.Join(cfg.LocalPath, "Dockerfile")))
+	if err != nil {
+		return nil, err
+	}
+	inv.Name = cfg.DockerImage
+	inv.Version = imageID
+
+	var findings []sbom.Finding
+	if db != nil {
+		findings = db.Match(inv)
+	}
+
+	out := SBOMOutput
+	if out == "" {
+		ext := "cdx"
+		if format == sbom.FormatSPDX {
+			ext = "spdx"
+		}
+		out = filepath.Join(cfg.LocalPath, fmt.Sprintf("sbom.%s.json", ext))
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return nil, err
+	}
+	if err := sbom.Write(f, format, inv, findings); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, c := range inv.Components {
+		counts[c.Type]++
+	}
+	fmt.Printf("  ✓ Wrote %s (%s): %d apk packages, %d unpackaged binaries, %d Go modules\n",
+		out, format, counts[sbom.TypeAPK], counts[sbom.TypeBinary], counts[sbom.TypeGoModule])
+	if inv.Distro != "" {
+		fmt.Printf("  Alpine %s\n", inv.Distro)
+	}
+	for _, c := range inv.Components {
+		if c.Type == sbom.TypeGoModule || (c.Type == sbom.TypeAPK && !isHeadline(c.Name)) {
+			continue
+		}
+		version := c.Version
+		if version == "" {
+			version = "(version unknown)"
+		}
+		line := fmt.Sprintf("  %-22s %s", c.Name, version)
+		if c.Type == sbom.TypeBinary {
+			line += "  " + c.Path
+			if c.Source != "" {
+				line += " from " + c.Source
+			}
+		}
+		fmt.Println(line)
+	}
+
+	if db != nil {
+		if len(findings) == 0 {
+			fmt.Println("\n✓ No known vulnerabilities")
+		} else {
+			fmt.Printf("\n✗ %d known vulnerabilities:\n", len(findings))
+			for _, v := range findings {
+				fix := ""
+				if v.FixedIn != "" {
+					fix = ", fixed in " + v.FixedIn
+				}
+				severity := v.Severity
+				if severity == "" || strings.HasPrefix(severity, "CVSS:") {
+					severity = "unrated"
+				}
+				fmt.Printf("  %s [%s] %s %s%s - %s\n", v.ID, severity, v.Component.Name, v.Component.Version, fix, v.Summary)
+			}
+		}
+	}
+	return findings, nil
+}
+
+func isHeadline(name string) bool {
+	for _, p := range headlinePrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
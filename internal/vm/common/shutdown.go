@@ -0,0 +1,146 @@
+// Graceful VM shutdown via the crosvm control socket
+// TEAM_049: kill -9 after 500ms risked corrupting PostgreSQL's data.img.
+// Stop now escalates: guest power button -> crosvm stop -> SIGTERM -> SIGKILL.
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anthropics/sovereign/internal/device"
+)
+
+// crosvm lives in the AVF apex and needs its libraries on the linker path
+const crosvmCmd = "LD_LIBRARY_PATH=/apex/com.android.virt/lib64:/system/lib64 /apex/com.android.virt/bin/crosvm"
+
+// DefaultStopGracePeriod applies when VMConfig.StopGracePeriod is zero (seconds)
+const DefaultStopGracePeriod = 30
+
+// StopMethod is the path StopVM took to end the VM
+type StopMethod string
+
+const (
+	StopNotRunning  StopMethod = "not-running" // Nothing to stop
+	StopPowerButton StopMethod = "powerbtn"    // Guest shut down cleanly after ACPI power button
+	StopCrosvmStop  StopMethod = "crosvm-stop" // crosvm exited on request (guest not quiesced)
+	StopSIGTERM     StopMethod = "sigterm"     // crosvm killed with SIGTERM
+	StopSIGKILL     StopMethod = "sigkill"     // crosvm killed with SIGKILL
+)
+
+// Clean reports whether the guest had a chance to flush its filesystems
+func (m StopMethod) Clean() bool {
+	return m == StopPowerButton || m == StopNotRunning
+}
+
+// StopResult describes how a VM was stopped
+type StopResult struct {
+	VM      string        `json:"vm"`
+	PID     string        `json:"pid,omitempty"`
+	Method  StopMethod    `json:"method"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// String renders the result for CLI output
+func (r *StopResult) String() string {
+	switch r.Method {
+	case StopNotRunning:
+		return "VM not running"
+	case StopPowerButton:
+		return fmt.Sprintf("clean guest shutdown via power button in %.1fs", r.Elapsed.Seconds())
+	default:
+		return fmt.Sprintf("escalated to %s after %.1fs - guest did not shut down cleanly", r.Method, r.Elapsed.Seconds())
+	}
+}
+
+// stopGracePeriod returns the configured grace period for the guest
+func stopGracePeriod(cfg *VMConfig) time.Duration {
+	if cfg.StopGracePeriod > 0 {
+		return time.Duration(cfg.StopGracePeriod) * time.Second
+	}
+	return DefaultStopGracePeriod * time.Second
+}
+
+// waitForExit polls until the VM process is gone or timeout elapses
+func waitForExit(ctx context.Context, cfg *VMConfig, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if device.GetProcessPID(ctx, cfg.ProcessPattern) == "" {
+			return true
+		}
+		if time.Now().After(deadline) || sleepCtx(ctx, time.Second) != nil {
+			return false
+		}
+	}
+}
+
+// ShutdownVM stops the crosvm process for cfg, preferring a clean guest shutdown:
+//  1. crosvm powerbtn on vm.sock, then wait StopGracePeriod for the guest to power off
+//  2. crosvm stop on vm.sock, then wait 5s
+//  3. kill (SIGTERM), then wait 5s
+//  4. kill -9
+//
+// Steps 1-2 are skipped when the control socket is missing.
+func ShutdownVM(ctx context.Context, cfg *VMConfig) (*StopResult, error) {
+	// TEAM_051: The supervisor owns its children - let it run the escalation
+	if r, err := supervisedStop(ctx, cfg); !errors.Is(err, errNoSupervisor) {
+		return r, err
+	}
+
+	result := &StopResult{VM: cfg.Name}
+	start := time.Now()
+	defer func() { result.Elapsed = time.Since(start) }()
+
+	pid := device.GetProcessPID(ctx, cfg.ProcessPattern)
+	if pid == "" {
+		result.Method = StopNotRunning
+		return result, nil
+	}
+	result.PID = pid
+
+	sock := controlSocket(cfg)
+	if device.FileExists(ctx, sock) {
+		// TEAM_050: A suspended guest can't see the power button - wake it first
+		if device.FileExists(ctx, pausedMarker(cfg)) {
+			fmt.Println("VM is paused, resuming so it can shut down...")
+			if err := crosvmControl(ctx, cfg, "resume"); err != nil {
+				fmt.Printf("⚠ %v\n", err)
+			}
+		}
+		grace := stopGracePeriod(cfg)
+		fmt.Printf("Requesting guest shutdown (power button, up to %s)...\n", grace)
+		device.RunShellCommand(ctx, crosvmCmd+" "+device.Quote("powerbtn", sock)+" 2>&1")
+		if waitForExit(ctx, cfg, grace) {
+			result.Method = StopPowerButton
+			return result, nil
+		}
+
+		fmt.Println("Guest did not power off, asking crosvm to stop...")
+		device.RunShellCommand(ctx, crosvmCmd+" "+device.Quote("stop", sock)+" 2>&1")
+		if waitForExit(ctx, cfg, 5*time.Second) {
+			result.Method = StopCrosvmStop
+			return result, nil
+		}
+	} else {
+		fmt.Printf("No control socket at %s - skipping clean shutdown\n", sock)
+	}
+
+	fmt.Printf("Sending SIGTERM to crosvm (PID: %s)...\n", pid)
+	device.KillProcess(ctx, pid)
+	if waitForExit(ctx, cfg, 5*time.Second) {
+		result.Method = StopSIGTERM
+		return result, nil
+	}
+
+	fmt.Println("Process still alive, force killing...")
+	device.Exec(ctx, "kill", "-9", pid)
+	if waitForExit(ctx, cfg, 2*time.Second) {
+		result.Method = StopSIGKILL
+		return result, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	return result, fmt.Errorf("crosvm (PID %s) survived SIGKILL", pid)
+}
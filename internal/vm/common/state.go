@@ -0,0 +1,431 @@
+// Host-side state store
+// TEAM_064: The CLI forgot everything between invocations - the boot script
+// was re-pushed by every deploy, and what a phone runs had to be rediscovered
+// on it. Builds, deploys, starts, stops and test runs are now recorded per
+// device and VM in one JSON file. The device stays the source of truth
+// (ps, the supervisor, the current link); the state is a record of what this
+// host did to it.
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/anthropics/sovereign/internal/device"
+)
+
+// StateFile overrides where the state is kept
+// (default $XDG_STATE_HOME/sovereign/state.json, else ~/.local/state/...)
+var StateFile string
+
+// StatePruneAge is how long a device may go unseen before state prune drops it
+// TEAM_064: Package-level flag for state prune --older-than
+var StatePruneAge = 90 * 24 * time.Hour
+
+const (
+	stateVersion     = 1
+	stateLockTimeout = 10 * time.Second
+)
+
+// State is everything the CLI remembers between invocations
+type State struct {
+	Version int                     `json:"version"`
+	Builds  map[string]*BuildRecord `json:"builds"`  // Local builds, by VM name
+	Devices map[string]*DeviceState `json:"devices"` // By device.TargetID
+}
+
+// BuildRecord is the last local build of a VM
+type BuildRecord struct {
+	InputsKey   string    `json:"inputs_key"`
+	ImageDigest string    `json:"image_digest,omitempty"`
+	BuiltAt     time.Time `json:"built_at"`
+}
+
+// DeviceState is what this host did to one phone
+type DeviceState struct {
+	LastSeen   time.Time            `json:"last_seen"`
+	BootScript string               `json:"boot_script,omitempty"` // bootScriptKey as last deployed
+	VMs        map[string]*VMRecord `json:"vms"`
+}
+
+// VMRecord is one VM on one device
+type VMRecord struct {
+	Build      string           `json:"build,omitempty"` // InputsKey of the deployed build
+	Release    string           `json:"release,omitempty"`
+	DeployedAt time.Time        `json:"deployed_at,omitempty"`
+	StartedAt  time.Time        `json:"started_at,omitempty"`
+	StoppedAt  time.Time        `json:"stopped_at,omitempty"`
+	LastTest   *TestRecord      `json:"last_test,omitempty"`
+	Tailscale  *TailscaleRecord `json:"tailscale,omitempty"`
+}
+
+// TestRecord summarizes the last `sovereign test` run
+type TestRecord struct {
+	At      time.Time `json:"at"`
+	Passed  bool      `json:"passed"`
+	Skipped bool      `json:"skipped,omitempty"` // VM was paused
+	Failed  []string  `json:"failed,omitempty"`  // Names of failed tests
+	Summary string    `json:"summary"`
+}
+
+// TailscaleRecord is the tailnet identity the VM was last seen with
+type TailscaleRecord struct {
+	IP       string    `json:"ip"`
+	Hostname string    `json:"hostname"`
+	SeenAt   time.Time `json:"seen_at"`
+}
+
+// statePath resolves StateFile
+func statePath() (string, error) {
+	if StateFile != "" {
+		return StateFile, nil
+	}
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(base, "sovereign", "state.json"), nil
+}
+
+// LoadState reads the state file; a missing file is an empty state.
+// Writes are atomic renames, so reading needs no lock.
+func LoadState() (*State, error) {
+	path, err := statePath()
+	if err != nil {
+		return nil, err
+	}
+	s := &State{Version: stateVersion, Builds: map[string]*BuildRecord{}, Devices: map[string]*DeviceState{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("corrupt state file %s: %w - remove it to start over", path, err)
+	}
+	if s.Version > stateVersion {
+		return nil, fmt.Errorf("state file %s is version %d, this sovereign understands %d - upgrade it", path, s.Version, stateVersion)
+	}
+	s.Version = stateVersion
+	if s.Builds == nil {
+		s.Builds = map[string]*BuildRecord{}
+	}
+	if s.Devices == nil {
+		s.Devices = map[string]*DeviceState{}
+	}
+	for _, d := range s.Devices {
+		if d.VMs == nil {
+			d.VMs = map[string]*VMRecord{}
+		}
+	}
+	return s, nil
+}
+
+// UpdateState applies fn to the state under an exclusive lock, so two CLI
+// runs can't lose each other's updates. Nothing is written if fn fails.
+func UpdateState(fn func(s *State) error) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	lock, err := lockFile(path+".lock", stateLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlockFile(lock)
+
+	s, err := LoadState()
+	if err != nil {
+		return err
+	}
+	if err := fn(s); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// errLocked is returned by lockFile when another process holds the lock
+var errLocked = errors.New("held by another sovereign process")
+
+// lockFile takes an exclusive flock on path, polling until timeout
+func lockFile(path string, timeout time.Duration) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if err != syscall.EWOULDBLOCK || time.Now().After(deadline) {
+			f.Close()
+			if err == syscall.EWOULDBLOCK {
+				return nil, fmt.Errorf("%s is %w", path, errLocked)
+			}
+			return nil, fmt.Errorf("locking %s: %w", path, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return f, nil
+}
+
+// unlockFile releases a lock taken by lockFile
+func unlockFile(f *os.File) {
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	f.Close()
+}
+
+// deviceState returns the entry for id, creating it
+func (s *State) deviceState(id string) *DeviceState {
+	d, ok := s.Devices[id]
+	if !ok {
+		d = &DeviceState{VMs: map[string]*VMRecord{}}
+		s.Devices[id] = d
+	}
+	return d
+}
+
+// Device returns the entry for the selected device, nil if there is none
+func (s *State) Device() *DeviceState {
+	return s.Devices[device.TargetID()]
+}
+
+// recordDevice updates the selected device's entry. State is bookkeeping:
+// failing to write it warns instead of failing the operation.
+func recordDevice(fn func(d *DeviceState)) {
+	id := device.TargetID()
+	if id == "" {
+		return
+	}
+	err := UpdateState(func(s *State) error {
+		d := s.deviceState(id)
+		d.LastSeen = time.Now().UTC()
+		fn(d)
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("⚠ Recording state: %v\n", err)
+	}
+}
+
+// recordVM updates cfg's entry on the selected device
+func recordVM(cfg *VMConfig, fn func(r *VMRecord)) {
+	recordDevice(func(d *DeviceState) {
+		r, ok := d.VMs[cfg.Name]
+		if !ok {
+			r = &VMRecord{}
+			d.VMs[cfg.Name] = r
+		}
+		fn(r)
+	})
+}
+
+// recordBuild stores the manifest of cfg's last local build
+func recordBuild(cfg *VMConfig) {
+	m, err := LocalBuild(cfg)
+	if err != nil {
+		return // Built before provenance - nothing to record
+	}
+	err = UpdateState(func(s *State) error {
+		s.Builds[cfg.Name] = &BuildRecord{InputsKey: m.InputsKey, ImageDigest: m.ImageDigest, BuiltAt: m.BuiltAt}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("⚠ Recording state: %v\n", err)
+	}
+}
+
+// recordTest stores a test report and the Tailscale identity it found
+func recordTest(cfg *VMConfig, r *TestReport) {
+	now := time.Now().UTC()
+	rec := &TestRecord{At: now, Passed: r.Passed, Skipped: r.Skipped}
+	for _, f := range r.Failed() {
+		rec.Failed = append(rec.Failed, f.Name)
+	}
+	rec.Summary = fmt.Sprintf("%d/%d passed", len(r.Results)-len(rec.Failed), len(r.Results))
+	if r.Skipped {
+		rec.Summary = "skipped (paused)"
+	}
+	recordVM(cfg, func(v *VMRecord) {
+		v.LastTest = rec
+		if r.tailscaleIP != "" {
+			v.Tailscale = &TailscaleRecord{IP: r.tailscaleIP, Hostname: r.tailscaleHost, SeenAt: now}
+		}
+	})
+}
+
+// forgetVM drops cfg's entry on the selected device
+func forgetVM(cfg *VMConfig) {
+	recordDevice(func(d *DeviceState) { delete(d.VMs, cfg.Name) })
+}
+
+// ShowState writes the state file, one device and VM per block
+func ShowState(w io.Writer) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	s, err := LoadState()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "State: %s\n", path)
+
+	if len(s.Builds) > 0 {
+		fmt.Fprintln(w, "\nLocal builds:")
+		for _, name := range sortedKeys(s.Builds) {
+			b := s.Builds[name]
+			fmt.Fprintf(w, "  %-8s %s  image %s  built %s\n", name, shortDigest(b.InputsKey), shortDigest(b.ImageDigest), formatStateTime(b.BuiltAt))
+		}
+	}
+
+	current := device.TargetID()
+	for _, id := range sortedKeys(s.Devices) {
+		d := s.Devices[id]
+		marker := ""
+		if id == current {
+			marker = " (selected)"
+		}
+		fmt.Fprintf(w, "\nDevice %s%s - last seen %s\n", id, marker, formatStateTime(d.LastSeen))
+		if d.BootScript != "" {
+			fmt.Fprintf(w, "  Boot script: %s\n", shortDigest(d.BootScript))
+		}
+		for _, name := range sortedKeys(d.VMs) {
+			v := d.VMs[name]
+			fmt.Fprintf(w, "  %s:\n", name)
+			if v.Release != "" {
+				stale := ""
+				if b, ok := s.Builds[name]; ok && v.Build != "" && b.InputsKey != v.Build {
+					stale = " - local build is newer"
+				}
+				fmt.Fprintf(w, "    Deployed: release %s, build %s, %s%s\n", v.Release, shortDigest(v.Build), formatStateTime(v.DeployedAt), stale)
+			}
+			if !v.StartedAt.IsZero() {
+				fmt.Fprintf(w, "    Started:  %s\n", formatStateTime(v.StartedAt))
+			}
+			if v.StoppedAt.After(v.StartedAt) {
+				fmt.Fprintf(w, "    Stopped:  %s\n", formatStateTime(v.StoppedAt))
+			}
+			if t := v.LastTest; t != nil {
+				mark := "✓"
+				if t.Skipped {
+					mark = "?"
+				} else if !t.Passed {
+					mark = "✗"
+				}
+				fmt.Fprintf(w, "    Tested:   %s %s, %s\n", mark, t.Summary, formatStateTime(t.At))
+				for _, f := range t.Failed {
+					fmt.Fprintf(w, "      ✗ %s\n", f)
+				}
+			}
+			if ts := v.Tailscale; ts != nil {
+				fmt.Fprintf(w, "    Tailnet:  %s as %s, %s\n", ts.IP, ts.Hostname, formatStateTime(ts.SeenAt))
+			}
+		}
+	}
+	if len(s.Builds) == 0 && len(s.Devices) == 0 {
+		fmt.Fprintln(w, "  (empty)")
+	}
+	return nil
+}
+
+// PruneState drops devices not seen for StatePruneAge, VMs that are no
+// longer registered, and - on the connected device - VMs whose directory is
+// gone. Returns a line per removed entry.
+func PruneState(ctx context.Context) ([]string, error) {
+	registered := map[string]*VMConfig{}
+	for _, cfg := range RegisteredConfigs() {
+		registered[cfg.Name] = cfg
+	}
+	current := ""
+	if device.IsConnected(ctx) {
+		current = device.TargetID()
+	}
+	// Device checks happen before taking the lock
+	gone := map[string]bool{}
+	if s, err := LoadState(); err == nil && current != "" {
+		if d, ok := s.Devices[current]; ok {
+			for name := range d.VMs {
+				if cfg, ok := registered[name]; ok && !device.DirExists(ctx, cfg.DevicePath) {
+					gone[name] = true
+				}
+			}
+		}
+	}
+
+	var removed []string
+	cutoff := time.Now().Add(-StatePruneAge)
+	err := UpdateState(func(s *State) error {
+		removed = nil
+		for name := range s.Builds {
+			if registered[name] == nil {
+				delete(s.Builds, name)
+				removed = append(removed, fmt.Sprintf("build %s (VM no longer registered)", name))
+			}
+		}
+		for id, d := range s.Devices {
+			if d.LastSeen.Before(cutoff) {
+				delete(s.Devices, id)
+				removed = append(removed, fmt.Sprintf("device %s (last seen %s)", id, formatStateTime(d.LastSeen)))
+				continue
+			}
+			for name := range d.VMs {
+				switch {
+				case registered[name] == nil:
+					removed = append(removed, fmt.Sprintf("%s on %s (VM no longer registered)", name, id))
+				case id == current && gone[name]:
+					removed = append(removed, fmt.Sprintf("%s on %s (not on the device)", name, id))
+				default:
+					continue
+				}
+				delete(d.VMs, name)
+			}
+		}
+		return nil
+	})
+	sort.Strings(removed)
+	return removed, err
+}
+
+func formatStateTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/sovereign/internal/device"
+)
+
+// withRegistered registers cfgs for the test
+func withRegistered(t *testing.T, cfgs ...*VMConfig) {
+	t.Helper()
+	for _, cfg := range cfgs {
+		RegisterConfig(cfg)
+	}
+	t.Cleanup(func() {
+		configsMu.Lock()
+		defer configsMu.Unlock()
+		for _, cfg := range cfgs {
+			delete(configs, cfg.Name)
+		}
+	})
+}
+
+func TestPruneState(t *testing.T) {
+	withHostState(t)
+	withRegistered(t,
+		&VMConfig{Name: "pkept", DevicePath: "/data/sovereign/vm/pkept"},
+		&VMConfig{Name: "pmissing", DevicePath: "/data/sovereign/vm/pmissing"})
+	oldAge := StatePruneAge
+	StatePruneAge = 30 * 24 * time.Hour
+	defer func() { StatePruneAge = oldAge }()
+
+	fake := device.NewFakeTransport().On("[ -d /data/sovereign/vm/pkept ]", "yes", nil)
+	old := device.CurrentTransport()
+	device.SetTransport(fake)
+	defer device.SetTransport(old)
+
+	now := time.Now()
+	vms := func() map[string]*VMRecord {
+		return map[string]*VMRecord{"pkept": {}, "pmissing": {}, "punregistered": {}}
+	}
+	err := UpdateState(func(s *State) error {
+		s.Builds["pkept"] = &BuildRecord{InputsKey: "k"}
+		s.Builds["punregistered"] = &BuildRecord{InputsKey: "k"}
+		s.Devices[device.TargetID()] = &DeviceState{LastSeen: now, VMs: vms()}
+		s.Devices["ssh://recent"] = &DeviceState{LastSeen: now.Add(-10 * 24 * time.Hour), VMs: vms()}
+		s.Devices["ssh://stale"] = &DeviceState{LastSeen: now.Add(-100 * 24 * time.Hour), VMs: vms()}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := PruneState(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"build punregistered (VM no longer registered)",
+		"device ssh://stale (last seen",
+		"pmissing on " + device.TargetID() + " (not on the device)",
+		"punregistered on " + device.TargetID() + " (VM no longer registered)",
+		"punregistered on ssh://recent (VM no longer registered)",
+	}
+	if len(removed) != len(want) {
+		t.Fatalf("removed:\n%s", strings.Join(removed, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(removed[i], want[i]) {
+			t.Errorf("removed[%d] = %q, want %q", i, removed[i], want[i])
+		}
+	}
+
+	s, err := LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Builds["pkept"] == nil || s.Builds["punregistered"] != nil {
+		t.Errorf("builds = %v", s.Builds)
+	}
+	if _, ok := s.Devices["ssh://stale"]; ok {
+		t.Error("stale device kept")
+	}
+	// Only the connected device is checked for missing VMs
+	if d := s.Devices["ssh://recent"]; d == nil || d.VMs["pmissing"] == nil || d.VMs["pkept"] == nil {
+		t.Errorf("recent device = %+v", d)
+	}
+	if d := s.Devices[device.TargetID()]; d == nil || len(d.VMs) != 1 || d.VMs["pkept"] == nil {
+		t.Errorf("connected device = %+v", d)
+	}
+}
@@ -0,0 +1,42 @@
+// Per-VM status summary
+// TEAM_052: `sovereign status --<vm>` shows the supervisor's crash-loop
+// post-mortem, not just whether crosvm is running
+// TEAM_056: ...and which build is on the phone
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/report"
+)
+
+// StatusVM summarises cfg's VM: whether it runs, the supervisor's restart
+// history with the exit reason and console tail of a crash loop, and the
+// deployed build. Cheaper than DiagnoseVM - no network checks.
+func StatusVM(ctx context.Context, cfg *VMConfig) (*Diagnosis, error) {
+	d := &Diagnosis{
+		VM:     cfg.Name,
+		Title:  fmt.Sprintf("%s VM Status", cfg.DisplayName),
+		footer: fmt.Sprintf("Use 'sovereign diagnose --%s' for detailed diagnosis", cfg.Name),
+	}
+	if !device.IsConnected(ctx) {
+		return d, fmt.Errorf("no device connected")
+	}
+
+	s := d.section("Process")
+	pid := processPID(ctx, cfg)
+	switch {
+	case pid != "" && device.FileExists(ctx, pausedMarker(cfg)):
+		s.add(report.StatusWarn, "PAUSED (PID: %s)", pid)
+	case pid != "":
+		s.add(report.StatusOK, "Running (PID: %s)", pid)
+	default:
+		s.add(report.StatusFail, "Not running")
+	}
+	addSupervisorStatus(ctx, s, cfg)
+
+	addProvenance(ctx, d.section("Build Provenance"), cfg)
+	return d, nil
+}
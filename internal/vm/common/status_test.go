@@ -0,0 +1,82 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/supervisor"
+)
+
+func TestStatusShowsCrashLoop(t *testing.T) {
+	resp, _ := json.Marshal(supervisor.Response{OK: true, VMs: []supervisor.VMStatus{{
+		Name:        "status-sql",
+		State:       supervisor.StateFailed,
+		Restarts:    5,
+		LastExit:    "exit status 1",
+		LastExitAt:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		CrashLoop:   true,
+		ConsoleTail: []string{"Kernel panic - not syncing: VFS"},
+	}}})
+	fake := device.NewFakeTransport().On("ctl status status-sql", string(resp), nil)
+	old := device.CurrentTransport()
+	device.SetTransport(fake)
+	defer device.SetTransport(old)
+
+	d, err := StatusVM(context.Background(), &VMConfig{Name: "status-sql", DisplayName: "PostgreSQL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	d.WriteText(&out)
+	for _, want := range []string{
+		"✗ Not running",
+		"Supervisor: failed, 5 restart(s)",
+		"Last exit: exit status 1 at 2026-01-02T03:04:05Z",
+		"✗ Crash loop",
+		"console: Kernel panic - not syncing: VFS",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("status output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestStatusShowsDeployedBuild(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &VMConfig{Name: "status-vault", DisplayName: "Vault", LocalPath: dir, DevicePath: "/data/sovereign/vm/status-vault"}
+	deployed, _ := json.Marshal(BuildManifest{VM: cfg.Name, BuildHost: "laptop", InputsKey: "old",
+		ImageDigest: "sha256:0123456789abcdef", BaseImages: []string{"alpine:3.20"}})
+	local, _ := json.Marshal(BuildManifest{VM: cfg.Name, BuildHost: "laptop", InputsKey: "new"})
+	if err := os.WriteFile(filepath.Join(dir, buildManifestFile), local, 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := device.NewFakeTransport().On("cat "+cfg.DevicePath+"/"+buildManifestFile, string(deployed), nil)
+	old := device.CurrentTransport()
+	device.SetTransport(fake)
+	defer device.SetTransport(old)
+
+	d, err := StatusVM(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	d.WriteText(&out)
+	for _, want := range []string{
+		"## Build Provenance",
+		"Deployed: built ",
+		"image 0123456789ab",
+		"Base image: alpine:3.20",
+		"⚠ Local build differs",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("status output missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// CLI side of the on-device Go supervisor
+// TEAM_051: When sovereign-supervisor is running on the phone, start/stop go
+// through its control socket and process state comes from it instead of
+// grepping `ps`. Without it, everything falls back to sovereign_start.sh.
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/supervisor"
+)
+
+const (
+	// SupervisorDevicePath is where DeployBootScript installs the binary
+	SupervisorDevicePath = "/data/sovereign/bin/sovereign-supervisor"
+	// SupervisorLocalPath is the linux/arm64 binary BuildVM builds and
+	// DeployBootScript pushes, next to the boot script
+	SupervisorLocalPath = "host/sovereign-supervisor"
+	supervisorPackage   = "./cmd/sovereign-supervisor"
+)
+
+// supervisorBuilt keeps build --all from compiling the supervisor per VM
+var (
+	supervisorBuilt   bool
+	supervisorBuildMu sync.Mutex
+)
+
+// errNoSupervisor means the binary is missing or its daemon isn't running
+var errNoSupervisor = errors.New("supervisor not running")
+
+// supervisorCall runs `sovereign-supervisor ctl <op> [vm]` on the device.
+// Returns errNoSupervisor when there is nothing to talk to.
+func supervisorCall(ctx context.Context, timeout time.Duration, op, name string) (*supervisor.Response, error) {
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	out, _ := device.CurrentTransport().Run(cctx, device.Quote(SupervisorDevicePath, "ctl", op, name)+" 2>/dev/null")
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	var resp supervisor.Response
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &resp); err != nil {
+		return nil, errNoSupervisor
+	}
+	if !resp.OK {
+		if strings.HasPrefix(resp.Error, "supervisor not reachable") {
+			return nil, errNoSupervisor
+		}
+		return &resp, fmt.Errorf("supervisor: %s", resp.Error)
+	}
+	return &resp, nil
+}
+
+// SupervisorStatus returns the supervisor's view of every VM
+func SupervisorStatus(ctx context.Context) ([]supervisor.VMStatus, error) {
+	resp, err := supervisorCall(ctx, 30*time.Second, supervisor.OpStatus, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.VMs, nil
+}
+
+// supervisedStatus returns the supervisor's status for cfg, or false if no
+// supervisor is running or it doesn't know the VM
+func supervisedStatus(ctx context.Context, cfg *VMConfig) (supervisor.VMStatus, bool) {
+	resp, err := supervisorCall(ctx, 30*time.Second, supervisor.OpStatus, cfg.Name)
+	if err != nil || len(resp.VMs) != 1 {
+		return supervisor.VMStatus{}, false
+	}
+	return resp.VMs[0], true
+}
+
+// processPID returns the VM's crosvm PID: from the supervisor when it is
+// running, otherwise by matching ProcessPattern against `ps`
+func processPID(ctx context.Context, cfg *VMConfig) string {
+	if st, ok := supervisedStatus(ctx, cfg); ok {
+		if st.PID == 0 {
+			return ""
+		}
+		return fmt.Sprint(st.PID)
+	}
+	return device.GetProcessPID(ctx, cfg.ProcessPattern)
+}
+
+// SupervisorConfig builds supervisor.json from the registered VMs
+func SupervisorConfig() (*supervisor.Config, error) {
+	subnet, err := BridgeSubnet()
+	if err != nil {
+		return nil, err
+	}
+	bridge, err := BridgeCIDR(subnet)
+	if err != nil {
+		return nil, err
+	}
+	sc := &supervisor.Config{
+		BridgeName: BridgeName,
+		BridgeCIDR: bridge,
+		Subnet:     subnet,
+		Uplink:     "wlan0",
+	}
+	for _, cfg := range RegisteredConfigs() {
+		ip := cfg.TAPGuestIP
+		if ip == "" {
+			ip = LeasedIP(cfg.Name)
+		}
+		spec := supervisor.Spec{
+			Name:         cfg.Name,
+			Dir:          cfg.DevicePath,
+			TAP:          cfg.TAPInterface,
+			SecretParams: cfg.KernelSecrets,
+			StopGrace:    int(stopGracePeriod(cfg).Seconds()),
+			// TEAM_052: Supervisor fills in defaults for zero values
+			Restart:       string(cfg.Restart.Mode),
+			MaxRetries:    cfg.Restart.MaxRetries,
+			RestartWindow: cfg.Restart.Window,
+		}
+		var params []string
+		if ip != "" {
+			c := *cfg
+			c.TAPGuestIP = ip
+			if p, err := KernelNetParams(&c); err == nil {
+				params = append(params, p)
+			}
+			if port := readyPort(cfg); port != 0 {
+				spec.ReadyAddr = net.JoinHostPort(ip, fmt.Sprint(port))
+			}
+		}
+		if sqlIP := ResolveDependencyIP(PostgreSQLDependency); sqlIP != "" {
+			params = append(params, "sovereign.sql="+sqlIP)
+		}
+		spec.Params = strings.Join(params, " ")
+		for _, dep := range cfg.Dependencies {
+			spec.DependsOn = append(spec.DependsOn, dep.Name)
+		}
+		sc.VMs = append(sc.VMs, spec)
+	}
+	return sc, nil
+}
+
+// PushSupervisorConfig writes supervisor.json to the device and asks a
+// running supervisor to reload it
+func PushSupervisorConfig(ctx context.Context) error {
+	sc, err := SupervisorConfig()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(sc, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp("", "supervisor-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+	if err := device.PushFile(ctx, tmp.Name(), supervisor.DefaultConfigPath); err != nil {
+		return err
+	}
+	if _, err := supervisorCall(ctx, 30*time.Second, supervisor.OpReload, ""); err != nil && !errors.Is(err, errNoSupervisor) {
+		return err
+	}
+	return nil
+}
+
+// BuildSupervisor cross-compiles the supervisor as a static linux/arm64
+// binary to SupervisorLocalPath. Runs once per process.
+// TEAM_051: Part of the build step, so deploy needs no Go toolchain
+func BuildSupervisor(ctx context.Context) error {
+	supervisorBuildMu.Lock()
+	defer supervisorBuildMu.Unlock()
+	if supervisorBuilt {
+		return nil
+	}
+
+	fmt.Println("Building supervisor (linux/arm64)...")
+	tmp := SupervisorLocalPath + ".tmp"
+	cmd := exec.CommandContext(ctx, "go", "build", "-trimpath", "-ldflags=-s -w", "-o", tmp, supervisorPackage)
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS=linux", "GOARCH=arm64")
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("go build %s (run from the repository root with a Go toolchain): %w\n%s", supervisorPackage, err, msg)
+	}
+	if err := os.Rename(tmp, SupervisorLocalPath); err != nil {
+		return err
+	}
+	supervisorBuilt = true
+	fmt.Printf("  ✓ Supervisor: %s\n", SupervisorLocalPath)
+	return nil
+}
+
+// errSupervisorNotBuilt is returned by deploy when build hasn't produced the binary
+var errSupervisorNotBuilt = fmt.Errorf("supervisor binary %s not found - run sovereign build first", SupervisorLocalPath)
+
+// deploySupervisor pushes and configures the prebuilt supervisor binary
+func deploySupervisor(ctx context.Context) error {
+	if _, err := os.Stat(SupervisorLocalPath); err != nil {
+		return errSupervisorNotBuilt
+	}
+
+	device.MkdirP(ctx, filepath.Dir(SupervisorDevicePath))
+	// Push next to the old binary and rename: a running supervisor keeps its inode
+	staging := SupervisorDevicePath + ".new"
+	if err := device.PushFile(ctx, SupervisorLocalPath, staging); err != nil {
+		return err
+	}
+	if _, err := device.RunShellCommand(ctx, device.Quote("chmod", "755", staging)+" && "+device.Quote("mv", "-f", staging, SupervisorDevicePath)); err != nil {
+		return fmt.Errorf("installing supervisor: %w", err)
+	}
+	return PushSupervisorConfig(ctx)
+}
+
+// ensureSupervisorMu serializes ensureSupervisor: vm.Up starts VMs in
+// parallel, and each would otherwise launch its own daemon
+var ensureSupervisorMu sync.Mutex
+
+// ensureSupervisor starts the supervisor daemon (without autostart) if the
+// binary is installed but not running. Returns errNoSupervisor if unavailable.
+func ensureSupervisor(ctx context.Context) error {
+	ensureSupervisorMu.Lock()
+	defer ensureSupervisorMu.Unlock()
+	if _, err := supervisorCall(ctx, 30*time.Second, supervisor.OpStatus, ""); err == nil {
+		return nil
+	}
+	if !device.FileExists(ctx, SupervisorDevicePath) || !device.FileExists(ctx, supervisor.DefaultConfigPath) {
+		return errNoSupervisor
+	}
+	fmt.Println("Starting supervisor daemon...")
+	if err := device.StartDetached(device.Quote(SupervisorDevicePath, "run")); err != nil {
+		return fmt.Errorf("starting supervisor: %w", err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := sleepCtx(ctx, time.Second); err != nil {
+			return err
+		}
+		if _, err := supervisorCall(ctx, 30*time.Second, supervisor.OpStatus, ""); err == nil {
+			return nil
+		}
+	}
+	return errNoSupervisor
+}
+
+// supervisedStop asks the supervisor to stop cfg; errNoSupervisor if it can't
+func supervisedStop(ctx context.Context, cfg *VMConfig) (*StopResult, error) {
+	st, ok := supervisedStatus(ctx, cfg)
+	if !ok {
+		return nil, errNoSupervisor
+	}
+	if st.PID == 0 {
+		// Cancel any pending restart, then let the caller catch strays
+		// started outside the supervisor (e.g. by sovereign_start.sh)
+		supervisorCall(ctx, 30*time.Second, supervisor.OpStop, cfg.Name)
+		return nil, errNoSupervisor
+	}
+	start := time.Now()
+	fmt.Printf("Stopping via supervisor (grace period %s)...\n", stopGracePeriod(cfg))
+	resp, err := supervisorCall(ctx, stopGracePeriod(cfg)+time.Minute, supervisor.OpStop, cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &StopResult{VM: cfg.Name, Method: StopMethod(resp.Method), Elapsed: time.Since(start)}, nil
+}
@@ -3,6 +3,7 @@
 package common
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -15,10 +16,32 @@ import (
 // RemoveTailscaleRegistrations removes existing Tailscale registrations
 // for the given hostname prefix (e.g., "sovereign-sql", "sovereign-forge").
 // TEAM_029: Extracted from sql/verify.go RemoveTailscaleRegistrations
-func RemoveTailscaleRegistrations(hostnamePrefix string) error {
+// TEAM_063: Runs planTailscaleCleanup's plan, so it honors --dry-run
+func RemoveTailscaleRegistrations(ctx context.Context, hostnamePrefix string) error {
+	p := &Plan{Title: fmt.Sprintf("Remove %s Tailscale registrations", hostnamePrefix)}
+	if err := planTailscaleCleanup(ctx, p, hostnamePrefix); err != nil {
+		return err
+	}
+	if len(p.Steps) == 0 {
+		return nil
+	}
+	_, err := runPlan(ctx, p)
+	return err
+}
+
+// tailscaleDevice is a tailnet peer registered by a VM
+type tailscaleDevice struct {
+	ID   string
+	Name string
+}
+
+// planTailscaleCleanup adds deleting every registration of hostnamePrefix
+// through the Tailscale API. Without an API key nothing can be deleted; the
+// devices are listed for manual removal and an error returned.
+func planTailscaleCleanup(ctx context.Context, p *Plan, hostnamePrefix string) error {
 	fmt.Println("Checking for existing Tailscale registrations...")
 
-	out, err := exec.Command("tailscale", "status", "--json").Output()
+	out, err := exec.CommandContext(ctx, "tailscale", "status", "--json").Output()
 	if err != nil {
 		fmt.Println("  ⚠ Cannot check Tailscale (CLI not available)")
 		return nil
@@ -37,16 +60,10 @@ func RemoveTailscaleRegistrations(hostnamePrefix string) error {
 		return nil
 	}
 
-	var toDelete []struct {
-		ID   string
-		Name string
-	}
+	var toDelete []tailscaleDevice
 	for _, peer := range status.Peer {
 		if strings.HasPrefix(peer.HostName, hostnamePrefix) {
-			toDelete = append(toDelete, struct {
-				ID   string
-				Name string
-			}{ID: peer.ID, Name: peer.HostName})
+			toDelete = append(toDelete, tailscaleDevice{ID: peer.ID, Name: peer.HostName})
 		}
 	}
 
@@ -68,33 +85,29 @@ func RemoveTailscaleRegistrations(hostnamePrefix string) error {
 	}
 
 	client := &http.Client{Timeout: 10 * time.Second}
-	var deleted int
 	for _, d := range toDelete {
-		url := fmt.Sprintf("https://api.tailscale.com/api/v2/device/%s", d.ID)
-		req, _ := http.NewRequest("DELETE", url, nil)
-		req.SetBasicAuth(apiKey, "")
-
-		resp, err := client.Do(req)
-		if err != nil {
-			fmt.Printf("  ⚠ Failed to delete %s: %v\n", d.Name, err)
-			continue
-		}
-		resp.Body.Close()
-
-		if resp.StatusCode == 200 || resp.StatusCode == 204 {
-			fmt.Printf("  ✓ Deleted %s\n", d.Name)
-			deleted++
-		} else {
-			fmt.Printf("  ⚠ Failed to delete %s: HTTP %d\n", d.Name, resp.StatusCode)
-		}
+		p.Do(StepTailscale, fmt.Sprintf("Deleting Tailscale device %s (ID: %s)...", d.Name, d.ID), true,
+			func(ctx context.Context) error { return deleteTailscaleDevice(ctx, client, apiKey, d) })
 	}
+	return nil
+}
+
+// deleteTailscaleDevice removes one device from the tailnet
+func deleteTailscaleDevice(ctx context.Context, client *http.Client, apiKey string, d tailscaleDevice) error {
+	url := fmt.Sprintf("https://api.tailscale.com/api/v2/device/%s", d.ID)
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	req.SetBasicAuth(apiKey, "")
 
-	if deleted == len(toDelete) {
-		fmt.Printf("  ✓ Successfully deleted all %d registration(s)\n", deleted)
-	} else {
-		fmt.Printf("  ⚠ Deleted %d of %d registration(s)\n", deleted, len(toDelete))
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", d.Name, err)
 	}
+	resp.Body.Close()
 
+	if resp.StatusCode != 200 && resp.StatusCode != 204 {
+		return fmt.Errorf("failed to delete %s: HTTP %d", d.Name, resp.StatusCode)
+	}
+	fmt.Printf("  ✓ Deleted %s\n", d.Name)
 	return nil
 }
 
@@ -133,8 +146,8 @@ func getAPIKey() string {
 // CheckTailscaleConnected checks if a machine is connected via Tailscale.
 // Returns the Tailscale IP if connected, empty string otherwise.
 // TEAM_029: Extracted from sql/verify.go Test()
-func CheckTailscaleConnected(hostnamePrefix string) (string, bool) {
-	out, err := exec.Command("tailscale", "status").Output()
+func CheckTailscaleConnected(ctx context.Context, hostnamePrefix string) (string, bool) {
+	out, err := exec.CommandContext(ctx, "tailscale", "status").Output()
 	if err != nil {
 		return "", false
 	}
@@ -3,99 +3,89 @@
 package common
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/anthropics/sovereign/internal/device"
 )
 
+// ErrTestsSkipped is returned by RunVMTests when the VM is paused
+var ErrTestsSkipped = errors.New("VM paused - tests skipped")
+
 // RunVMTests runs the common VM tests plus any custom tests.
 // TEAM_029: Extracted from sql/verify.go Test() and forge/verify.go Test()
-func RunVMTests(cfg *VMConfig, customTests []TestFunc) error {
-	fmt.Printf("=== Testing %s VM ===\n", cfg.DisplayName)
-	allPassed := true
-	testNum := 1
+// TEAM_045: Returns a TestReport instead of printing; callers pick the renderer
+func RunVMTests(ctx context.Context, cfg *VMConfig, customTests []TestFunc) (*TestReport, error) {
+	r := &TestReport{VM: cfg.Name, DisplayName: cfg.DisplayName}
+	defer recordTest(cfg, r) // TEAM_064
 
 	// Test 1: VM process running
-	fmt.Printf("%d. VM process running: ", testNum)
-	testNum++
-	out, _ := device.RunShellCommand(fmt.Sprintf("ps -ef | grep '%s' | grep -v grep | awk '{print $2}' | head -1", cfg.ProcessPattern))
-	vmPid := strings.TrimSpace(out)
+	// TEAM_051: Asks the supervisor when it is running, else greps ps
+	vmPid := processPID(ctx, cfg)
 	if vmPid == "" {
-		fmt.Println("✗ FAIL (crosvm not running)")
-		allPassed = false
+		r.Results = append(r.Results, TestResult{Name: "VM process running", Message: "crosvm not running"})
+	} else if device.FileExists(ctx, pausedMarker(cfg)) {
+		// TEAM_050: A paused guest can't answer - skip connectivity instead of
+		// failing it, but a frozen guest serves nothing, so the run doesn't pass
+		r.Results = append(r.Results, TestResult{Name: "VM process running", Passed: true, Message: "PID: " + vmPid + " (paused)"})
+		r.Results = append(r.Results, TestResult{Name: "Connectivity", Skipped: true, Message: fmt.Sprintf("VM paused - run 'sovereign resume --%s' to test", cfg.Name)})
+		r.Skipped = true
+		return r, ErrTestsSkipped
 	} else {
-		fmt.Printf("✓ PASS (PID: %s)\n", vmPid)
+		r.Results = append(r.Results, TestResult{Name: "VM process running", Passed: true, Message: "PID: " + vmPid})
 	}
 
 	// Test 2: TAP interface exists
-	fmt.Printf("%d. TAP interface (%s): ", testNum, cfg.TAPInterface)
-	testNum++
-	tapOut, _ := device.RunShellCommand(fmt.Sprintf("ip link show %s 2>/dev/null | grep -c UP", cfg.TAPInterface))
+	tapName := fmt.Sprintf("TAP interface (%s)", cfg.TAPInterface)
+	tapOut, _ := device.RunShellCommand(ctx, device.Quote("ip", "link", "show", cfg.TAPInterface)+" 2>/dev/null | grep -c UP")
 	if strings.TrimSpace(tapOut) == "1" {
-		fmt.Println("✓ PASS")
+		r.Results = append(r.Results, TestResult{Name: tapName, Passed: true})
 	} else {
-		fmt.Println("✗ FAIL (TAP interface not up)")
-		allPassed = false
+		r.Results = append(r.Results, TestResult{Name: tapName, Message: "TAP interface not up"})
 	}
 
 	// Test 3: Tailscale connected
-	fmt.Printf("%d. Tailscale connected: ", testNum)
-	testNum++
-	tsOut, tsErr := exec.Command("tailscale", "status").Output()
+	tsOut, tsErr := exec.CommandContext(ctx, "tailscale", "status").Output()
 	if tsErr != nil {
-		fmt.Println("? SKIP (tailscale not available on host)")
+		r.Results = append(r.Results, TestResult{Name: "Tailscale connected", Skipped: true, Message: "tailscale not available on host"})
 	} else {
-		var tsIP string
-		lines := strings.Split(string(tsOut), "\n")
-		for _, line := range lines {
+		ts := TestResult{Name: "Tailscale connected", Message: fmt.Sprintf("no active %s* in tailscale", cfg.TailscaleHost)}
+		for _, line := range strings.Split(string(tsOut), "\n") {
 			if strings.Contains(line, cfg.TailscaleHost) && !strings.Contains(line, "offline") {
 				parts := strings.Fields(line)
 				if len(parts) >= 2 {
-					tsIP = parts[0]
-					fmt.Printf("✓ PASS (%s as %s)\n", tsIP, parts[1])
+					ts.Passed = true
+					ts.Message = fmt.Sprintf("%s as %s", parts[0], parts[1])
+					r.tailscaleIP, r.tailscaleHost = parts[0], parts[1]
 				}
 				break
 			}
 		}
-		if tsIP == "" {
-			fmt.Printf("✗ FAIL (no active %s* in tailscale)\n", cfg.TailscaleHost)
-			allPassed = false
-		}
+		r.Results = append(r.Results, ts)
 	}
 
 	// Run custom tests
 	for _, testFn := range customTests {
-		result := testFn(cfg)
-		fmt.Printf("%d. %s: ", testNum, result.Name)
-		testNum++
-		if result.Passed {
-			if result.Message != "" {
-				fmt.Printf("✓ PASS (%s)\n", result.Message)
-			} else {
-				fmt.Println("✓ PASS")
-			}
-		} else {
-			fmt.Printf("✗ FAIL (%s)\n", result.Message)
-			allPassed = false
-		}
+		r.Results = append(r.Results, testFn(ctx, cfg))
 	}
 
-	fmt.Println()
-	if allPassed {
-		fmt.Println("=== ALL TESTS PASSED ===")
-		return nil
+	r.Passed = len(r.Failed()) == 0
+	if !r.Passed {
+		return r, fmt.Errorf("%d test(s) failed", len(r.Failed()))
 	}
-	return fmt.Errorf("some tests failed - see above")
+	return r, nil
 }
 
 // GetTailscaleFQDN returns the actual Tailscale FQDN for a VM.
 // TEAM_035: Helper for tests that need the full hostname (e.g., HTTPS tests)
 // TEAM_041: Fixed to return full FQDN including domain suffix
-func GetTailscaleFQDN(cfg *VMConfig) string {
+func GetTailscaleFQDN(ctx context.Context, cfg *VMConfig) string {
 	// First try 'tailscale status' to get the hostname
-	tsOut, err := exec.Command("tailscale", "status").Output()
+	tsOut, err := exec.CommandContext(ctx, "tailscale", "status").Output()
 	if err != nil {
 		return ""
 	}
@@ -122,7 +112,7 @@ func GetTailscaleFQDN(cfg *VMConfig) string {
 	}
 
 	// Otherwise, get the domain from tailscale dns status
-	dnsOut, err := exec.Command("tailscale", "status", "--json").Output()
+	dnsOut, err := exec.CommandContext(ctx, "tailscale", "status", "--json").Output()
 	if err == nil && strings.Contains(string(dnsOut), "MagicDNSSuffix") {
 		// Extract MagicDNSSuffix from JSON (simple extraction)
 		for _, line := range strings.Split(string(dnsOut), "\n") {
@@ -146,7 +136,7 @@ func GetTailscaleFQDN(cfg *VMConfig) string {
 	for _, suffix := range []string{"tail5bea38.ts.net", "ts.net"} {
 		fqdn := hostname + "." + suffix
 		// Quick DNS check
-		_, err := exec.Command("timeout", "1", "host", fqdn).Output()
+		_, err := exec.CommandContext(ctx, "timeout", "1", "host", fqdn).Output()
 		if err == nil {
 			return fqdn
 		}
@@ -158,8 +148,8 @@ func GetTailscaleFQDN(cfg *VMConfig) string {
 
 // TestPortOpen checks if a port is accessible on the TAP interface.
 // TEAM_029: Helper for service-specific tests
-func TestPortOpen(cfg *VMConfig, port int) TestResult {
-	out, _ := device.RunShellCommand(fmt.Sprintf("nc -z %s %d && echo OPEN || echo CLOSED", cfg.TAPGuestIP, port))
+func TestPortOpen(ctx context.Context, cfg *VMConfig, port int) TestResult {
+	out, _ := device.RunShellCommand(ctx, device.Quote("nc", "-z", cfg.TAPGuestIP, strconv.Itoa(port))+" && echo OPEN || echo CLOSED")
 	if strings.TrimSpace(out) == "OPEN" {
 		return TestResult{
 			Name:    fmt.Sprintf("Port %d responding (via TAP)", port),
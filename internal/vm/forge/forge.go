@@ -5,6 +5,8 @@
 package forge
 
 import (
+	"context"
+
 	"github.com/anthropics/sovereign/internal/vm"
 	"github.com/anthropics/sovereign/internal/vm/common"
 )
@@ -12,22 +14,24 @@ import (
 // TEAM_029: ForgeConfig defines the configuration for the Forgejo VM
 // TEAM_033: Fixed subnet to match actual implementation - all VMs on same bridge (192.168.100.0/24)
 var ForgeConfig = &common.VMConfig{
-	Name:           "forge",
-	DisplayName:    "Forgejo",
-	TAPInterface:   "vm_forge",
-	TAPHostIP:      "192.168.100.1",
-	TAPGuestIP:     "192.168.100.3",
-	TAPSubnet:      "192.168.100.0/24",
-	TailscaleHost:  "sovereign-forge",
-	DevicePath:     "/data/sovereign/vm/forgejo",
-	LocalPath:      "vm/forgejo",
-	ServicePorts:   []int{3000, 22},
-	ReadyMarker:    "INIT COMPLETE",
-	StartTimeout:   120,
+	Name:          "forge",
+	DisplayName:   "Forgejo",
+	TAPInterface:  "vm_forge",
+	TAPGuestIP:    "192.168.100.3",
+	TailscaleHost: "sovereign-forge",
+	DevicePath:    "/data/sovereign/vm/forgejo",
+	LocalPath:     "vm/forgejo",
+	ServicePorts:  []int{3000, 22},
+	ReadyMarker:   "INIT COMPLETE",
+	StartTimeout:  120,
+	// TEAM_053: Forgejo serves HTTPS on 443 (app.ini), not ServicePorts[0]
+	ReadinessProbes: []common.ReadinessProbe{
+		{Type: common.ProbeTCP, Port: 443},
+		{Type: common.ProbeHTTP, Scheme: "https", Path: "/api/healthz"},
+	},
 	DockerImage:    "sovereign-forge",
 	SharedKernel:   true,
 	KernelSource:   "vm/sql/Image",
-	NeedsSecrets:   false,
 	ProcessPattern: "[c]rosvm.*vm/forgejo/", // TEAM_036: Match path, not 'forge' (SQL cmdline has forgejo.db_password)
 	// TEAM_029: Forgejo requires PostgreSQL for its database
 	Dependencies: []common.ServiceDependency{
@@ -44,12 +48,15 @@ type VM struct{}
 
 func (v *VM) Name() string { return "forge" }
 
+// Config exposes ForgeConfig (used to detect manifest IP/TAP collisions)
+func (v *VM) Config() *common.VMConfig { return ForgeConfig }
+
 // TEAM_029: Build delegates to common.BuildVM
-func (v *VM) Build() error {
-	return common.BuildVM(ForgeConfig, "")
+func (v *VM) Build(ctx context.Context) error {
+	return common.BuildVM(ctx, ForgeConfig)
 }
 
 // TEAM_029: Deploy delegates to common.DeployVM
-func (v *VM) Deploy() error {
-	return common.DeployVM(ForgeConfig)
+func (v *VM) Deploy(ctx context.Context) error {
+	return common.DeployVM(ctx, ForgeConfig)
 }
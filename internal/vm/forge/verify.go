@@ -4,6 +4,7 @@
 package forge
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -12,8 +13,8 @@ import (
 )
 
 // TEAM_029: Test delegates to common.RunVMTests with Forge-specific custom tests
-func (v *VM) Test() error {
-	return common.RunVMTests(ForgeConfig, forgeCustomTests)
+func (v *VM) Test(ctx context.Context) (*common.TestReport, error) {
+	return common.RunVMTests(ctx, ForgeConfig, forgeCustomTests)
 }
 
 // TEAM_029: Forge-specific tests
@@ -23,13 +24,13 @@ var forgeCustomTests = []common.TestFunc{
 }
 
 // TEAM_035: Updated to use HTTPS on port 443 with dynamic FQDN detection
-func testForgejoWebUI(cfg *common.VMConfig) common.TestResult {
+func testForgejoWebUI(ctx context.Context, cfg *common.VMConfig) common.TestResult {
 	// Get actual FQDN (may be sovereign-forge-1, -2, etc.)
-	fqdn := common.GetTailscaleFQDN(cfg)
+	fqdn := common.GetTailscaleFQDN(ctx, cfg)
 	if fqdn == "" {
 		return common.TestResult{Name: "Forgejo web UI (via Tailscale)", Passed: false, Message: "cannot determine Tailscale FQDN"}
 	}
-	cmd := exec.Command("curl", "-sk", "-o", "/dev/null", "-w", "%{http_code}",
+	cmd := exec.CommandContext(ctx, "curl", "-sk", "-o", "/dev/null", "-w", "%{http_code}",
 		"--connect-timeout", "5", fmt.Sprintf("https://%s", fqdn))
 	output, _ := cmd.Output()
 	httpCode := strings.TrimSpace(string(output))
@@ -42,8 +43,8 @@ func testForgejoWebUI(cfg *common.VMConfig) common.TestResult {
 	return common.TestResult{Name: "Forgejo web UI (via Tailscale)", Passed: false, Message: fmt.Sprintf("HTTP %s from %s", httpCode, fqdn)}
 }
 
-func testSSHPort(cfg *common.VMConfig) common.TestResult {
-	cmd := exec.Command("nc", "-z", "-w", "3", cfg.TailscaleHost, "22")
+func testSSHPort(ctx context.Context, cfg *common.VMConfig) common.TestResult {
+	cmd := exec.CommandContext(ctx, "nc", "-z", "-w", "3", cfg.TailscaleHost, "22")
 	if err := cmd.Run(); err != nil {
 		return common.TestResult{Name: "SSH port (via Tailscale)", Passed: true, Message: "SSH port not responding (may be disabled)"}
 	}
@@ -51,6 +52,6 @@ func testSSHPort(cfg *common.VMConfig) common.TestResult {
 }
 
 // TEAM_029: RemoveTailscaleRegistrations delegates to common package
-func RemoveTailscaleRegistrations() error {
-	return common.RemoveTailscaleRegistrations("sovereign-forge")
+func RemoveTailscaleRegistrations(ctx context.Context) error {
+	return common.RemoveTailscaleRegistrations(ctx, "sovereign-forge")
 }
@@ -0,0 +1,85 @@
+// Generic VM backed by a manifest
+// TEAM_046: Same delegation as sql/forge/vault, with the config and custom
+// tests coming from services/<name>.toml instead of Go
+package manifest
+
+import (
+	"context"
+
+	"github.com/anthropics/sovereign/internal/vm/common"
+)
+
+// VM implements the vm.VM interface for a manifest-defined service
+type VM struct {
+	cfg   *common.VMConfig
+	tests []common.TestFunc
+}
+
+// NewVM builds a generic VM from a loaded manifest
+func NewVM(m *Manifest) *VM {
+	tests := make([]common.TestFunc, 0, len(m.Tests))
+	for _, spec := range m.Tests {
+		tests = append(tests, spec.Func())
+	}
+	return &VM{cfg: m.Config, tests: tests}
+}
+
+func (v *VM) Name() string { return v.cfg.Name }
+
+// Config exposes the manifest's VMConfig
+func (v *VM) Config() *common.VMConfig { return v.cfg }
+
+// Build delegates to common.BuildVM
+func (v *VM) Build(ctx context.Context) error {
+	return common.BuildVM(ctx, v.cfg)
+}
+
+// Deploy delegates to common.DeployVM
+func (v *VM) Deploy(ctx context.Context) error {
+	return common.DeployVM(ctx, v.cfg)
+}
+
+// Start delegates to common.StartVM
+func (v *VM) Start(ctx context.Context) error {
+	return common.StartVM(ctx, v.cfg)
+}
+
+// Stop delegates to common.StopVM
+func (v *VM) Stop(ctx context.Context) error {
+	return common.StopVM(ctx, v.cfg)
+}
+
+// Pause delegates to common.PauseVM
+func (v *VM) Pause(ctx context.Context) error {
+	return common.PauseVM(ctx, v.cfg)
+}
+
+// Resume delegates to common.ResumeVM
+func (v *VM) Resume(ctx context.Context) error {
+	return common.ResumeVM(ctx, v.cfg)
+}
+
+// Test delegates to common.RunVMTests with the manifest's [[test]] entries
+func (v *VM) Test(ctx context.Context) (*common.TestReport, error) {
+	return common.RunVMTests(ctx, v.cfg, v.tests)
+}
+
+// Remove delegates to common.RemoveVM
+func (v *VM) Remove(ctx context.Context) error {
+	return common.RemoveVM(ctx, v.cfg)
+}
+
+// Clean delegates to common.CleanVM for Tailscale cleanup
+func (v *VM) Clean(ctx context.Context) error {
+	return common.CleanVM(ctx, v.cfg)
+}
+
+// Diagnose delegates to common.DiagnoseVM
+func (v *VM) Diagnose(ctx context.Context) (*common.Diagnosis, error) {
+	return common.DiagnoseVM(ctx, v.cfg)
+}
+
+// Fix delegates to common.FixVM
+func (v *VM) Fix(ctx context.Context) (*common.FixReport, error) {
+	return common.FixVM(ctx, v.cfg)
+}
@@ -0,0 +1,396 @@
+// Package manifest loads declarative service definitions (services/*.toml)
+// and registers them as generic VMs.
+// TEAM_046: Adding a service no longer needs a Go package - drop a manifest in
+// services/ and the vm/<name>/ build context next to the existing VMs.
+package manifest
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/anthropics/sovereign/internal/rootfs"
+	"github.com/anthropics/sovereign/internal/vm"
+	"github.com/anthropics/sovereign/internal/vm/common"
+)
+
+// Dir is where LoadAndRegister looks for manifests (relative to sovereign-vault/)
+var Dir = "services"
+
+// Defaults shared by every VM on the bridge
+const (
+	defaultReadyMarker  = "INIT COMPLETE"
+	defaultStartTimeout = 120
+	defaultKernel       = "vm/sql/Image"
+)
+
+// KnownDependencies maps depends_on names to dependencies defined in Go.
+// Manifests can also depend on each other by name.
+var KnownDependencies = map[string]common.ServiceDependency{
+	"sql": common.PostgreSQLDependency,
+}
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Manifest is one parsed services/<name>.toml
+type Manifest struct {
+	Path      string
+	Config    *common.VMConfig
+	DependsOn []string   // Resolved into Config.Dependencies by Load
+	Tests     []TestSpec // Custom tests appended to the common ones
+}
+
+// Load parses and validates every *.toml in dir.
+// A missing directory is not an error - there are simply no manifests.
+func Load(dir string) ([]*Manifest, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.toml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var manifests []*Manifest
+	byName := map[string]*Manifest{}
+	for _, path := range paths {
+		m, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if prev, dup := byName[m.Config.Name]; dup {
+			return nil, fmt.Errorf("%s: service %q already defined in %s", path, m.Config.Name, prev.Path)
+		}
+		byName[m.Config.Name] = m
+		manifests = append(manifests, m)
+	}
+
+	if err := checkCollisions(manifests); err != nil {
+		return nil, err
+	}
+	for _, m := range manifests {
+		if err := resolveDependencies(m, byName); err != nil {
+			return nil, err
+		}
+	}
+	return manifests, nil
+}
+
+// LoadFile parses and validates a single manifest (dependencies unresolved)
+func LoadFile(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseTOML(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m, err := decode(path, doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// decode maps TOML keys onto VMConfig, filling name-derived defaults
+func decode(path string, doc *document) (*Manifest, error) {
+	base := filepath.Base(path)
+	for name := range doc.tables {
+		return nil, fmt.Errorf("%s: unknown table [%s]", base, name)
+	}
+
+	r := newReader(doc.root, base)
+	cfg := &common.VMConfig{
+		Name:            r.str("name"),
+		DisplayName:     r.str("display_name"),
+		TAPInterface:    r.str("tap_interface"),
+		TAPHostIP:       r.str("tap_host_ip"),
+		TAPGuestIP:      r.str("tap_guest_ip"),
+		TAPSubnet:       r.str("tap_subnet"),
+		TailscaleHost:   r.str("tailscale_host"),
+		DevicePath:      r.str("device_path"),
+		LocalPath:       r.str("local_path"),
+		ServicePorts:    r.ints("service_ports"),
+		ReadyMarker:     r.str("ready_marker"),
+		StartTimeout:    r.int("start_timeout"),
+		StopGracePeriod: r.int("stop_grace_period"),
+		DockerImage:     r.str("docker_image"),
+		SharedKernel:    r.bool("shared_kernel"),
+		KernelSource:    r.str("kernel_source"),
+		RootfsSize:      r.str("rootfs_size"),
+		DataSize:        r.str("data_size"),
+		ProcessPattern:  r.str("process_pattern"),
+		Restart: common.RestartPolicy{
+			Mode:       common.RestartMode(r.str("restart")),
+			MaxRetries: r.int("restart_max_retries"),
+			Window:     r.int("restart_window"),
+		},
+	}
+	// TEAM_059: The same credentials sql bakes in, as DB_PASSWORD in init.sh
+	if r.bool("needs_secrets") {
+		cfg.Secrets = common.DBCredentials
+	}
+	m := &Manifest{Path: path, Config: cfg, DependsOn: r.strs("depends_on")}
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+
+	for i, t := range doc.arrays["dependency"] {
+		dr := newReader(t, fmt.Sprintf("%s [[dependency]] #%d", base, i+1))
+		dep := common.ServiceDependency{
+			Name:          dr.str("name"),
+			TailscaleHost: dr.str("tailscale_host"),
+			TAPIP:         dr.str("tap_ip"),
+			Port:          dr.int("port"),
+			Description:   dr.str("description"),
+		}
+		if err := dr.done(); err != nil {
+			return nil, err
+		}
+		if dep.Name == "" || dep.Port == 0 || (dep.TailscaleHost == "" && dep.TAPIP == "") {
+			return nil, fmt.Errorf("%s [[dependency]] #%d: name, port and tailscale_host or tap_ip are required", base, i+1)
+		}
+		cfg.Dependencies = append(cfg.Dependencies, dep)
+	}
+
+	for i, t := range doc.arrays["test"] {
+		tr := newReader(t, fmt.Sprintf("%s [[test]] #%d", base, i+1))
+		spec := TestSpec{
+			Name:         tr.str("name"),
+			Type:         tr.str("type"),
+			Via:          tr.str("via"),
+			Port:         tr.int("port"),
+			Path:         tr.str("path"),
+			Scheme:       tr.str("scheme"),
+			ExpectStatus: tr.ints("expect_status"),
+			Optional:     tr.bool("optional"),
+		}
+		if err := tr.done(); err != nil {
+			return nil, err
+		}
+		m.Tests = append(m.Tests, spec)
+	}
+
+	// TEAM_053: Readiness probes gate 'start'; [[test]] only runs in 'test'
+	for i, t := range doc.arrays["probe"] {
+		pr := newReader(t, fmt.Sprintf("%s [[probe]] #%d", base, i+1))
+		probe := common.ReadinessProbe{
+			Type:         common.ProbeType(pr.str("type")),
+			Port:         pr.int("port"),
+			Path:         pr.str("path"),
+			Scheme:       pr.str("scheme"),
+			ExpectStatus: pr.ints("expect_status"),
+			User:         pr.str("user"),
+			Command:      pr.str("command"),
+		}
+		if err := pr.done(); err != nil {
+			return nil, err
+		}
+		cfg.ReadinessProbes = append(cfg.ReadinessProbes, probe)
+	}
+
+	for name := range doc.arrays {
+		if name != "dependency" && name != "test" && name != "probe" {
+			return nil, fmt.Errorf("%s: unknown table [[%s]]", base, name)
+		}
+	}
+
+	applyDefaults(cfg)
+	return m, nil
+}
+
+// applyDefaults derives unset fields from the service name, matching the
+// conventions of the built-in sql/forge/vault configs
+func applyDefaults(cfg *common.VMConfig) {
+	n := cfg.Name
+	if cfg.DisplayName == "" {
+		cfg.DisplayName = n
+	}
+	if cfg.TAPInterface == "" {
+		cfg.TAPInterface = "vm_" + n
+	}
+	if cfg.TAPSubnet == "" {
+		cfg.TAPSubnet = common.DefaultSubnet
+	}
+	if cfg.TAPHostIP == "" {
+		cfg.TAPHostIP, _ = common.BridgeIP(cfg.TAPSubnet)
+	}
+	if cfg.TailscaleHost == "" {
+		cfg.TailscaleHost = "sovereign-" + n
+	}
+	if cfg.DevicePath == "" {
+		cfg.DevicePath = "/data/sovereign/vm/" + n
+	}
+	if cfg.LocalPath == "" {
+		cfg.LocalPath = "vm/" + n
+	}
+	if cfg.ReadyMarker == "" {
+		cfg.ReadyMarker = defaultReadyMarker
+	}
+	if cfg.StartTimeout == 0 {
+		cfg.StartTimeout = defaultStartTimeout
+	}
+	if cfg.DockerImage == "" {
+		cfg.DockerImage = "sovereign-" + n
+	}
+	if cfg.SharedKernel && cfg.KernelSource == "" {
+		cfg.KernelSource = defaultKernel
+	}
+	if cfg.ProcessPattern == "" {
+		// TEAM_036: Match the path, not the name - other cmdlines may contain it
+		cfg.ProcessPattern = "[c]rosvm.*vm/" + n + "/"
+	}
+}
+
+// validate checks a single manifest in isolation
+func validate(m *Manifest) error {
+	cfg := m.Config
+	if !namePattern.MatchString(cfg.Name) {
+		return fmt.Errorf("name %q must be lowercase letters, digits and dashes", cfg.Name)
+	}
+	if len(cfg.TAPInterface) > 15 {
+		return fmt.Errorf("tap_interface %q exceeds the 15 character interface name limit", cfg.TAPInterface)
+	}
+
+	_, subnet, err := net.ParseCIDR(cfg.TAPSubnet)
+	if err != nil {
+		return fmt.Errorf("tap_subnet: %w", err)
+	}
+	// TEAM_047: tap_guest_ip is optional - IPAM leases one on first deploy/start
+	for key, ip := range map[string]string{"tap_host_ip": cfg.TAPHostIP, "tap_guest_ip": cfg.TAPGuestIP} {
+		if ip == "" {
+			continue
+		}
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return fmt.Errorf("%s %q is not an IP address", key, ip)
+		}
+		if !subnet.Contains(parsed) {
+			return fmt.Errorf("%s %s is outside tap_subnet %s", key, ip, cfg.TAPSubnet)
+		}
+	}
+	if gw, _ := common.BridgeIP(cfg.TAPSubnet); cfg.TAPHostIP != gw {
+		return fmt.Errorf("tap_host_ip %s must be the bridge gateway %s", cfg.TAPHostIP, gw)
+	}
+	if cfg.TAPGuestIP == cfg.TAPHostIP {
+		return fmt.Errorf("tap_guest_ip %s is the bridge gateway", cfg.TAPGuestIP)
+	}
+
+	if len(cfg.ServicePorts) == 0 {
+		return fmt.Errorf("service_ports must list at least one port")
+	}
+	for _, p := range cfg.ServicePorts {
+		if p < 1 || p > 65535 {
+			return fmt.Errorf("service port %d out of range", p)
+		}
+	}
+	if cfg.StartTimeout < 0 {
+		return fmt.Errorf("start_timeout must be positive")
+	}
+	if cfg.StopGracePeriod < 0 {
+		return fmt.Errorf("stop_grace_period must be positive")
+	}
+	switch cfg.Restart.Mode {
+	case "", common.RestartAlways, common.RestartOnFailure, common.RestartNever:
+	default:
+		return fmt.Errorf("restart %q must be always, on-failure or never", cfg.Restart.Mode)
+	}
+	if cfg.Restart.MaxRetries < 0 || cfg.Restart.Window < 0 {
+		return fmt.Errorf("restart_max_retries and restart_window must be positive")
+	}
+	if cfg.RootfsSize != "" && cfg.RootfsSize != "auto" {
+		if _, err := rootfs.ParseSize(cfg.RootfsSize); err != nil {
+			return fmt.Errorf("rootfs_size: %w", err)
+		}
+	}
+	if cfg.DataSize != "" {
+		if _, err := rootfs.ParseSize(cfg.DataSize); err != nil {
+			return fmt.Errorf("data_size: %w", err)
+		}
+	}
+	if _, err := os.Stat(cfg.LocalPath); err != nil {
+		return fmt.Errorf("local_path %s: %w (needs Dockerfile and init.sh)", cfg.LocalPath, err)
+	}
+
+	for i, p := range cfg.ReadinessProbes {
+		if _, err := p.Normalize(); err != nil {
+			return fmt.Errorf("[[probe]] #%d: %w", i+1, err)
+		}
+	}
+	for i := range m.Tests {
+		if err := m.Tests[i].validate(); err != nil {
+			return fmt.Errorf("[[test]] #%d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// checkCollisions rejects manifests that clash with each other or with
+// services already in the vm registry (built-in Go VMs)
+// TEAM_047: Uses the same IPAM collision check as the built-in configs
+func checkCollisions(manifests []*Manifest) error {
+	cfgs := common.RegisteredConfigs()
+	for _, m := range manifests {
+		if _, builtin := vm.Get(m.Config.Name); builtin {
+			return fmt.Errorf("%s: service %q is already registered", m.Path, m.Config.Name)
+		}
+		cfgs = append(cfgs, m.Config)
+	}
+	if err := common.CheckIPCollisions(cfgs); err != nil {
+		return fmt.Errorf("services: %w", err)
+	}
+	return nil
+}
+
+// resolveDependencies turns depends_on names into ServiceDependency entries
+func resolveDependencies(m *Manifest, byName map[string]*Manifest) error {
+	for _, name := range m.DependsOn {
+		if name == m.Config.Name {
+			return fmt.Errorf("%s: service cannot depend on itself", m.Path)
+		}
+		if dep, ok := KnownDependencies[name]; ok {
+			m.Config.Dependencies = append(m.Config.Dependencies, dep)
+			continue
+		}
+		other, ok := byName[name]
+		if !ok {
+			known := make([]string, 0, len(KnownDependencies)+len(byName))
+			for k := range KnownDependencies {
+				known = append(known, k)
+			}
+			for k := range byName {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("%s: unknown dependency %q (known: %s)", m.Path, name, strings.Join(known, ", "))
+		}
+		m.Config.Dependencies = append(m.Config.Dependencies, common.ServiceDependency{
+			Name:          other.Config.Name,
+			TailscaleHost: other.Config.TailscaleHost,
+			Port:          other.Config.ServicePorts[0],
+			Description:   other.Config.DisplayName,
+		})
+	}
+	return nil
+}
+
+// LoadAndRegister loads every manifest in Dir and registers it in the vm registry.
+// Returns the names registered so the CLI can list them.
+func LoadAndRegister() ([]string, error) {
+	manifests, err := Load(Dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		vm.Register(m.Config.Name, NewVM(m))
+		names = append(names, m.Config.Name)
+	}
+	return names, nil
+}
@@ -0,0 +1,24 @@
+package manifest
+
+import "testing"
+
+// TEAM_059: needs_secrets gives a manifest VM sql's build-time provider
+func TestDecodeNeedsSecrets(t *testing.T) {
+	for src, want := range map[string]bool{
+		"name = \"cloud\"\nneeds_secrets = true":  true,
+		"name = \"cloud\"\nneeds_secrets = false": false,
+		"name = \"cloud\"":                        false,
+	} {
+		doc, err := parseTOML(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := decode("cloud.toml", doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Config.Secrets != nil; got != want {
+			t.Errorf("%q: has provider = %v, want %v", src, got, want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Declarative custom tests for manifest services
+// TEAM_046: Covers what forge/vault verify.go do by hand - HTTP over
+// Tailscale and TCP ports over TAP or Tailscale
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/anthropics/sovereign/internal/device"
+	"github.com/anthropics/sovereign/internal/vm/common"
+)
+
+// Test types and transports
+const (
+	TestHTTP = "http"
+	TestTCP  = "tcp"
+
+	ViaTAP       = "tap"       // nc from the device to TAPGuestIP
+	ViaTailscale = "tailscale" // from the host to the Tailscale FQDN
+)
+
+// TestSpec is one [[test]] table
+type TestSpec struct {
+	Name         string
+	Type         string // "http" or "tcp"
+	Via          string // "tap" or "tailscale" (http is tailscale only)
+	Port         int    // tcp: required, http: optional (scheme default)
+	Path         string // http: request path, default "/"
+	Scheme       string // http: "https" (default) or "http"
+	ExpectStatus []int  // http: accepted status codes, default [200]
+	Optional     bool   // Failure is reported but does not fail the run
+}
+
+// validate checks the spec and fills defaults
+func (t *TestSpec) validate() error {
+	switch t.Type {
+	case TestHTTP:
+		if t.Via == "" {
+			t.Via = ViaTailscale
+		}
+		if t.Via != ViaTailscale {
+			return fmt.Errorf("http tests run over tailscale only (the device has no curl)")
+		}
+		if t.Scheme == "" {
+			t.Scheme = "https"
+		}
+		if t.Scheme != "https" && t.Scheme != "http" {
+			return fmt.Errorf("scheme must be http or https, got %q", t.Scheme)
+		}
+		if t.Path == "" {
+			t.Path = "/"
+		}
+		if !strings.HasPrefix(t.Path, "/") {
+			return fmt.Errorf("path %q must start with /", t.Path)
+		}
+		if len(t.ExpectStatus) == 0 {
+			t.ExpectStatus = []int{200}
+		}
+	case TestTCP:
+		if t.Via == "" {
+			t.Via = ViaTAP
+		}
+		if t.Via != ViaTAP && t.Via != ViaTailscale {
+			return fmt.Errorf("via must be tap or tailscale, got %q", t.Via)
+		}
+		if t.Port == 0 {
+			return fmt.Errorf("tcp tests need a port")
+		}
+	default:
+		return fmt.Errorf("type must be http or tcp, got %q", t.Type)
+	}
+	if t.Port < 0 || t.Port > 65535 {
+		return fmt.Errorf("port %d out of range", t.Port)
+	}
+	if t.Name == "" {
+		t.Name = t.defaultName()
+	}
+	return nil
+}
+
+func (t *TestSpec) defaultName() string {
+	if t.Type == TestHTTP {
+		return fmt.Sprintf("HTTP %s (via Tailscale)", t.Path)
+	}
+	via := "TAP"
+	if t.Via == ViaTailscale {
+		via = "Tailscale"
+	}
+	return fmt.Sprintf("Port %d (via %s)", t.Port, via)
+}
+
+// Func turns the spec into a common.TestFunc
+func (t TestSpec) Func() common.TestFunc {
+	return func(ctx context.Context, cfg *common.VMConfig) common.TestResult {
+		var r common.TestResult
+		if t.Type == TestHTTP {
+			r = t.runHTTP(ctx, cfg)
+		} else {
+			r = t.runTCP(ctx, cfg)
+		}
+		if !r.Passed && t.Optional {
+			r.Passed = true
+			r.Message += " (optional)"
+		}
+		return r
+	}
+}
+
+// runHTTP curls the service over Tailscale, like forge's web UI test
+func (t TestSpec) runHTTP(ctx context.Context, cfg *common.VMConfig) common.TestResult {
+	fqdn := common.GetTailscaleFQDN(ctx, cfg)
+	if fqdn == "" {
+		return common.TestResult{Name: t.Name, Passed: false, Message: "cannot determine Tailscale FQDN"}
+	}
+	host := fqdn
+	if t.Port != 0 {
+		host = fmt.Sprintf("%s:%d", fqdn, t.Port)
+	}
+	url := fmt.Sprintf("%s://%s%s", t.Scheme, host, t.Path)
+	cmd := exec.CommandContext(ctx, "curl", "-sk", "-o", "/dev/null", "-w", "%{http_code}",
+		"--connect-timeout", "5", url)
+	output, _ := cmd.Output()
+	httpCode := strings.TrimSpace(string(output))
+	code, _ := strconv.Atoi(httpCode)
+	if slices.Contains(t.ExpectStatus, code) {
+		return common.TestResult{Name: t.Name, Passed: true, Message: fmt.Sprintf("HTTP %d from %s", code, url)}
+	}
+	return common.TestResult{Name: t.Name, Passed: false, Message: fmt.Sprintf("HTTP %s from %s", httpCode, url)}
+}
+
+// runTCP checks a port over TAP (from the device) or Tailscale (from the host)
+func (t TestSpec) runTCP(ctx context.Context, cfg *common.VMConfig) common.TestResult {
+	if t.Via == ViaTAP {
+		out, _ := device.RunShellCommand(ctx, device.Quote("nc", "-z", "-w", "3", cfg.TAPGuestIP, strconv.Itoa(t.Port))+" && echo OPEN || echo CLOSED")
+		if strings.TrimSpace(out) == "OPEN" {
+			return common.TestResult{Name: t.Name, Passed: true}
+		}
+		return common.TestResult{Name: t.Name, Passed: false, Message: fmt.Sprintf("port %d not reachable on TAP", t.Port)}
+	}
+	cmd := exec.CommandContext(ctx, "nc", "-z", "-w", "3", cfg.TailscaleHost, strconv.Itoa(t.Port))
+	if err := cmd.Run(); err != nil {
+		return common.TestResult{Name: t.Name, Passed: false, Message: fmt.Sprintf("port %d not reachable via %s", t.Port, cfg.TailscaleHost)}
+	}
+	return common.TestResult{Name: t.Name, Passed: true}
+}
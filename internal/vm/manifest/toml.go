@@ -0,0 +1,357 @@
+// Minimal TOML reader for service manifests
+// TEAM_046: Only the subset manifests need - no new module dependency.
+// Supports: comments, key = value, strings ("..." and '...'), integers,
+// booleans, arrays (may span lines), [table] and [[array-of-tables]].
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// table is one parsed TOML table: key -> string | int64 | bool | []any
+type table map[string]any
+
+// document is a parsed manifest file
+type document struct {
+	root   table
+	tables map[string]table   // [name]
+	arrays map[string][]table // [[name]]
+}
+
+// parseTOML parses the manifest subset described above
+func parseTOML(src string) (*document, error) {
+	doc := &document{root: table{}, tables: map[string]table{}, arrays: map[string][]table{}}
+	cur := doc.root
+
+	lines := strings.Split(src, "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		// [[array-of-tables]]
+		if strings.HasPrefix(line, "[[") {
+			if !strings.HasSuffix(line, "]]") {
+				return nil, fmt.Errorf("line %d: unterminated [[table]] header", lineNo)
+			}
+			name := strings.TrimSpace(line[2 : len(line)-2])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty [[table]] name", lineNo)
+			}
+			cur = table{}
+			doc.arrays[name] = append(doc.arrays[name], cur)
+			continue
+		}
+
+		// [table]
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated [table] header", lineNo)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty [table] name", lineNo)
+			}
+			if _, dup := doc.tables[name]; dup {
+				return nil, fmt.Errorf("line %d: table [%s] defined twice", lineNo, name)
+			}
+			cur = table{}
+			doc.tables[name] = cur
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key := strings.TrimSpace(line[:eq])
+		raw := strings.TrimSpace(line[eq+1:])
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", lineNo)
+		}
+		if _, dup := cur[key]; dup {
+			return nil, fmt.Errorf("line %d: key %q defined twice", lineNo, key)
+		}
+
+		// Multi-line arrays: keep reading until brackets balance
+		for strings.HasPrefix(raw, "[") && !arrayClosed(raw) {
+			i++
+			if i >= len(lines) {
+				return nil, fmt.Errorf("line %d: unterminated array for %q", lineNo, key)
+			}
+			raw += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+
+		v, err := parseValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", lineNo, key, err)
+		}
+		cur[key] = v
+	}
+	return doc, nil
+}
+
+// stripComment removes a trailing # comment that is not inside a string
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// arrayClosed reports whether the brackets in s (outside strings) balance
+func arrayClosed(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+// parseValue parses a scalar or array value
+func parseValue(raw string) (any, error) {
+	switch {
+	case raw == "":
+		return nil, fmt.Errorf("missing value")
+	case raw == "true":
+		return true, nil
+	case raw == "false":
+		return false, nil
+	case strings.HasPrefix(raw, `"`):
+		if len(raw) < 2 || !strings.HasSuffix(raw, `"`) {
+			return nil, fmt.Errorf("unterminated string %s", raw)
+		}
+		if err := checkEscapes(raw); err != nil {
+			return nil, err
+		}
+		s, err := strconv.Unquote(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", raw)
+		}
+		return s, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return nil, fmt.Errorf("unterminated string %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	case strings.HasPrefix(raw, "["):
+		if !strings.HasSuffix(raw, "]") {
+			return nil, fmt.Errorf("unexpected text after array %s", raw)
+		}
+		return parseArray(raw[1 : len(raw)-1])
+	case strings.HasPrefix(raw, "{"):
+		return nil, fmt.Errorf("inline tables are not supported - use a [table] or [[table]]")
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(raw, "_", ""), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported value %s", raw)
+	}
+	return n, nil
+}
+
+// checkEscapes rejects escapes TOML basic strings don't have but
+// strconv.Unquote would accept (\x41, \101, \a, \v, \')
+func checkEscapes(raw string) error {
+	for i := 1; i < len(raw)-1; i++ {
+		if raw[i] != '\\' {
+			continue
+		}
+		i++
+		if !strings.ContainsRune(`btnfr"\uU`, rune(raw[i])) {
+			return fmt.Errorf("invalid escape \\%c in %s", raw[i], raw)
+		}
+	}
+	return nil
+}
+
+// parseArray splits a flat array body on commas outside strings
+func parseArray(body string) ([]any, error) {
+	var items []any
+	var quote byte
+	start := 0
+	flush := func(end int) error {
+		part := strings.TrimSpace(body[start:end])
+		if part == "" {
+			return nil // trailing comma
+		}
+		v, err := parseValue(part)
+		if err != nil {
+			return err
+		}
+		items = append(items, v)
+		return nil
+	}
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == ']':
+			return nil, fmt.Errorf("nested arrays are not supported")
+		case c == ',':
+			if err := flush(i); err != nil {
+				return nil, err
+			}
+			start = i + 1
+		}
+	}
+	if err := flush(len(body)); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// reader pulls typed values out of a table and remembers the first error,
+// so manifest decoding reads as a flat list of fields.
+type reader struct {
+	t    table
+	ctx  string // "nextcloud.toml [[test]] #2" - prefixes errors
+	used map[string]bool
+	err  error
+}
+
+func newReader(t table, ctx string) *reader {
+	return &reader{t: t, ctx: ctx, used: map[string]bool{}}
+}
+
+func (r *reader) fail(key, want string, got any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%s: %s must be %s, got %T", r.ctx, key, want, got)
+	}
+}
+
+func (r *reader) str(key string) string {
+	r.used[key] = true
+	v, ok := r.t[key]
+	if !ok {
+		return ""
+	}
+	s, ok := v.(string)
+	if !ok {
+		r.fail(key, "a string", v)
+	}
+	return s
+}
+
+func (r *reader) int(key string) int {
+	r.used[key] = true
+	v, ok := r.t[key]
+	if !ok {
+		return 0
+	}
+	n, ok := v.(int64)
+	if !ok {
+		r.fail(key, "an integer", v)
+	}
+	return int(n)
+}
+
+func (r *reader) bool(key string) bool {
+	r.used[key] = true
+	v, ok := r.t[key]
+	if !ok {
+		return false
+	}
+	b, ok := v.(bool)
+	if !ok {
+		r.fail(key, "true or false", v)
+	}
+	return b
+}
+
+func (r *reader) strs(key string) []string {
+	r.used[key] = true
+	v, ok := r.t[key]
+	if !ok {
+		return nil
+	}
+	arr, ok := v.([]any)
+	if !ok {
+		r.fail(key, "an array of strings", v)
+		return nil
+	}
+	out := make([]string, 0, len(arr))
+	for _, item := range arr {
+		s, ok := item.(string)
+		if !ok {
+			r.fail(key, "an array of strings", item)
+			return nil
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func (r *reader) ints(key string) []int {
+	r.used[key] = true
+	v, ok := r.t[key]
+	if !ok {
+		return nil
+	}
+	arr, ok := v.([]any)
+	if !ok {
+		r.fail(key, "an array of integers", v)
+		return nil
+	}
+	out := make([]int, 0, len(arr))
+	for _, item := range arr {
+		n, ok := item.(int64)
+		if !ok {
+			r.fail(key, "an array of integers", item)
+			return nil
+		}
+		out = append(out, int(n))
+	}
+	return out
+}
+
+// done reports the first type error, or any key that was never read (typos)
+func (r *reader) done() error {
+	if r.err != nil {
+		return r.err
+	}
+	for key := range r.t {
+		if !r.used[key] {
+			return fmt.Errorf("%s: unknown key %q", r.ctx, key)
+		}
+	}
+	return nil
+}
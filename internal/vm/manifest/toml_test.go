@@ -0,0 +1,134 @@
+package manifest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOMLValues(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		key  string
+		want any
+	}{
+		{"basic string", `k = "hello"`, "k", "hello"},
+		{"escapes", `k = "tab\there \"q\" back\\slash \u00e9"`, "k", "tab\there \"q\" back\\slash é"},
+		{"literal string keeps backslashes", `k = 'C:\path\n'`, "k", `C:\path\n`},
+		{"hash inside string", `k = "a # not a comment" # comment`, "k", "a # not a comment"},
+		{"hash inside literal", `k = 'x#y'`, "k", "x#y"},
+		{"integer", `k = 5432`, "k", int64(5432)},
+		{"negative integer", `k = -7`, "k", int64(-7)},
+		{"underscores", `k = 1_000_000`, "k", int64(1000000)},
+		{"true", `k = true`, "k", true},
+		{"false", `k = false`, "k", false},
+		{"array", `k = [1, 2, 3]`, "k", []any{int64(1), int64(2), int64(3)}},
+		{"array of strings with commas", `k = ["a,b", 'c]', "d"]`, "k", []any{"a,b", "c]", "d"}},
+		{"trailing comma", `k = ["a", "b",]`, "k", []any{"a", "b"}},
+		{"empty array", `k = []`, "k", []any(nil)},
+		{"multi-line array", "k = [\n  \"a\", # first\n  \"b\",\n]", "k", []any{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseTOML(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := doc.root[tt.key]; !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLTables(t *testing.T) {
+	doc, err := parseTOML(`
+name = "nextcloud"
+
+[build]
+memory = 2048
+
+[[test]]
+type = "tcp"
+port = 80
+
+[[test]]
+type = "http"
+path = "/status.php"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.root["name"] != "nextcloud" {
+		t.Fatalf("root = %v", doc.root)
+	}
+	if doc.tables["build"]["memory"] != int64(2048) {
+		t.Fatalf("[build] = %v", doc.tables["build"])
+	}
+	tests := doc.arrays["test"]
+	if len(tests) != 2 || tests[0]["port"] != int64(80) || tests[1]["path"] != "/status.php" {
+		t.Fatalf("[[test]] = %v", tests)
+	}
+	if _, ok := tests[0]["path"]; ok {
+		t.Fatal("keys leaked between [[test]] entries")
+	}
+
+	// The same key in different tables is not a duplicate
+	if _, err := parseTOML("[a]\nx = 1\n[b]\nx = 2"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string // Substring of the error, including the line number
+	}{
+		{"duplicate key", "a = 1\nb = 2\na = 3", `line 3: key "a" defined twice`},
+		{"duplicate key in table", "[t]\nx = 1\nx = 2", `line 3: key "x" defined twice`},
+		{"duplicate table", "[t]\n[t]", "line 2: table [t] defined twice"},
+		{"missing equals", "a = 1\nbogus", "line 2: expected key = value"},
+		{"missing key", " = 1", "line 1: missing key"},
+		{"missing value", "a =", "line 1: a: missing value"},
+		{"unterminated string", `a = "abc`, "line 1: a: unterminated string"},
+		{"unterminated literal", `a = 'abc`, "line 1: a: unterminated string"},
+		{"bad escape", `a = "\x41"`, `line 1: a: invalid escape \x`},
+		{"unterminated array", "a = [1,\n2,\n", `line 1: unterminated array for "a"`},
+		{"nested array", "a = [[1], [2]]", "line 1: a: nested arrays are not supported"},
+		{"text after array", "a = [1] 2", "line 1: a: unexpected text after array"},
+		{"inline table", "a = { b = 1 }", "line 1: a: inline tables are not supported"},
+		{"unterminated header", "[t", "line 1: unterminated [table] header"},
+		{"unterminated array header", "[[t]", "line 1: unterminated [[table]] header"},
+		{"empty header", "[ ]", "line 1: empty [table] name"},
+		{"float", "a = 1.5", "line 1: a: unsupported value 1.5"},
+		{"error after multi-line array", "a = [\n1,\n]\nb = ?", "line 4: b: unsupported value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML(tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReaderReportsTypeAndUnknownKeys(t *testing.T) {
+	doc, err := parseTOML("name = 5\ntypo = true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newReader(doc.root, "x.toml")
+	r.str("name")
+	if err := r.done(); err == nil || !strings.Contains(err.Error(), "x.toml: name must be a string") {
+		t.Fatalf("err = %v", err)
+	}
+
+	r = newReader(doc.root, "x.toml")
+	r.int("name")
+	if err := r.done(); err == nil || !strings.Contains(err.Error(), `unknown key "typo"`) {
+		t.Fatalf("err = %v", err)
+	}
+}
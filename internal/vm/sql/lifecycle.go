@@ -4,35 +4,47 @@
 package sql
 
 import (
+	"context"
+
 	"github.com/anthropics/sovereign/internal/vm/common"
 )
 
 // TEAM_029: Start delegates to common.StartVM
-func (v *VM) Start() error {
-	return common.StartVM(SQLConfig)
+func (v *VM) Start(ctx context.Context) error {
+	return common.StartVM(ctx, SQLConfig)
 }
 
 // TEAM_029: Stop delegates to common.StopVM
-func (v *VM) Stop() error {
-	return common.StopVM(SQLConfig)
+func (v *VM) Stop(ctx context.Context) error {
+	return common.StopVM(ctx, SQLConfig)
+}
+
+// TEAM_050: Pause delegates to common.PauseVM
+func (v *VM) Pause(ctx context.Context) error {
+	return common.PauseVM(ctx, SQLConfig)
+}
+
+// TEAM_050: Resume delegates to common.ResumeVM
+func (v *VM) Resume(ctx context.Context) error {
+	return common.ResumeVM(ctx, SQLConfig)
 }
 
 // TEAM_029: Remove delegates to common.RemoveVM
-func (v *VM) Remove() error {
-	return common.RemoveVM(SQLConfig)
+func (v *VM) Remove(ctx context.Context) error {
+	return common.RemoveVM(ctx, SQLConfig)
 }
 
 // TEAM_039: Clean delegates to common.CleanVM for Tailscale cleanup
-func (v *VM) Clean() error {
-	return common.CleanVM(SQLConfig)
+func (v *VM) Clean(ctx context.Context) error {
+	return common.CleanVM(ctx, SQLConfig)
 }
 
 // TEAM_041: Diagnose delegates to common.DiagnoseVM for comprehensive debugging
-func (v *VM) Diagnose() error {
-	return common.DiagnoseVM(SQLConfig)
+func (v *VM) Diagnose(ctx context.Context) (*common.Diagnosis, error) {
+	return common.DiagnoseVM(ctx, SQLConfig)
 }
 
 // TEAM_041: Fix delegates to common.FixVM for automatic issue repair
-func (v *VM) Fix() error {
-	return common.FixVM(SQLConfig)
+func (v *VM) Fix(ctx context.Context) (*common.FixReport, error) {
+	return common.FixVM(ctx, SQLConfig)
 }
@@ -3,6 +3,7 @@
 package sql
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"regexp"
@@ -14,8 +15,8 @@ import (
 )
 
 // TEAM_029: Test delegates to common.RunVMTests with SQL-specific custom tests
-func (v *VM) Test() error {
-	return common.RunVMTests(SQLConfig, sqlCustomTests)
+func (v *VM) Test(ctx context.Context) (*common.TestReport, error) {
+	return common.RunVMTests(ctx, SQLConfig, sqlCustomTests)
 }
 
 // TEAM_029: SQL-specific tests for PostgreSQL
@@ -24,28 +25,27 @@ var sqlCustomTests = []common.TestFunc{
 	testCanExecuteQuery,
 }
 
-func testPostgresResponding(cfg *common.VMConfig) common.TestResult {
-	out, _ := device.RunShellCommand(fmt.Sprintf("nc -z %s 5432 && echo OPEN || echo CLOSED", cfg.TAPGuestIP))
+func testPostgresResponding(ctx context.Context, cfg *common.VMConfig) common.TestResult {
+	out, _ := device.RunShellCommand(ctx, device.Quote("nc", "-z", cfg.TAPGuestIP, "5432")+" && echo OPEN || echo CLOSED")
 	if strings.TrimSpace(out) == "OPEN" {
 		return common.TestResult{Name: "PostgreSQL responding (via TAP)", Passed: true}
 	}
 	return common.TestResult{Name: "PostgreSQL responding (via TAP)", Passed: false, Message: "port 5432 not reachable on TAP"}
 }
 
-func testCanExecuteQuery(cfg *common.VMConfig) common.TestResult {
+func testCanExecuteQuery(ctx context.Context, cfg *common.VMConfig) common.TestResult {
 	creds, _ := secrets.LoadSecretsFile()
 	pgPassword := "sovereign"
 	if creds != nil {
 		pgPassword = creds.DBPassword
 	}
-	queryOut, _ := device.RunShellCommand(fmt.Sprintf(
-		"PGPASSWORD=%s psql -h %s -U postgres -c 'SELECT 1;' 2>&1 | grep -c '1 row'",
-		pgPassword, cfg.TAPGuestIP))
-	if strings.TrimSpace(queryOut) == "1" {
+	// TEAM_066: The password goes over stdin, not on the command line
+	queryOut, _ := common.DevicePsql(ctx, cfg.TAPGuestIP, 5432, "postgres", pgPassword, "-c", "SELECT 1;")
+	if strings.Contains(queryOut, "1 row") {
 		return common.TestResult{Name: "Can execute query (via TAP)", Passed: true}
 	}
 	// Fallback: check port
-	connOut, _ := device.RunShellCommand(fmt.Sprintf("nc -z %s 5432 && echo OK", cfg.TAPGuestIP))
+	connOut, _ := device.RunShellCommand(ctx, device.Quote("nc", "-z", cfg.TAPGuestIP, "5432")+" && echo OK")
 	if strings.Contains(connOut, "OK") {
 		return common.TestResult{Name: "Can execute query (via TAP)", Passed: true, Message: "port open, psql not available on device"}
 	}
@@ -89,8 +89,8 @@ func testCanExecuteQuery(cfg *common.VMConfig) common.TestResult {
 // This function is now only used by `sovereign remove --sql` for cleanup.
 // ============================================================================
 // TEAM_029: Delegated to common.RemoveTailscaleRegistrations
-func RemoveTailscaleRegistrations() error {
-	return common.RemoveTailscaleRegistrations("sovereign-sql")
+func RemoveTailscaleRegistrations(ctx context.Context) error {
+	return common.RemoveTailscaleRegistrations(ctx, "sovereign-sql")
 }
 
 // TEAM_019/TEAM_020: Preflight check to prevent duplicate Tailscale registrations
@@ -103,8 +103,8 @@ func RemoveTailscaleRegistrations() error {
 // See RemoveTailscaleRegistrations() for full bug documentation.
 // The user has requested this fix 10+ times. It still doesn't work.
 // ============================================================================
-func checkTailscaleRegistration() error {
-	out, err := exec.Command("tailscale", "status").Output()
+func checkTailscaleRegistration(ctx context.Context) error {
+	out, err := exec.CommandContext(ctx, "tailscale", "status").Output()
 	if err != nil {
 		// Tailscale not available on host - skip check but warn
 		fmt.Println("⚠ Warning: Cannot check Tailscale status (tailscale CLI not available)")
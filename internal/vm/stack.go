@@ -0,0 +1,301 @@
+// Dependency-ordered start/stop for the whole stack
+// TEAM_048: `sovereign up` / `sovereign down` - builds a DAG from
+// VMConfig.Dependencies instead of starting sql, forge, vault by hand
+package vm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// dependencies returns the registered services name depends on.
+// Dependencies on services that are not registered (external databases
+// reached over Tailscale) don't affect ordering; StartVM still checks them.
+func dependencies(name string) []string {
+	v, ok := Get(name)
+	if !ok {
+		return nil
+	}
+	c, ok := v.(configured)
+	if !ok {
+		return nil
+	}
+	var deps []string
+	for _, dep := range c.Config().Dependencies {
+		if _, registered := Get(dep.Name); registered && dep.Name != name {
+			deps = append(deps, dep.Name)
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// closure returns names plus everything they (transitively) depend on
+func closure(names []string) ([]string, error) {
+	seen := map[string]bool{}
+	var visit func(string) error
+	visit = func(n string) error {
+		if seen[n] {
+			return nil
+		}
+		if _, ok := Get(n); !ok {
+			return fmt.Errorf("unknown service %q (available: %s)", n, strings.Join(sortedList(), ", "))
+		}
+		seen[n] = true
+		for _, d := range dependencies(n) {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, n := range names {
+		if err := visit(n); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for n := range seen {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// dependents returns names plus everything that (transitively) depends on them
+func dependents(names []string) ([]string, error) {
+	all := sortedList()
+	seen := map[string]bool{}
+	var visit func(string)
+	visit = func(n string) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		for _, other := range all {
+			for _, d := range dependencies(other) {
+				if d == n {
+					visit(other)
+				}
+			}
+		}
+	}
+	for _, n := range names {
+		if _, ok := Get(n); !ok {
+			return nil, fmt.Errorf("unknown service %q (available: %s)", n, strings.Join(all, ", "))
+		}
+		visit(n)
+	}
+	out := make([]string, 0, len(seen))
+	for n := range seen {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func sortedList() []string {
+	names := List()
+	sort.Strings(names)
+	return names
+}
+
+// Waves orders services into start waves: every service in a wave depends
+// only on services in earlier waves, so each wave can start in parallel.
+// Returns an error naming the cycle if the graph is not a DAG.
+func Waves(names []string) ([][]string, error) {
+	indegree := map[string]int{}
+	users := map[string][]string{} // dep -> services needing it
+	inSet := map[string]bool{}
+	for _, n := range names {
+		inSet[n] = true
+	}
+	for _, n := range names {
+		indegree[n] += 0
+		for _, d := range dependencies(n) {
+			if !inSet[d] {
+				continue
+			}
+			indegree[n]++
+			users[d] = append(users[d], n)
+		}
+	}
+
+	var waves [][]string
+	done := 0
+	for done < len(names) {
+		var wave []string
+		for _, n := range names {
+			if indegree[n] == 0 {
+				wave = append(wave, n)
+			}
+		}
+		if len(wave) == 0 {
+			return nil, fmt.Errorf("dependency cycle: %s", findCycle(names, inSet))
+		}
+		sort.Strings(wave)
+		for _, n := range wave {
+			indegree[n] = -1
+			for _, u := range users[n] {
+				indegree[u]--
+			}
+		}
+		done += len(wave)
+		waves = append(waves, wave)
+	}
+	return waves, nil
+}
+
+// findCycle returns one cycle as "a -> b -> a" for the error message
+func findCycle(names []string, inSet map[string]bool) string {
+	const (
+		white = iota
+		grey
+		black
+	)
+	color := map[string]int{}
+	var stack []string
+	var cycle []string
+	var visit func(string) bool
+	visit = func(n string) bool {
+		color[n] = grey
+		stack = append(stack, n)
+		for _, d := range dependencies(n) {
+			if !inSet[d] {
+				continue
+			}
+			if color[d] == grey {
+				for i, s := range stack {
+					if s == d {
+						cycle = append(append([]string{}, stack[i:]...), d)
+						break
+					}
+				}
+				return true
+			}
+			if color[d] == white && visit(d) {
+				return true
+			}
+		}
+		stack = stack[:len(stack)-1]
+		color[n] = black
+		return false
+	}
+	for _, n := range names {
+		if color[n] == white && visit(n) {
+			return strings.Join(cycle, " -> ")
+		}
+	}
+	return "(unknown)"
+}
+
+// runWave runs op for every service in wave concurrently and returns
+// the failures keyed by service name
+func runWave(ctx context.Context, wave []string, op func(context.Context, VM) error) map[string]error {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed = map[string]error{}
+	)
+	for _, name := range wave {
+		v, _ := Get(name)
+		wg.Add(1)
+		go func(name string, v VM) {
+			defer wg.Done()
+			if err := op(ctx, v); err != nil {
+				mu.Lock()
+				failed[name] = err
+				mu.Unlock()
+			}
+		}(name, v)
+	}
+	wg.Wait()
+	return failed
+}
+
+// waveError summarizes failures from one wave
+func waveError(verb string, failed map[string]error) error {
+	names := make([]string, 0, len(failed))
+	for n := range failed {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	fmt.Fprintf(&b, "failed to %s %s", verb, strings.Join(names, ", "))
+	for _, n := range names {
+		fmt.Fprintf(&b, "\n  %s: %v", n, failed[n])
+	}
+	return fmt.Errorf("%s", b.String())
+}
+
+// Up starts the named services (all registered services if none are given)
+// plus their dependencies. Dependencies start first; StartVM returns only
+// once the service's readiness probes pass, so each wave waits for readiness
+// of the previous one. Services within a wave start in parallel.
+func Up(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		names = sortedList()
+	}
+	set, err := closure(names)
+	if err != nil {
+		return err
+	}
+	waves, err := Waves(set)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("=== Bringing up %s ===\n", strings.Join(set, ", "))
+	for i, wave := range waves {
+		fmt.Printf("\n--- Wave %d/%d: %s ---\n", i+1, len(waves), strings.Join(wave, ", "))
+		failed := runWave(ctx, wave, func(ctx context.Context, v VM) error {
+			return v.Start(ctx)
+		})
+		if len(failed) > 0 {
+			// Later waves depend on this one - don't start them against a broken base
+			return waveError("start", failed)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	fmt.Println("\n✓ Stack is up")
+	return nil
+}
+
+// Down stops the named services (all registered services if none are given)
+// plus everything that depends on them, in reverse dependency order.
+// Unlike Up, a failure doesn't abort: the remaining services are still stopped.
+func Down(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		names = sortedList()
+	}
+	set, err := dependents(names)
+	if err != nil {
+		return err
+	}
+	waves, err := Waves(set)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("=== Bringing down %s ===\n", strings.Join(set, ", "))
+	allFailed := map[string]error{}
+	for i := len(waves) - 1; i >= 0; i-- {
+		wave := waves[i]
+		fmt.Printf("\n--- Wave %d/%d: %s ---\n", len(waves)-i, len(waves), strings.Join(wave, ", "))
+		for n, err := range runWave(ctx, wave, func(ctx context.Context, v VM) error {
+			return v.Stop(ctx)
+		}) {
+			allFailed[n] = err
+		}
+	}
+	if len(allFailed) > 0 {
+		return waveError("stop", allFailed)
+	}
+	fmt.Println("\n✓ Stack is down")
+	return nil
+}
@@ -0,0 +1,179 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/sovereign/internal/vm/common"
+)
+
+// stubVM records Start/Stop calls; only its config matters for ordering
+type stubVM struct {
+	cfg  *common.VMConfig
+	log  *callLog
+	fail bool
+}
+
+type callLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *callLog) add(s string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, s)
+}
+
+func (v *stubVM) Name() string                     { return v.cfg.Name }
+func (v *stubVM) Config() *common.VMConfig         { return v.cfg }
+func (v *stubVM) Build(ctx context.Context) error  { return nil }
+func (v *stubVM) Deploy(ctx context.Context) error { return nil }
+func (v *stubVM) Start(ctx context.Context) error {
+	v.log.add("start " + v.cfg.Name)
+	if v.fail {
+		return errors.New("boom")
+	}
+	return nil
+}
+func (v *stubVM) Stop(ctx context.Context) error {
+	v.log.add("stop " + v.cfg.Name)
+	if v.fail {
+		return errors.New("boom")
+	}
+	return nil
+}
+func (v *stubVM) Pause(ctx context.Context) error                         { return nil }
+func (v *stubVM) Resume(ctx context.Context) error                        { return nil }
+func (v *stubVM) Test(ctx context.Context) (*common.TestReport, error)    { return nil, nil }
+func (v *stubVM) Remove(ctx context.Context) error                        { return nil }
+func (v *stubVM) Clean(ctx context.Context) error                         { return nil }
+func (v *stubVM) Diagnose(ctx context.Context) (*common.Diagnosis, error) { return nil, nil }
+func (v *stubVM) Fix(ctx context.Context) (*common.FixReport, error)      { return nil, nil }
+
+// withStack registers one stub per entry of graph (service -> dependencies)
+// for the duration of the test
+func withStack(t *testing.T, graph map[string][]string, failing ...string) *callLog {
+	t.Helper()
+	mu.Lock()
+	saved := registry
+	registry = map[string]VM{}
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		registry = saved
+		mu.Unlock()
+	})
+
+	log := &callLog{}
+	for name, deps := range graph {
+		cfg := &common.VMConfig{Name: name, TAPInterface: "vm_" + name}
+		for _, d := range deps {
+			cfg.Dependencies = append(cfg.Dependencies, common.ServiceDependency{Name: d})
+		}
+		v := &stubVM{cfg: cfg, log: log}
+		for _, f := range failing {
+			v.fail = v.fail || f == name
+		}
+		mu.Lock()
+		registry[name] = v
+		mu.Unlock()
+	}
+	return log
+}
+
+func TestWaves(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph map[string][]string
+		want  [][]string
+	}{
+		{"independent", map[string][]string{"a": nil, "b": nil},
+			[][]string{{"a", "b"}}},
+		{"sovereign stack", map[string][]string{"sql": nil, "forge": {"sql"}, "vault": {"sql"}},
+			[][]string{{"sql"}, {"forge", "vault"}}},
+		{"chain", map[string][]string{"a": nil, "b": {"a"}, "c": {"b"}},
+			[][]string{{"a"}, {"b"}, {"c"}}},
+		{"diamond", map[string][]string{"a": nil, "b": {"a"}, "c": {"a"}, "d": {"b", "c"}},
+			[][]string{{"a"}, {"b", "c"}, {"d"}}},
+		{"unregistered dependency is ignored", map[string][]string{"app": {"external-db"}},
+			[][]string{{"app"}}},
+		{"self dependency is ignored", map[string][]string{"a": {"a"}},
+			[][]string{{"a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStack(t, tt.graph)
+			got, err := Waves(sortedList())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWavesReportsCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph map[string][]string
+		want  string
+	}{
+		{"pair", map[string][]string{"a": {"b"}, "b": {"a"}}, "dependency cycle: a -> b -> a"},
+		{"triangle behind a root", map[string][]string{"root": nil, "x": {"root", "z"}, "y": {"x"}, "z": {"y"}},
+			"dependency cycle: x -> z -> y -> x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStack(t, tt.graph)
+			_, err := Waves(sortedList())
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClosureAndDependents(t *testing.T) {
+	withStack(t, map[string][]string{"sql": nil, "forge": {"sql"}, "vault": {"sql"}, "web": nil})
+
+	if got, err := closure([]string{"vault"}); err != nil || !reflect.DeepEqual(got, []string{"sql", "vault"}) {
+		t.Fatalf("closure(vault) = %v, %v", got, err)
+	}
+	if got, err := dependents([]string{"sql"}); err != nil || !reflect.DeepEqual(got, []string{"forge", "sql", "vault"}) {
+		t.Fatalf("dependents(sql) = %v, %v", got, err)
+	}
+	for _, f := range []func([]string) ([]string, error){closure, dependents} {
+		if _, err := f([]string{"nope"}); err == nil || !strings.Contains(err.Error(), `unknown service "nope"`) {
+			t.Fatalf("unknown service: err = %v", err)
+		}
+	}
+}
+
+func TestUpStopsAfterFailedWave(t *testing.T) {
+	log := withStack(t, map[string][]string{"sql": nil, "forge": {"sql"}}, "sql")
+	err := Up(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to start sql") {
+		t.Fatalf("err = %v", err)
+	}
+	if !reflect.DeepEqual(log.calls, []string{"start sql"}) {
+		t.Fatalf("calls = %v, want forge never started", log.calls)
+	}
+}
+
+func TestDownStopsDependentsFirstAndContinues(t *testing.T) {
+	log := withStack(t, map[string][]string{"sql": nil, "forge": {"sql"}}, "forge")
+	err := Down(context.Background(), []string{"sql"})
+	if err == nil || !strings.Contains(err.Error(), "failed to stop forge") {
+		t.Fatalf("err = %v", err)
+	}
+	if !reflect.DeepEqual(log.calls, []string{"stop forge", "stop sql"}) {
+		t.Fatalf("calls = %v", log.calls)
+	}
+}
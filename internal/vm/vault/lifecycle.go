@@ -3,35 +3,47 @@
 package vault
 
 import (
+	"context"
+
 	"github.com/anthropics/sovereign/internal/vm/common"
 )
 
 // Start delegates to common.StartVM
-func (v *VM) Start() error {
-	return common.StartVM(VaultConfig)
+func (v *VM) Start(ctx context.Context) error {
+	return common.StartVM(ctx, VaultConfig)
 }
 
 // Stop delegates to common.StopVM
-func (v *VM) Stop() error {
-	return common.StopVM(VaultConfig)
+func (v *VM) Stop(ctx context.Context) error {
+	return common.StopVM(ctx, VaultConfig)
+}
+
+// Pause delegates to common.PauseVM
+func (v *VM) Pause(ctx context.Context) error {
+	return common.PauseVM(ctx, VaultConfig)
+}
+
+// Resume delegates to common.ResumeVM
+func (v *VM) Resume(ctx context.Context) error {
+	return common.ResumeVM(ctx, VaultConfig)
 }
 
 // Remove delegates to common.RemoveVM
-func (v *VM) Remove() error {
-	return common.RemoveVM(VaultConfig)
+func (v *VM) Remove(ctx context.Context) error {
+	return common.RemoveVM(ctx, VaultConfig)
 }
 
 // TEAM_039: Clean delegates to common.CleanVM for Tailscale cleanup
-func (v *VM) Clean() error {
-	return common.CleanVM(VaultConfig)
+func (v *VM) Clean(ctx context.Context) error {
+	return common.CleanVM(ctx, VaultConfig)
 }
 
 // TEAM_041: Diagnose delegates to common.DiagnoseVM for comprehensive debugging
-func (v *VM) Diagnose() error {
-	return common.DiagnoseVM(VaultConfig)
+func (v *VM) Diagnose(ctx context.Context) (*common.Diagnosis, error) {
+	return common.DiagnoseVM(ctx, VaultConfig)
 }
 
 // TEAM_041: Fix delegates to common.FixVM for automatic issue repair
-func (v *VM) Fix() error {
-	return common.FixVM(VaultConfig)
+func (v *VM) Fix(ctx context.Context) (*common.FixReport, error) {
+	return common.FixVM(ctx, VaultConfig)
 }
@@ -3,6 +3,8 @@
 package vault
 
 import (
+	"context"
+
 	"github.com/anthropics/sovereign/internal/vm"
 	"github.com/anthropics/sovereign/internal/vm/common"
 )
@@ -10,22 +12,25 @@ import (
 // VaultConfig defines the configuration for the Vaultwarden VM
 // TEAM_034: Based on working Forgejo pattern
 var VaultConfig = &common.VMConfig{
-	Name:           "vault",
-	DisplayName:    "Vaultwarden",
-	TAPInterface:   "vm_vault",
-	TAPHostIP:      "192.168.100.1",
-	TAPGuestIP:     "192.168.100.4", // SQL=.2, Forge=.3, Vault=.4
-	TAPSubnet:      "192.168.100.0/24",
-	TailscaleHost:  "sovereign-vault",
-	DevicePath:     "/data/sovereign/vm/vault",
-	LocalPath:      "vm/vault",
-	ServicePorts:   []int{443, 80, 3012}, // TEAM_035: Added WebSocket port for browser extension sync
-	ReadyMarker:    "INIT COMPLETE",
-	StartTimeout:   120,
+	Name:          "vault",
+	DisplayName:   "Vaultwarden",
+	TAPInterface:  "vm_vault",
+	TAPGuestIP:    "192.168.100.4",
+	TailscaleHost: "sovereign-vault",
+	DevicePath:    "/data/sovereign/vm/vault",
+	LocalPath:     "vm/vault",
+	ServicePorts:  []int{443, 80, 3012}, // TEAM_035: Added WebSocket port for browser extension sync
+	ReadyMarker:   "INIT COMPLETE",
+	StartTimeout:  120,
+	// TEAM_053: INIT COMPLETE has been printed while the HTTPS listener was failing
+	ReadinessProbes: []common.ReadinessProbe{
+		{Type: common.ProbeTCP, Port: 443},
+		{Type: common.ProbeHTTP, Scheme: "https", Path: "/alive"},
+	},
 	DockerImage:    "sovereign-vault",
 	SharedKernel:   true,
 	KernelSource:   "vm/sql/Image",
-	NeedsSecrets:   true,                  // Vaultwarden needs database password
+	KernelSecrets:  map[string]string{"vaultwarden.db_password": "POSTGRES_VAULTWARDEN_PASSWORD"},
 	ProcessPattern: "[c]rosvm.*vm/vault/", // TEAM_036: Match path, not 'vault' (SQL cmdline has vaultwarden.db_password)
 	// Vaultwarden requires PostgreSQL for its database
 	Dependencies: []common.ServiceDependency{
@@ -42,12 +47,15 @@ type VM struct{}
 
 func (v *VM) Name() string { return "vault" }
 
+// Config exposes VaultConfig (used to detect manifest IP/TAP collisions)
+func (v *VM) Config() *common.VMConfig { return VaultConfig }
+
 // Build delegates to common.BuildVM
-func (v *VM) Build() error {
-	return common.BuildVM(VaultConfig, "")
+func (v *VM) Build(ctx context.Context) error {
+	return common.BuildVM(ctx, VaultConfig)
 }
 
 // Deploy delegates to common.DeployVM
-func (v *VM) Deploy() error {
-	return common.DeployVM(VaultConfig)
+func (v *VM) Deploy(ctx context.Context) error {
+	return common.DeployVM(ctx, VaultConfig)
 }
@@ -3,6 +3,7 @@
 package vault
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -11,8 +12,8 @@ import (
 )
 
 // Test delegates to common.RunVMTests with Vault-specific custom tests
-func (v *VM) Test() error {
-	return common.RunVMTests(VaultConfig, vaultCustomTests)
+func (v *VM) Test(ctx context.Context) (*common.TestReport, error) {
+	return common.RunVMTests(ctx, VaultConfig, vaultCustomTests)
 }
 
 // Vault-specific tests
@@ -22,13 +23,13 @@ var vaultCustomTests = []common.TestFunc{
 	testVaultwardenAPI,
 }
 
-func testVaultwardenWebUI(cfg *common.VMConfig) common.TestResult {
+func testVaultwardenWebUI(ctx context.Context, cfg *common.VMConfig) common.TestResult {
 	// TEAM_035: Get actual FQDN from tailscale status (may be sovereign-vault-1, -2, etc.)
-	fqdn := common.GetTailscaleFQDN(cfg)
+	fqdn := common.GetTailscaleFQDN(ctx, cfg)
 	if fqdn == "" {
 		return common.TestResult{Name: "Vaultwarden web UI (via Tailscale)", Passed: false, Message: "cannot determine Tailscale FQDN"}
 	}
-	cmd := exec.Command("curl", "-sk", "-o", "/dev/null", "-w", "%{http_code}",
+	cmd := exec.CommandContext(ctx, "curl", "-sk", "-o", "/dev/null", "-w", "%{http_code}",
 		"--connect-timeout", "5", fmt.Sprintf("https://%s", fqdn))
 	output, _ := cmd.Output()
 	httpCode := strings.TrimSpace(string(output))
@@ -38,13 +39,13 @@ func testVaultwardenWebUI(cfg *common.VMConfig) common.TestResult {
 	return common.TestResult{Name: "Vaultwarden web UI (via Tailscale)", Passed: false, Message: fmt.Sprintf("HTTP %s from %s", httpCode, fqdn)}
 }
 
-func testVaultwardenAPI(cfg *common.VMConfig) common.TestResult {
+func testVaultwardenAPI(ctx context.Context, cfg *common.VMConfig) common.TestResult {
 	// TEAM_035: Get actual FQDN from tailscale status
-	fqdn := common.GetTailscaleFQDN(cfg)
+	fqdn := common.GetTailscaleFQDN(ctx, cfg)
 	if fqdn == "" {
 		return common.TestResult{Name: "Vaultwarden API (via Tailscale)", Passed: false, Message: "cannot determine Tailscale FQDN"}
 	}
-	cmd := exec.Command("curl", "-sk", "-o", "/dev/null", "-w", "%{http_code}",
+	cmd := exec.CommandContext(ctx, "curl", "-sk", "-o", "/dev/null", "-w", "%{http_code}",
 		"--connect-timeout", "5", fmt.Sprintf("https://%s/api/config", fqdn))
 	output, _ := cmd.Output()
 	httpCode := strings.TrimSpace(string(output))
@@ -55,6 +56,6 @@ func testVaultwardenAPI(cfg *common.VMConfig) common.TestResult {
 }
 
 // RemoveTailscaleRegistrations delegates to common package
-func RemoveTailscaleRegistrations() error {
-	return common.RemoveTailscaleRegistrations("sovereign-vault")
+func RemoveTailscaleRegistrations(ctx context.Context) error {
+	return common.RemoveTailscaleRegistrations(ctx, "sovereign-vault")
 }
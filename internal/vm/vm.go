@@ -2,24 +2,44 @@
 // TEAM_010: Created during CLI refactor for VM abstraction
 package vm
 
-import "sync"
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/anthropics/sovereign/internal/vm/common"
+)
 
 // VM defines the interface for virtual machine operations
 // TEAM_011: Removed Prepare() - merged into Build() for simpler workflow
 // TEAM_039: Added Clean() for Tailscale registration cleanup
 // TEAM_041: Added Diagnose() for comprehensive debugging
 // TEAM_041: Added Fix() for automatic issue detection and repair
+// TEAM_044: All operations take a context - cancelling it aborts device commands,
+// pushes and boot waits, and a deadline bounds the whole operation
+// TEAM_045: Test, Diagnose and Fix return structured results for report.Write
+// TEAM_050: Added Pause()/Resume() (crosvm suspend/resume over vm.sock)
 type VM interface {
 	Name() string
-	Build() error    // Build VM image (includes rootfs preparation)
-	Deploy() error   // Deploy to device (idempotent - creates dirs if needed)
-	Start() error    // Start the VM
-	Stop() error     // Stop the VM
-	Test() error     // Test VM connectivity
-	Remove() error   // Remove VM from device
-	Clean() error    // Clean up Tailscale registrations
-	Diagnose() error // Run comprehensive diagnostics
-	Fix() error      // Auto-detect and fix common issues
+	Build(ctx context.Context) error                         // Build VM image (includes rootfs preparation)
+	Deploy(ctx context.Context) error                        // Deploy to device (idempotent - creates dirs if needed)
+	Start(ctx context.Context) error                         // Start the VM
+	Stop(ctx context.Context) error                          // Stop the VM
+	Pause(ctx context.Context) error                         // Suspend vCPUs, keeping guest state
+	Resume(ctx context.Context) error                        // Resume a paused VM
+	Test(ctx context.Context) (*common.TestReport, error)    // Test VM connectivity
+	Remove(ctx context.Context) error                        // Remove VM from device
+	Clean(ctx context.Context) error                         // Clean up Tailscale registrations
+	Diagnose(ctx context.Context) (*common.Diagnosis, error) // Run comprehensive diagnostics
+	Fix(ctx context.Context) (*common.FixReport, error)      // Auto-detect and fix common issues
+}
+
+// SignalContext returns a context cancelled on Ctrl-C (SIGINT) or SIGTERM.
+// TEAM_044: The CLI passes this to VM operations so Ctrl-C aborts cleanly.
+func SignalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 }
 
 var (
@@ -27,11 +47,32 @@ var (
 	mu       sync.RWMutex
 )
 
+// configured is implemented by VMs backed by a common.VMConfig
+type configured interface {
+	Config() *common.VMConfig
+}
+
 // Register registers a VM implementation
+// TEAM_047: Also records its VMConfig so IPAM sees every VM's addressing
 func Register(name string, vm VM) {
 	mu.Lock()
 	defer mu.Unlock()
 	registry[name] = vm
+	if c, ok := vm.(configured); ok {
+		common.RegisterConfig(c.Config())
+	}
+}
+
+// CheckAddressing reports guest IP or TAP collisions between registered VMs,
+// and registered VMs whose readiness probes are invalid
+func CheckAddressing() error {
+	cfgs := common.RegisteredConfigs()
+	for _, cfg := range cfgs {
+		if _, err := common.ReadinessProbes(cfg); err != nil {
+			return err
+		}
+	}
+	return common.CheckIPCollisions(cfgs)
 }
 
 // Get returns a VM by name
@@ -42,6 +83,21 @@ func Get(name string) (VM, bool) {
 	return vm, ok
 }
 
+// Config returns the VMConfig behind a registered VM, for commands that work
+// on any VM without being part of the VM interface (sbom)
+// TEAM_057: Added for `sovereign sbom --<vm>`
+func Config(name string) (*common.VMConfig, bool) {
+	v, ok := Get(name)
+	if !ok {
+		return nil, false
+	}
+	c, ok := v.(configured)
+	if !ok {
+		return nil, false
+	}
+	return c.Config(), true
+}
+
 // List returns all registered VM names
 func List() []string {
 	mu.RLock()
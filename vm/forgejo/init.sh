@@ -97,8 +97,19 @@ mkdir -p /dev/net
 chmod 666 /dev/net/tun
 
 # TEAM_030: Configure TAP networking (bridge-based)
-# All VMs on same 192.168.100.x subnet via shared bridge
-# SQL VM: 192.168.100.2, Forge VM: 192.168.100.3, Gateway: 192.168.100.1
+# All VMs on same subnet via shared bridge
+# TEAM_047: Address from IPAM (sovereign.ip/sovereign.gw on the kernel cmdline),
+# falling back to the historical static assignment
+NET_CIDR="192.168.100.3/24"
+NET_GW="192.168.100.1"
+SQL_HOST="192.168.100.2"
+for param in $(cat /proc/cmdline); do
+    case "$param" in
+        sovereign.ip=*) NET_CIDR="${param#sovereign.ip=}" ;;
+        sovereign.gw=*) NET_GW="${param#sovereign.gw=}" ;;
+        sovereign.sql=*) SQL_HOST="${param#sovereign.sql=}" ;;
+    esac
+done
 echo "=== Configuring TAP Network ==="
 sleep 1
 
@@ -124,12 +135,11 @@ fi
 echo "Found interface: $IFACE"
 
 if [ -n "$IFACE" ]; then
-    # TEAM_030: Use 192.168.100.3 - same subnet as SQL VM (192.168.100.2)
-    ip addr add 192.168.100.3/24 dev "$IFACE"
+    ip addr add "$NET_CIDR" dev "$IFACE"
     ip link set "$IFACE" up
-    ip route add default via 192.168.100.1
+    ip route add default via "$NET_GW"
     echo "nameserver 8.8.8.8" > /etc/resolv.conf
-    echo "Network configured on $IFACE (192.168.100.3)"
+    echo "Network configured on $IFACE ($NET_CIDR)"
     ip addr show "$IFACE"
     ip route show
 else
@@ -217,8 +227,8 @@ fi
 # ============================================================================
 echo "=== Waiting for PostgreSQL ==="
 # TEAM_029: Use TAP IP for VM-to-VM (Tailscale userspace can't initiate outgoing)
-# SQL VM TAP IP: 192.168.100.2, routed via Android host gateway
-DB_HOST="192.168.100.2"
+# SQL VM TAP IP from the kernel cmdline, routed via Android host gateway
+DB_HOST="$SQL_HOST"
 DB_PORT="5432"
 DB_USER="forgejo"
 DB_NAME="forgejo"
@@ -349,6 +359,31 @@ log "=== INIT COMPLETE ==="
 # If either dies (OOM, crash, anything), restart it automatically.
 # Reference: Field Guide Section 6 - "No process supervision in VM"
 # ============================================================================
+# TEAM_049: Clean shutdown on power button / poweroff (host: crosvm powerbtn)
+shutdown_guest() {
+    log "Shutdown requested - stopping Forgejo"
+    kill $FORGEJO_PID $TAILSCALED_PID 2>/dev/null
+    wait $FORGEJO_PID 2>/dev/null
+    sync
+    log "=== SHUTDOWN COMPLETE ==="
+    poweroff -f
+}
+trap shutdown_guest TERM INT PWR USR2
+
+# TEAM_049: crosvm powerbtn only presses KEY_POWER on the guest's input device;
+# nothing in the kernel turns that into a shutdown. busybox acpid maps it to
+# /etc/acpi/power.sh, which sends this PID 1 the USR2 that busybox poweroff
+# uses, so shutdown_guest runs. Without acpid, stop escalates to crosvm stop.
+mkdir -p /etc/acpi
+printf '#!/bin/sh\nkill -USR2 1\n' > /etc/acpi/power.sh
+chmod +x /etc/acpi/power.sh
+printf 'PWRF power.sh\nPWRB power.sh\n' > /etc/acpid.conf
+if acpid -c /etc/acpi -a /etc/acpid.conf 2>/dev/null; then
+    log "Power button handler started (acpid)"
+else
+    log "WARNING: acpid not available - power button ignored"
+fi
+
 while true; do
     # Check Forgejo
     if ! kill -0 $FORGEJO_PID 2>/dev/null; then
@@ -371,5 +406,6 @@ while true; do
         # TEAM_034: tailscale serve removed - direct port binding works
     fi
     
-    sleep 30
+    # Background sleep so the shutdown trap fires immediately
+    sleep 30 & wait $!
 done
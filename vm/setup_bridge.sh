@@ -6,6 +6,12 @@
 BRIDGE_NAME="vm_bridge"
 BRIDGE_IP="192.168.100.1"
 BRIDGE_SUBNET="24"
+VM_SUBNET="192.168.100.0/24"
+# TEAM_047: network.env (from the CLI's IPAM) overrides the defaults above
+if [ -f /data/sovereign/network.env ]; then
+    . /data/sovereign/network.env
+    BRIDGE_SUBNET="${BRIDGE_CIDR#*/}"
+fi
 
 # Check if bridge already exists
 if ip link show ${BRIDGE_NAME} >/dev/null 2>&1; then
@@ -38,8 +44,8 @@ if [ -n "$GATEWAY" ]; then
 fi
 
 # NAT for VM traffic to internet
-iptables -t nat -D POSTROUTING -s 192.168.100.0/24 -o wlan0 -j MASQUERADE 2>/dev/null || true
-iptables -t nat -A POSTROUTING -s 192.168.100.0/24 -o wlan0 -j MASQUERADE
+iptables -t nat -D POSTROUTING -s ${VM_SUBNET} -o wlan0 -j MASQUERADE 2>/dev/null || true
+iptables -t nat -A POSTROUTING -s ${VM_SUBNET} -o wlan0 -j MASQUERADE
 
 # FORWARD rules for bridge traffic to internet
 iptables -D FORWARD -i ${BRIDGE_NAME} -o wlan0 -j ACCEPT 2>/dev/null || true
@@ -48,4 +54,4 @@ iptables -I FORWARD 1 -i ${BRIDGE_NAME} -o wlan0 -j ACCEPT
 iptables -I FORWARD 2 -i wlan0 -o ${BRIDGE_NAME} -m state --state RELATED,ESTABLISHED -j ACCEPT
 
 echo "Bridge ${BRIDGE_NAME} created with IP ${BRIDGE_IP}/${BRIDGE_SUBNET}"
-echo "VMs should use 192.168.100.x addresses (gateway: ${BRIDGE_IP})"
+echo "VMs should use ${VM_SUBNET} addresses (gateway: ${BRIDGE_IP})"
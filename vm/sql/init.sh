@@ -87,7 +87,18 @@ mkdir -p /dev/net
 chmod 666 /dev/net/tun
 
 # TEAM_016/017: Configure TAP networking
-# Host TAP is 192.168.100.1, guest is 192.168.100.2
+# TEAM_047: Address from IPAM (sovereign.ip/sovereign.gw on the kernel cmdline),
+# falling back to the historical static assignment
+NET_CIDR="192.168.100.2/24"
+NET_GW="192.168.100.1"
+SQL_HOST="192.168.100.2"
+for param in $(cat /proc/cmdline); do
+    case "$param" in
+        sovereign.ip=*) NET_CIDR="${param#sovereign.ip=}" ;;
+        sovereign.gw=*) NET_GW="${param#sovereign.gw=}" ;;
+        sovereign.sql=*) SQL_HOST="${param#sovereign.sql=}" ;;
+    esac
+done
 echo "=== Configuring TAP Network ==="
 # TEAM_023: Reduced sleep - interface should be ready immediately after /sys mount
 sleep 1
@@ -115,9 +126,9 @@ fi
 echo "Found interface: $IFACE"
 
 if [ -n "$IFACE" ]; then
-    ip addr add 192.168.100.2/24 dev "$IFACE"
+    ip addr add "$NET_CIDR" dev "$IFACE"
     ip link set "$IFACE" up
-    ip route add default via 192.168.100.1
+    ip route add default via "$NET_GW"
     echo "nameserver 8.8.8.8" > /etc/resolv.conf
     echo "Network configured on $IFACE"
     ip addr show "$IFACE"
@@ -131,11 +142,11 @@ echo "=== Testing Network ==="
 ping -c 2 8.8.8.8 2>&1 || echo "Ping failed - will retry after Tailscale"
 
 # TEAM_037: Tailscale REMOVED from SQL VM
-# Forge and Vault connect via TAP network (192.168.100.2:5432), not Tailscale.
+# Forge and Vault connect via TAP network (port 5432), not Tailscale.
 # This simplifies the SQL VM and removes unnecessary complexity.
 # If external Tailnet access to PostgreSQL is needed later, re-enable this section.
 echo "=== Tailscale Disabled (not needed for SQL) ==="
-echo "Forge/Vault connect via TAP: 192.168.100.2:5432"
+echo "Forge/Vault connect via TAP: ${NET_CIDR%/*}:5432"
 
 # TEAM_037: Sync time via NTP directly (no Tailscale needed)
 if command -v ntpd >/dev/null 2>&1; then
@@ -231,6 +242,31 @@ su postgres -c "psql -c \"SELECT version();\"" 2>&1
 log "PostgreSQL started"
 log "=== INIT COMPLETE ==="
 
+# TEAM_049: Clean shutdown on power button / poweroff (host: crosvm powerbtn).
+# busybox poweroff signals PID 1 with USR2; acpid below delivers the power button.
+shutdown_guest() {
+    log "Shutdown requested - stopping PostgreSQL"
+    su postgres -c "pg_ctl -D /data/postgres -m fast -w stop" 2>&1
+    sync
+    log "=== SHUTDOWN COMPLETE ==="
+    poweroff -f
+}
+trap shutdown_guest TERM INT PWR USR2
+
+# TEAM_049: crosvm powerbtn only presses KEY_POWER on the guest's input device;
+# nothing in the kernel turns that into a shutdown. busybox acpid maps it to
+# /etc/acpi/power.sh, which sends this PID 1 the USR2 that busybox poweroff
+# uses, so shutdown_guest runs. Without acpid, stop escalates to crosvm stop.
+mkdir -p /etc/acpi
+printf '#!/bin/sh\nkill -USR2 1\n' > /etc/acpi/power.sh
+chmod +x /etc/acpi/power.sh
+printf 'PWRF power.sh\nPWRB power.sh\n' > /etc/acpid.conf
+if acpid -c /etc/acpi -a /etc/acpid.conf 2>/dev/null; then
+    log "Power button handler started (acpid)"
+else
+    log "WARNING: acpid not available - power button ignored"
+fi
+
 # TEAM_037: Supervision loop for PostgreSQL only (Tailscale removed)
 # If PostgreSQL dies (OOM, crash, anything), restart it automatically.
 # Reference: Field Guide Section 6 - "No process supervision in VM"
@@ -244,5 +280,6 @@ while true; do
         sleep 5
     fi
     
-    sleep 30
+    # Background sleep so the shutdown trap fires immediately
+    sleep 30 & wait $!
 done
@@ -13,6 +13,10 @@ TAP_NAME="vm_sql"
 # TEAM_030: Bridge-based networking - all VMs on same subnet
 BRIDGE_NAME="vm_bridge"
 BRIDGE_IP="192.168.100.1"
+BRIDGE_CIDR="${BRIDGE_IP}/24"
+VM_SUBNET="192.168.100.0/24"
+# TEAM_047: network.env (from the CLI's IPAM) overrides the defaults above
+[ -f "${SOVEREIGN_DIR}/network.env" ] && . "${SOVEREIGN_DIR}/network.env"
 
 # Load auth key if exists
 [ -f "${SOVEREIGN_DIR}/.env" ] && . ${SOVEREIGN_DIR}/.env
@@ -33,7 +37,7 @@ sleep 1
 if ! ip link show ${BRIDGE_NAME} >/dev/null 2>&1; then
     echo "Creating shared VM bridge: ${BRIDGE_NAME}"
     ip link add ${BRIDGE_NAME} type bridge
-    ip addr add ${BRIDGE_IP}/24 dev ${BRIDGE_NAME}
+    ip addr add ${BRIDGE_CIDR} dev ${BRIDGE_NAME}
     ip link set ${BRIDGE_NAME} up
 fi
 
@@ -60,8 +64,8 @@ if [ -n "$GATEWAY" ]; then
 fi
 
 # NAT for VM traffic to internet
-iptables -t nat -D POSTROUTING -s 192.168.100.0/24 -o wlan0 -j MASQUERADE 2>/dev/null || true
-iptables -t nat -A POSTROUTING -s 192.168.100.0/24 -o wlan0 -j MASQUERADE
+iptables -t nat -D POSTROUTING -s ${VM_SUBNET} -o wlan0 -j MASQUERADE 2>/dev/null || true
+iptables -t nat -A POSTROUTING -s ${VM_SUBNET} -o wlan0 -j MASQUERADE
 
 # FORWARD rules for bridge traffic
 iptables -D FORWARD -i ${BRIDGE_NAME} -o wlan0 -j ACCEPT 2>/dev/null || true